@@ -14,14 +14,22 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
+	"github.com/banking/user-service/internal/addressvalidation"
 	apihttp "github.com/banking/user-service/internal/api/http"
+	"github.com/banking/user-service/internal/auditlog"
 	"github.com/banking/user-service/internal/config"
 	"github.com/banking/user-service/internal/crypto"
 	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/jobs"
 	"github.com/banking/user-service/internal/pkg/health"
 	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/pkg/metrics"
 	"github.com/banking/user-service/internal/pkg/tracer"
+	"github.com/banking/user-service/internal/repository/mongodb"
 	"github.com/banking/user-service/internal/repository/postgres"
 	rediscache "github.com/banking/user-service/internal/repository/redis"
 	"github.com/banking/user-service/internal/resilience"
@@ -88,6 +96,13 @@ func run() error {
 		defer tr.Shutdown(ctx)
 	}
 
+	// Job counters are reported to the log
+	mp := metrics.New(metrics.Config{
+		Enabled:  cfg.Metrics.Enabled,
+		Interval: cfg.Metrics.Interval,
+	}, log)
+	defer mp.Shutdown(ctx)
+
 	// Initialize circuit breakers
 	circuitBreakers := resilience.NewCircuitBreakers()
 
@@ -108,6 +123,19 @@ func run() error {
 	})
 	defer redisClient.Close()
 
+	// Initialize MongoDB client
+	mongoClient, err := initMongo(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer func() {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer disconnectCancel()
+		if err := mongoClient.Disconnect(disconnectCtx); err != nil {
+			log.Warn("failed to disconnect from MongoDB", logger.ErrorField(err))
+		}
+	}()
+
 	// Initialize encryption
 	encryptor, err := crypto.NewFieldEncryptor(
 		cfg.Encryption.EncryptionKeysBase64,
@@ -126,12 +154,24 @@ func run() error {
 	healthChecker.Register("redis", health.RedisChecker(func(ctx context.Context) error {
 		return redisClient.Ping(ctx).Err()
 	}))
+	healthChecker.Register("mongodb", health.MongoChecker(func(ctx context.Context) error {
+		return mongoClient.Ping(ctx, readpref.Primary())
+	}))
 
 	// Initialize repositories
 	userRepo := postgres.NewUserRepository(pgPool, encryptor, circuitBreakers.Postgres)
 	addressRepo := postgres.NewAddressRepository(pgPool, encryptor, circuitBreakers.Postgres)
 	deviceRepo := postgres.NewDeviceRepository(pgPool, encryptor, circuitBreakers.Postgres)
+	erasureRepo := postgres.NewErasureRepository(pgPool, circuitBreakers.Postgres)
+	consentRepo := postgres.NewConsentRepository(pgPool, encryptor, circuitBreakers.Postgres)
 	userCache := rediscache.NewUserCache(redisClient, circuitBreakers.Redis, cfg.Redis.DefaultTTL)
+	otpStore := rediscache.NewOTPStore(redisClient, circuitBreakers.Redis)
+	exportStore := rediscache.NewDataExportStore(redisClient, encryptor, circuitBreakers.Redis)
+	prefRepo := mongodb.NewPreferenceRepository(mongoClient.Database(cfg.MongoDB.Database), circuitBreakers.MongoDB)
+	if err := prefRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	flagRepo := mongodb.NewFeatureFlagRepository(mongoClient.Database(cfg.MongoDB.Database), circuitBreakers.MongoDB)
 
 	// Initialize Kafka audit producer
 	auditProducer, err := events.NewAuditProducer(events.AuditProducerConfig{
@@ -147,29 +187,236 @@ func run() error {
 		defer auditProducer.Close()
 	}
 
+	// Initialize Kafka domain event producer
+	eventProducer, err := events.NewEventProducer(cfg.Kafka.Brokers, cfg.Kafka.EventTopic, circuitBreakers.Kafka, log)
+	if err != nil {
+		log.Warn("failed to create event producer, domain events will be dropped", logger.ErrorField(err))
+	} else {
+		defer eventProducer.Close()
+	}
+
+	// Device changes go to their own topic, consumed by the fraud service
+	deviceEventProducer, err := events.NewEventProducer(cfg.Kafka.Brokers, cfg.Kafka.DeviceTopic, circuitBreakers.Kafka, log)
+	if err != nil {
+		log.Warn("failed to create device event producer, device changes will be dropped", logger.ErrorField(err))
+	} else {
+		defer deviceEventProducer.Close()
+	}
+
+	// Contact notifications carry tokens, codes and raw contact details, so only the
+	// notification service reads their topic
+	notificationProducer, err := events.NewEventProducer(cfg.Kafka.Brokers, cfg.Kafka.NotificationTopic, circuitBreakers.Kafka, log)
+	if err != nil {
+		log.Warn("failed to create notification producer, notifications will be dropped", logger.ErrorField(err))
+	} else {
+		defer notificationProducer.Close()
+	}
+
 	// Initialize services
 	hmacSecret := []byte(cfg.Encryption.AuditHMACSecret)
+	notifier := service.NewNotifier(notificationProducer, log)
 	userService := service.NewUserService(
 		userRepo,
+		prefRepo,
+		userCache,
+		auditProducer,
+		eventProducer,
+		notifier,
+		log,
+		hmacSecret,
+		service.UserServiceConfig{
+			EmailChangeTokenTTL: cfg.Account.EmailChangeTokenTTL,
+			RestoreGracePeriod:  cfg.Account.RestoreGracePeriod,
+		},
+	)
+	phoneService := service.NewPhoneVerificationService(
+		userRepo,
+		otpStore,
 		userCache,
 		auditProducer,
+		eventProducer,
+		notifier,
 		log,
 		hmacSecret,
+		service.PhoneVerificationConfig{
+			CodeTTL:        cfg.Account.PhoneOTPTTL,
+			ResendCooldown: cfg.Account.PhoneOTPResendCooldown,
+			MaxAttempts:    cfg.Account.PhoneOTPMaxAttempts,
+			Lockout:        cfg.Account.PhoneOTPLockout,
+		},
 	)
 	addressService := service.NewAddressService(
 		addressRepo,
+		deviceRepo,
+		userRepo,
 		auditProducer,
+		notifier,
 		log,
 		hmacSecret,
+		service.AddressServiceConfig{
+			ChangeConfirmationTTL: cfg.Account.AddressChangeConfirmationTTL,
+			ChangeVelocityWindow:  cfg.Account.AddressChangeVelocityWindow,
+			MaxChangesPerWindow:   cfg.Account.AddressChangeMaxPerWindow,
+		},
 	)
+	deviceRiskScorer := service.NewDeviceRiskScorer(
+		deviceRepo,
+		userRepo,
+		userCache,
+		auditProducer,
+		eventProducer,
+		log,
+		hmacSecret,
+		service.DeviceRiskConfig{
+			Threshold:         cfg.Devices.RiskThreshold,
+			RapidChangeWindow: cfg.Devices.RapidChangeWindow,
+		},
+	)
+	deviceFeed := service.NewDeviceFeed(deviceEventProducer, log)
 	deviceService := service.NewDeviceService(
+		deviceRepo,
+		deviceRiskScorer,
+		deviceFeed,
+		auditProducer,
+		log,
+		hmacSecret,
+		service.DeviceServiceConfig{
+			MaxDevicesPerUser: cfg.Devices.MaxPerUser,
+			HeartbeatInterval: cfg.Devices.HeartbeatInterval,
+		},
+	)
+	deviceTrustService := service.NewDeviceTrustService(
+		deviceRepo,
+		userRepo,
+		otpStore,
+		deviceFeed,
+		auditProducer,
+		eventProducer,
+		notifier,
+		log,
+		hmacSecret,
+		service.DeviceTrustConfig{
+			TrustTTL:       cfg.Devices.TrustTTL,
+			StepUpMaxAge:   cfg.Devices.StepUpMaxAge,
+			StepUpACRs:     cfg.Devices.StepUpACRs,
+			CodeTTL:        cfg.Account.PhoneOTPTTL,
+			ResendCooldown: cfg.Account.PhoneOTPResendCooldown,
+			MaxAttempts:    cfg.Account.PhoneOTPMaxAttempts,
+			Lockout:        cfg.Account.PhoneOTPLockout,
+		},
+	)
+	pushTokenService := service.NewPushTokenService(deviceRepo, auditProducer, log, hmacSecret)
+	erasureService := service.NewErasureService(
+		erasureRepo,
+		prefRepo,
+		exportStore,
+		userCache,
+		auditProducer,
+		eventProducer,
+		log,
+		hmacSecret,
+		service.ErasureConfig{
+			RetentionPeriod: cfg.Account.ErasureRetention,
+			BatchSize:       cfg.Jobs.ErasureBatchSize,
+		},
+	)
+
+	// Local rules always run; the provider, when configured, can overrule them
+	addressValidators := []addressvalidation.AddressValidator{addressvalidation.NewLocalValidator()}
+	if cfg.AddressValidation.ProviderURL != "" {
+		addressValidators = append(addressValidators, addressvalidation.NewHTTPValidator(addressvalidation.HTTPValidatorConfig{
+			URL:     cfg.AddressValidation.ProviderURL,
+			APIKey:  cfg.AddressValidation.ProviderAPIKey,
+			Source:  cfg.AddressValidation.ProviderName,
+			Timeout: cfg.AddressValidation.ProviderTimeout,
+		}, circuitBreakers.AddressValidation))
+	}
+	addressValidationService := service.NewAddressValidationService(
+		addressRepo,
+		addressvalidation.NewPipeline(addressValidators...),
+		log,
+		service.AddressValidationConfig{
+			BatchSize: cfg.Jobs.AddressValidationBatchSize,
+		},
+	)
+	addressExpiryService := service.NewAddressExpiryService(
+		addressRepo,
+		auditProducer,
+		eventProducer,
+		log,
+		hmacSecret,
+		service.AddressExpiryConfig{
+			BatchSize: cfg.Jobs.AddressExpiryBatchSize,
+		},
+	)
+	devicePruneService := service.NewDevicePruneService(
+		deviceRepo,
+		deviceFeed,
+		auditProducer,
+		eventProducer,
+		log,
+		hmacSecret,
+		service.DevicePruneConfig{
+			InactiveDays: cfg.Devices.InactiveDays,
+			BatchSize:    cfg.Jobs.DevicePruneBatchSize,
+		},
+	)
+	// Without the audit store, exports still complete but list audit_events as incomplete
+	var auditReader service.AuditLogReader
+	if cfg.AuditStore.URL != "" {
+		auditReader = auditlog.NewHTTPReader(auditlog.HTTPReaderConfig{
+			URL:     cfg.AuditStore.URL,
+			APIKey:  cfg.AuditStore.APIKey,
+			Timeout: cfg.AuditStore.Timeout,
+		}, circuitBreakers.AuditStore)
+	} else {
+		log.Warn("audit store not configured, data exports will not include audit events")
+	}
+	exportService := service.NewDataExportService(
+		userRepo,
+		addressRepo,
+		deviceRepo,
+		consentRepo,
+		prefRepo,
+		auditReader,
+		exportStore,
+		auditProducer,
+		log,
+		hmacSecret,
+		service.DataExportConfig{
+			BundleTTL: cfg.Account.DataExportTTL,
+			Timeout:   cfg.Account.DataExportTimeout,
+		},
+	)
+	consentService := service.NewConsentService(
+		consentRepo,
+		userRepo,
+		auditProducer,
+		eventProducer,
+		notifier,
+		log,
+		hmacSecret,
+		service.ConsentConfig{
+			ConfirmationTTL: cfg.Account.ConsentConfirmationTTL,
+		},
+	)
+	prefService := service.NewPreferenceService(
+		prefRepo,
+		phoneService,
+		consentService,
+		auditProducer,
+		log,
+		hmacSecret,
+	)
+	flagService := service.NewFeatureFlagService(
+		flagRepo,
+		prefRepo,
+		userRepo,
 		deviceRepo,
 		auditProducer,
 		log,
 		hmacSecret,
 	)
-	// Preference service uses interface - would need MongoDB repo
-	// For now, pass nil and handle gracefully in router
 
 	// Load JWT public key
 	authPublicKey, err := loadPublicKey(cfg.Auth.JWTPublicKeyPath)
@@ -183,9 +430,15 @@ func run() error {
 		Logger:         log,
 		Health:         healthChecker,
 		UserService:    userService,
+		PhoneService:   phoneService,
+		ExportService:  exportService,
 		AddressService: addressService,
 		DeviceService:  deviceService,
-		PrefService:    nil, // TODO: Initialize with MongoDB repo
+		TrustService:   deviceTrustService,
+		PushService:    pushTokenService,
+		PrefService:    prefService,
+		ConsentService: consentService,
+		FlagService:    flagService,
 		RedisClient:    redisClient,
 		CircuitBreaker: circuitBreakers.Redis,
 		AuthPublicKey:  authPublicKey,
@@ -200,6 +453,14 @@ func run() error {
 		}
 	}()
 
+	// Start background jobs
+	scheduler := jobs.NewScheduler(log)
+	scheduler.Register(jobs.NewErasureJob(erasureService, log), cfg.Jobs.ErasureInterval)
+	scheduler.Register(jobs.NewAddressValidationJob(addressValidationService, log), cfg.Jobs.AddressValidationInterval)
+	scheduler.Register(jobs.NewAddressExpiryJob(addressExpiryService, log), cfg.Jobs.AddressExpiryInterval)
+	scheduler.Register(jobs.Exclusive(jobs.NewDevicePruneJob(devicePruneService, log), postgres.NewAdvisoryLocker(pgPool), log), cfg.Jobs.DevicePruneInterval)
+	scheduler.Start(ctx)
+
 	// Wait for shutdown signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -211,9 +472,18 @@ func run() error {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer shutdownCancel()
 
-	if err := router.Shutdown(shutdownCtx); err != nil {
-		log.Error("Server forced to shutdown", logger.ErrorField(err))
-		return err
+	scheduler.Stop()
+
+	routerErr := router.Shutdown(shutdownCtx)
+
+	// Exports are started by requests, so they are drained once no more can arrive
+	if err := exportService.Shutdown(shutdownCtx); err != nil {
+		log.Warn("data exports cancelled at shutdown", logger.ErrorField(err))
+	}
+
+	if routerErr != nil {
+		log.Error("Server forced to shutdown", logger.ErrorField(routerErr))
+		return routerErr
 	}
 
 	log.Info("Server exited gracefully")
@@ -245,6 +515,28 @@ func initPostgres(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error
 	return pool, nil
 }
 
+func initMongo(ctx context.Context, cfg *config.Config) (*mongo.Client, error) {
+	clientOpts := options.Client().
+		ApplyURI(cfg.MongoDB.URI).
+		SetConnectTimeout(cfg.MongoDB.ConnectTimeout).
+		SetMaxPoolSize(cfg.MongoDB.MaxPoolSize)
+
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test connection
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.MongoDB.ConnectTimeout)
+	defer cancel()
+	if err := client.Ping(pingCtx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	return client, nil
+}
+
 func loadPublicKey(path string) (*rsa.PublicKey, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
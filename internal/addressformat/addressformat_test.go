@@ -0,0 +1,102 @@
+package addressformat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/banking/user-service/internal/domain"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   domain.AddressData
+		want domain.AddressData
+	}{
+		{
+			name: "US casing, state name and ZIP+4",
+			in:   domain.AddressData{StreetLine1: "  1600 PENNSYLVANIA  AVE NW ", City: "washington", State: "district of columbia", PostalCode: "205000003", Country: "us"},
+			want: domain.AddressData{StreetLine1: "1600 Pennsylvania Ave NW", City: "Washington", State: "DC", PostalCode: "20500-0003", Country: "US"},
+		},
+		{
+			name: "mixed case is kept",
+			in:   domain.AddressData{StreetLine1: "12 McDonald St", City: "DeKalb", State: "il", PostalCode: "60115", Country: "US"},
+			want: domain.AddressData{StreetLine1: "12 McDonald St", City: "DeKalb", State: "IL", PostalCode: "60115", Country: "US"},
+		},
+		{
+			name: "UK outward and inward codes",
+			in:   domain.AddressData{StreetLine1: "10 downing street", City: "LONDON", PostalCode: "sw1a2aa", Country: "GB"},
+			want: domain.AddressData{StreetLine1: "10 Downing Street", City: "London", PostalCode: "SW1A 2AA", Country: "GB"},
+		},
+		{
+			name: "Canadian province name and postal code",
+			in:   domain.AddressData{StreetLine1: "24 Sussex Dr", City: "Ottawa", State: "Ontario", PostalCode: "k1m1m4", Country: "CA"},
+			want: domain.AddressData{StreetLine1: "24 Sussex Dr", City: "Ottawa", State: "ON", PostalCode: "K1M 1M4", Country: "CA"},
+		},
+		{
+			name: "Indian state code expanded",
+			in:   domain.AddressData{StreetLine1: "1 Marine Drive", City: "Mumbai", State: "mh", PostalCode: "400 020", Country: "IN"},
+			want: domain.AddressData{StreetLine1: "1 Marine Drive", City: "Mumbai", State: "Maharashtra", PostalCode: "400020", Country: "IN"},
+		},
+		{
+			name: "unknown country only cleans whitespace and casing",
+			in:   domain.AddressData{StreetLine1: "1 main road", City: "harare", State: "x", PostalCode: "ab 12", Country: "zw"},
+			want: domain.AddressData{StreetLine1: "1 Main Road", City: "Harare", State: "x", PostalCode: "ab 12", Country: "ZW"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.in
+			got := Normalize(&in)
+			if *got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, *got)
+			}
+			if in != tt.in {
+				t.Error("input was modified")
+			}
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		in   domain.AddressData
+		want []string
+	}{
+		{
+			name: "US",
+			in:   domain.AddressData{StreetLine1: "1 Main St", StreetLine2: "Apt 4", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US"},
+			want: []string{"1 Main St", "Apt 4", "SPRINGFIELD IL 62701", "UNITED STATES"},
+		},
+		{
+			name: "Canada",
+			in:   domain.AddressData{StreetLine1: "24 Sussex Dr", City: "Ottawa", State: "ON", PostalCode: "K1M 1M4", Country: "CA"},
+			want: []string{"24 Sussex Dr", "OTTAWA ON  K1M 1M4", "CANADA"},
+		},
+		{
+			name: "UK",
+			in:   domain.AddressData{StreetLine1: "10 Downing Street", City: "London", PostalCode: "SW1A 2AA", Country: "GB"},
+			want: []string{"10 Downing Street", "LONDON", "SW1A 2AA", "UNITED KINGDOM"},
+		},
+		{
+			name: "Germany",
+			in:   domain.AddressData{StreetLine1: "Unter den Linden 1", City: "Berlin", PostalCode: "10117", Country: "DE"},
+			want: []string{"Unter den Linden 1", "10117 Berlin", "GERMANY"},
+		},
+		{
+			name: "unknown country",
+			in:   domain.AddressData{StreetLine1: "1 Main Road", City: "Harare", Country: "ZW"},
+			want: []string{"1 Main Road", "Harare", "ZW"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Label(&tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
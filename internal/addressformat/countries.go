@@ -0,0 +1,96 @@
+package addressformat
+
+// labelStyle selects how the locality line of a mailing label is laid out
+type labelStyle int
+
+const (
+	styleCityStatePostal labelStyle = iota // SPRINGFIELD IL 62701
+	stylePostalCity                        // 10115 Berlin
+	styleCityThenPostal                    // post town and postcode on separate lines
+	styleCityPostalState                   // New Delhi 110001, state on its own line
+)
+
+// countryRules holds normalization and formatting conventions for one country
+type countryRules struct {
+	name            string // Printed as the last label line for international mail
+	style           labelStyle
+	stateSeparator  string // Between state and postal code for styleCityStatePostal
+	upperLocality   bool   // Postal authority asks for the locality line in capitals
+	normalizeState  func(string) string
+	normalizePostal func(string) string
+}
+
+var usStateNames = map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas", "CA": "California",
+	"CO": "Colorado", "CT": "Connecticut", "DE": "Delaware", "FL": "Florida", "GA": "Georgia",
+	"HI": "Hawaii", "ID": "Idaho", "IL": "Illinois", "IN": "Indiana", "IA": "Iowa",
+	"KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana", "ME": "Maine", "MD": "Maryland",
+	"MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota", "MS": "Mississippi", "MO": "Missouri",
+	"MT": "Montana", "NE": "Nebraska", "NV": "Nevada", "NH": "New Hampshire", "NJ": "New Jersey",
+	"NM": "New Mexico", "NY": "New York", "NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio",
+	"OK": "Oklahoma", "OR": "Oregon", "PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina",
+	"SD": "South Dakota", "TN": "Tennessee", "TX": "Texas", "UT": "Utah", "VT": "Vermont",
+	"VA": "Virginia", "WA": "Washington", "WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+	"DC": "District of Columbia", "AS": "American Samoa", "GU": "Guam", "MP": "Northern Mariana Islands",
+	"PR": "Puerto Rico", "VI": "U.S. Virgin Islands",
+}
+
+var caProvinceNames = map[string]string{
+	"AB": "Alberta", "BC": "British Columbia", "MB": "Manitoba", "NB": "New Brunswick",
+	"NL": "Newfoundland and Labrador", "NS": "Nova Scotia", "NT": "Northwest Territories",
+	"NU": "Nunavut", "ON": "Ontario", "PE": "Prince Edward Island", "QC": "Quebec",
+	"SK": "Saskatchewan", "YT": "Yukon",
+}
+
+var auStateNames = map[string]string{
+	"ACT": "Australian Capital Territory", "NSW": "New South Wales", "NT": "Northern Territory",
+	"QLD": "Queensland", "SA": "South Australia", "TAS": "Tasmania", "VIC": "Victoria",
+	"WA": "Western Australia",
+}
+
+var inStateNames = map[string]string{
+	"AP": "Andhra Pradesh", "AR": "Arunachal Pradesh", "AS": "Assam", "BR": "Bihar",
+	"CT": "Chhattisgarh", "GA": "Goa", "GJ": "Gujarat", "HR": "Haryana", "HP": "Himachal Pradesh",
+	"JH": "Jharkhand", "KA": "Karnataka", "KL": "Kerala", "MP": "Madhya Pradesh", "MH": "Maharashtra",
+	"MN": "Manipur", "ML": "Meghalaya", "MZ": "Mizoram", "NL": "Nagaland", "OR": "Odisha",
+	"PB": "Punjab", "RJ": "Rajasthan", "SK": "Sikkim", "TN": "Tamil Nadu", "TG": "Telangana",
+	"TR": "Tripura", "UP": "Uttar Pradesh", "UT": "Uttarakhand", "WB": "West Bengal",
+	"DL": "Delhi", "JK": "Jammu and Kashmir", "LA": "Ladakh", "CH": "Chandigarh", "PY": "Puducherry",
+}
+
+// countries is keyed by ISO 3166-1 alpha-2 code
+var countries = map[string]countryRules{
+	"US": {
+		name: "UNITED STATES", style: styleCityStatePostal, stateSeparator: " ", upperLocality: true,
+		normalizeState: stateCodes(usStateNames), normalizePostal: normalizeUSZip,
+	},
+	"CA": {
+		// Canada Post separates the province and postal code with two spaces
+		name: "CANADA", style: styleCityStatePostal, stateSeparator: "  ", upperLocality: true,
+		normalizeState: stateCodes(caProvinceNames), normalizePostal: splitPostal(6, 3, " "),
+	},
+	"AU": {
+		name: "AUSTRALIA", style: styleCityStatePostal, stateSeparator: " ", upperLocality: true,
+		normalizeState: stateCodes(auStateNames),
+	},
+	"IN": {
+		name: "INDIA", style: styleCityPostalState,
+		normalizeState: stateNames(inStateNames), normalizePostal: compactPostal,
+	},
+	"GB": {name: "UNITED KINGDOM", style: styleCityThenPostal, upperLocality: true, normalizePostal: normalizeUKPostcode},
+	"IE": {name: "IRELAND", style: styleCityThenPostal, normalizePostal: splitPostal(7, 4, " ")},
+	"DE": {name: "GERMANY", style: stylePostalCity},
+	"FR": {name: "FRANCE", style: stylePostalCity, upperLocality: true},
+	"ES": {name: "SPAIN", style: stylePostalCity},
+	"IT": {name: "ITALY", style: stylePostalCity},
+	"NL": {name: "NETHERLANDS", style: stylePostalCity, normalizePostal: splitPostal(6, 2, " ")},
+	"BE": {name: "BELGIUM", style: stylePostalCity},
+	"CH": {name: "SWITZERLAND", style: stylePostalCity},
+	"AT": {name: "AUSTRIA", style: stylePostalCity},
+	"SE": {name: "SWEDEN", style: stylePostalCity, normalizePostal: splitPostal(5, 2, " ")},
+	"PL": {name: "POLAND", style: stylePostalCity, normalizePostal: splitPostal(5, 3, "-")},
+	"BR": {name: "BRAZIL", style: styleCityStatePostal, stateSeparator: " ", normalizePostal: splitPostal(8, 3, "-")},
+	"MX": {name: "MEXICO", style: stylePostalCity},
+	"SG": {name: "SINGAPORE", style: styleCityPostalState, normalizePostal: compactPostal},
+	"JP": {name: "JAPAN", style: styleCityStatePostal, stateSeparator: " ", normalizePostal: splitPostal(7, 4, "-")},
+}
@@ -0,0 +1,59 @@
+package addressformat
+
+import (
+	"strings"
+
+	"github.com/banking/user-service/internal/domain"
+)
+
+// Label renders an address as the lines of a mailing label, in the order and casing the
+// destination country's postal service expects; the country is always the last line
+// The address should already be normalized
+func Label(addr *domain.AddressData) []string {
+	rules, known := countries[strings.ToUpper(addr.Country)]
+	if !known {
+		rules = countryRules{name: strings.ToUpper(addr.Country), style: styleCityStatePostal, stateSeparator: " "}
+	}
+
+	city := addr.City
+	if rules.upperLocality {
+		city = strings.ToUpper(city)
+	}
+
+	var lines []string
+	lines = appendLine(lines, addr.StreetLine1)
+	lines = appendLine(lines, addr.StreetLine2)
+
+	switch rules.style {
+	case stylePostalCity:
+		lines = appendLine(lines, joinNonEmpty(" ", addr.PostalCode, city, addr.State))
+	case styleCityThenPostal:
+		lines = appendLine(lines, city)
+		lines = appendLine(lines, addr.State)
+		lines = appendLine(lines, addr.PostalCode)
+	case styleCityPostalState:
+		lines = appendLine(lines, joinNonEmpty(" ", city, addr.PostalCode))
+		lines = appendLine(lines, addr.State)
+	default:
+		lines = appendLine(lines, joinNonEmpty(" ", city, joinNonEmpty(rules.stateSeparator, addr.State, addr.PostalCode)))
+	}
+
+	return appendLine(lines, rules.name)
+}
+
+func appendLine(lines []string, line string) []string {
+	if line == "" {
+		return lines
+	}
+	return append(lines, line)
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,148 @@
+package addressformat
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/banking/user-service/internal/domain"
+)
+
+// Normalize returns the canonical form of an address; the input is left untouched
+// Whitespace is collapsed everywhere, while casing, state and postal code rules are
+// applied only for countries we serve and otherwise left as typed
+func Normalize(in *domain.AddressData) *domain.AddressData {
+	out := &domain.AddressData{
+		StreetLine1: cleanSpace(in.StreetLine1),
+		StreetLine2: cleanSpace(in.StreetLine2),
+		City:        cleanSpace(in.City),
+		State:       cleanSpace(in.State),
+		PostalCode:  cleanSpace(in.PostalCode),
+		Country:     strings.ToUpper(cleanSpace(in.Country)),
+	}
+
+	out.StreetLine1 = fixCasing(out.StreetLine1)
+	out.StreetLine2 = fixCasing(out.StreetLine2)
+	out.City = fixCasing(out.City)
+
+	rules, ok := countries[out.Country]
+	if !ok {
+		return out
+	}
+
+	if rules.normalizeState != nil {
+		out.State = rules.normalizeState(out.State)
+	}
+	if rules.normalizePostal != nil && out.PostalCode != "" {
+		out.PostalCode = rules.normalizePostal(out.PostalCode)
+	}
+
+	return out
+}
+
+// cleanSpace trims and collapses runs of whitespace to a single space
+func cleanSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// upperWords stay capitalized when fixing casing: compass directions and PO boxes
+var upperWords = map[string]bool{
+	"n": true, "s": true, "e": true, "w": true,
+	"ne": true, "nw": true, "se": true, "sw": true,
+	"po": true,
+}
+
+// fixCasing title-cases text typed entirely in upper or lower case
+// Mixed case is assumed deliberate (McDonald, DeKalb) and kept
+func fixCasing(s string) string {
+	if s != strings.ToUpper(s) && s != strings.ToLower(s) {
+		return s
+	}
+
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		if upperWords[w] {
+			words[i] = strings.ToUpper(w)
+			continue
+		}
+		r := []rune(w)
+		// Units such as "4b" or ordinals such as "21st" start with a digit and stay lower case
+		if unicode.IsLetter(r[0]) {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// compactPostal strips separators and upper-cases a postal code before reformatting
+func compactPostal(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// splitPostal returns a postal normalizer inserting sep before the last n characters
+// when the compacted code has exactly length characters; other input is only upper-cased
+func splitPostal(length, n int, sep string) func(string) string {
+	return func(s string) string {
+		c := compactPostal(s)
+		if len(c) != length {
+			return strings.ToUpper(s)
+		}
+		return c[:length-n] + sep + c[length-n:]
+	}
+}
+
+// normalizeUSZip formats ZIP and ZIP+4 codes
+func normalizeUSZip(s string) string {
+	c := compactPostal(s)
+	if len(c) == 9 {
+		return c[:5] + "-" + c[5:]
+	}
+	return c
+}
+
+// normalizeUKPostcode separates the outward and inward codes
+// The inward code is always a digit and two letters, so it is the last three characters
+func normalizeUKPostcode(s string) string {
+	c := compactPostal(s)
+	if len(c) < 5 || len(c) > 7 {
+		return strings.ToUpper(s)
+	}
+	return c[:len(c)-3] + " " + c[len(c)-3:]
+}
+
+// stateCodes returns a state normalizer that abbreviates full names to their codes
+func stateCodes(names map[string]string) func(string) string {
+	return func(s string) string {
+		upper := strings.ToUpper(s)
+		if _, ok := names[upper]; ok {
+			return upper
+		}
+		for code, name := range names {
+			if strings.EqualFold(name, s) {
+				return code
+			}
+		}
+		return s
+	}
+}
+
+// stateNames returns a state normalizer that expands codes to full names
+// Used where postal authorities expect the name rather than an abbreviation
+func stateNames(names map[string]string) func(string) string {
+	return func(s string) string {
+		if name, ok := names[strings.ToUpper(s)]; ok {
+			return name
+		}
+		for _, name := range names {
+			if strings.EqualFold(name, s) {
+				return name
+			}
+		}
+		return s
+	}
+}
@@ -0,0 +1,111 @@
+package addressvalidation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// maxProviderResponse bounds how much of a provider response is read
+const maxProviderResponse = 64 << 10
+
+// HTTPValidatorConfig holds settings for an HTTP address validation provider
+type HTTPValidatorConfig struct {
+	URL     string        // Endpoint receiving POSTed addresses
+	APIKey  string        // Sent as a bearer token when set
+	Source  string        // Recorded as validation_source, e.g. the provider's name
+	Timeout time.Duration // Per-request timeout (default: 5s)
+}
+
+// HTTPValidator delegates validation to an external provider over HTTP
+//
+// The provider receives the address as JSON and answers with:
+//
+//	{"status": "VALID" | "INVALID" | "UNKNOWN", "reasons": ["..."]}
+type HTTPValidator struct {
+	client *http.Client
+	cb     *resilience.CircuitBreaker
+	cfg    HTTPValidatorConfig
+}
+
+// providerResponse is the provider's answer
+type providerResponse struct {
+	Status  domain.ValidationStatus `json:"status"`
+	Reasons []string                `json:"reasons,omitempty"`
+}
+
+// NewHTTPValidator creates a new HTTP provider adapter
+// cb may be nil, e.g. in tests
+func NewHTTPValidator(cfg HTTPValidatorConfig, cb *resilience.CircuitBreaker) *HTTPValidator {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.Source == "" {
+		cfg.Source = "HTTP_PROVIDER"
+	}
+	return &HTTPValidator{
+		client: &http.Client{Timeout: cfg.Timeout},
+		cb:     cb,
+		cfg:    cfg,
+	}
+}
+
+// Validate implements AddressValidator
+func (v *HTTPValidator) Validate(ctx context.Context, addr *domain.AddressData) (*Result, error) {
+	if v.cb == nil {
+		return v.validate(ctx, addr)
+	}
+	result, err := v.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return v.validate(ctx, addr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Result), nil
+}
+
+func (v *HTTPValidator) validate(ctx context.Context, addr *domain.AddressData) (*Result, error) {
+	body, err := json.Marshal(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal address: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build validation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if v.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+v.cfg.APIKey)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("address validation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("address validation provider returned status %d", resp.StatusCode)
+	}
+
+	var pr providerResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProviderResponse)).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("failed to decode validation response: %w", err)
+	}
+
+	switch pr.Status {
+	case domain.ValidationStatusValid, domain.ValidationStatusInvalid, domain.ValidationStatusUnknown:
+	default:
+		return nil, fmt.Errorf("address validation provider returned unexpected status %q", pr.Status)
+	}
+
+	return &Result{Status: pr.Status, Source: v.cfg.Source, Reasons: pr.Reasons}, nil
+}
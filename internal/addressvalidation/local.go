@@ -0,0 +1,125 @@
+package addressvalidation
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/banking/user-service/internal/domain"
+)
+
+// SourceLocal is recorded for addresses judged by the built-in rules
+const SourceLocal = "LOCAL_RULES"
+
+// Rejection reasons
+const (
+	ReasonStreetRequired     = "street_required"
+	ReasonCityRequired       = "city_required"
+	ReasonPostalCodeRequired = "postal_code_required"
+	ReasonPostalCodeFormat   = "postal_code_format"
+	ReasonStateRequired      = "state_required"
+	ReasonStateUnknown       = "state_unknown"
+)
+
+// countryRules describes what a well-formed address looks like in one country
+type countryRules struct {
+	postalCode    *regexp.Regexp
+	stateRequired bool
+	states        map[string]bool // Accepted state codes; nil accepts any non-empty value
+}
+
+var usStates = codeSet(
+	"AL", "AK", "AZ", "AR", "CA", "CO", "CT", "DE", "FL", "GA", "HI", "ID", "IL", "IN", "IA",
+	"KS", "KY", "LA", "ME", "MD", "MA", "MI", "MN", "MS", "MO", "MT", "NE", "NV", "NH", "NJ",
+	"NM", "NY", "NC", "ND", "OH", "OK", "OR", "PA", "RI", "SC", "SD", "TN", "TX", "UT", "VT",
+	"VA", "WA", "WV", "WI", "WY", "DC",
+	// Territories and military mail
+	"AS", "GU", "MP", "PR", "VI", "AA", "AE", "AP",
+)
+
+var caProvinces = codeSet("AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT")
+
+var auStates = codeSet("ACT", "NSW", "NT", "QLD", "SA", "TAS", "VIC", "WA")
+
+// rulesByCountry is keyed by ISO 3166-1 alpha-2 code
+var rulesByCountry = map[string]countryRules{
+	"US": {postalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`), stateRequired: true, states: usStates},
+	"CA": {postalCode: regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`), stateRequired: true, states: caProvinces},
+	"AU": {postalCode: regexp.MustCompile(`^\d{4}$`), stateRequired: true, states: auStates},
+	"BR": {postalCode: regexp.MustCompile(`^\d{5}-?\d{3}$`), stateRequired: true},
+	"MX": {postalCode: regexp.MustCompile(`^\d{5}$`), stateRequired: true},
+	"IN": {postalCode: regexp.MustCompile(`^\d{6}$`), stateRequired: true},
+	"GB": {postalCode: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`)},
+	"IE": {postalCode: regexp.MustCompile(`^[A-Z]\d[\dW] ?[A-Z\d]{4}$`)},
+	"DE": {postalCode: regexp.MustCompile(`^\d{5}$`)},
+	"FR": {postalCode: regexp.MustCompile(`^\d{5}$`)},
+	"ES": {postalCode: regexp.MustCompile(`^\d{5}$`)},
+	"IT": {postalCode: regexp.MustCompile(`^\d{5}$`)},
+	"NL": {postalCode: regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`)},
+	"BE": {postalCode: regexp.MustCompile(`^\d{4}$`)},
+	"CH": {postalCode: regexp.MustCompile(`^\d{4}$`)},
+	"AT": {postalCode: regexp.MustCompile(`^\d{4}$`)},
+	"SE": {postalCode: regexp.MustCompile(`^\d{3} ?\d{2}$`)},
+	"PL": {postalCode: regexp.MustCompile(`^\d{2}-\d{3}$`)},
+	"JP": {postalCode: regexp.MustCompile(`^\d{3}-?\d{4}$`)},
+	"SG": {postalCode: regexp.MustCompile(`^\d{6}$`)},
+	"CN": {postalCode: regexp.MustCompile(`^\d{6}$`)},
+}
+
+// LocalValidator applies per-country format and required-field rules without network calls
+// It can prove an address malformed but not that it exists, so a pass is only as strong as
+// the rules; countries without rules are reported as UNKNOWN
+type LocalValidator struct{}
+
+// NewLocalValidator creates a new rule-based validator
+func NewLocalValidator() *LocalValidator {
+	return &LocalValidator{}
+}
+
+// Validate implements AddressValidator
+func (v *LocalValidator) Validate(ctx context.Context, addr *domain.AddressData) (*Result, error) {
+	var reasons []string
+
+	if strings.TrimSpace(addr.StreetLine1) == "" {
+		reasons = append(reasons, ReasonStreetRequired)
+	}
+	if strings.TrimSpace(addr.City) == "" {
+		reasons = append(reasons, ReasonCityRequired)
+	}
+
+	postalCode := strings.ToUpper(strings.TrimSpace(addr.PostalCode))
+	if postalCode == "" {
+		reasons = append(reasons, ReasonPostalCodeRequired)
+	}
+
+	rules, known := rulesByCountry[strings.ToUpper(addr.Country)]
+	if known {
+		if postalCode != "" && !rules.postalCode.MatchString(postalCode) {
+			reasons = append(reasons, ReasonPostalCodeFormat)
+		}
+
+		state := strings.ToUpper(strings.TrimSpace(addr.State))
+		switch {
+		case rules.stateRequired && state == "":
+			reasons = append(reasons, ReasonStateRequired)
+		case state != "" && rules.states != nil && !rules.states[state]:
+			reasons = append(reasons, ReasonStateUnknown)
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &Result{Status: domain.ValidationStatusInvalid, Source: SourceLocal, Reasons: reasons}, nil
+	}
+	if !known {
+		return &Result{Status: domain.ValidationStatusUnknown, Source: SourceLocal}, nil
+	}
+	return &Result{Status: domain.ValidationStatusValid, Source: SourceLocal}, nil
+}
+
+func codeSet(codes ...string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		set[c] = true
+	}
+	return set
+}
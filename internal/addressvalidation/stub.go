@@ -0,0 +1,34 @@
+package addressvalidation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/banking/user-service/internal/domain"
+)
+
+// StubDecision decides the stub provider's answer for an address
+type StubDecision func(addr *domain.AddressData) (domain.ValidationStatus, []string)
+
+// NewStubServer starts a local server speaking the HTTPValidator protocol
+// It lets tests and local environments exercise the provider path without a real vendor;
+// callers must Close the returned server
+func NewStubServer(decide StubDecision) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var addr domain.AddressData
+		if err := json.NewDecoder(r.Body).Decode(&addr); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		status, reasons := decide(&addr)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(providerResponse{Status: status, Reasons: reasons})
+	}))
+}
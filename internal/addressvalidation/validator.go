@@ -0,0 +1,71 @@
+package addressvalidation
+
+import (
+	"context"
+
+	"github.com/banking/user-service/internal/domain"
+)
+
+// Result is the outcome of validating an address
+type Result struct {
+	Status  domain.ValidationStatus
+	Source  string   // Recorded as addresses.validation_source
+	Reasons []string // Why an address was rejected; never contains the address itself
+}
+
+// AddressValidator checks an address against a set of rules or an external provider
+// Validators return UNKNOWN when they cannot judge an address and an error only when
+// they could not run at all
+type AddressValidator interface {
+	Validate(ctx context.Context, addr *domain.AddressData) (*Result, error)
+}
+
+// Pipeline runs validators in order, cheapest first
+// An INVALID result stops the pipeline; otherwise the last definitive answer wins, so a
+// provider can overrule the local rules but an unavailable provider does not discard them
+type Pipeline struct {
+	validators []AddressValidator
+}
+
+// NewPipeline creates a pipeline from the given validators
+func NewPipeline(validators ...AddressValidator) *Pipeline {
+	return &Pipeline{validators: validators}
+}
+
+// Validate implements AddressValidator
+// An error is returned only if every validator failed
+func (p *Pipeline) Validate(ctx context.Context, addr *domain.AddressData) (*Result, error) {
+	var best *Result
+	var lastErr error
+
+	for _, v := range p.validators {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		res, err := v.Validate(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		switch res.Status {
+		case domain.ValidationStatusInvalid:
+			return res, nil
+		case domain.ValidationStatusValid:
+			best = res
+		default:
+			if best == nil {
+				best = res
+			}
+		}
+	}
+
+	if best == nil {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return &Result{Status: domain.ValidationStatusUnknown}, nil
+	}
+	return best, nil
+}
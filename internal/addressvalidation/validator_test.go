@@ -0,0 +1,165 @@
+package addressvalidation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/banking/user-service/internal/domain"
+)
+
+func usAddress() *domain.AddressData {
+	return &domain.AddressData{
+		StreetLine1: "1 Main St",
+		City:        "Springfield",
+		State:       "IL",
+		PostalCode:  "62701",
+		Country:     "US",
+	}
+}
+
+func TestLocalValidator_Rules(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(a *domain.AddressData)
+		want   domain.ValidationStatus
+		reason string
+	}{
+		{"valid US", func(a *domain.AddressData) {}, domain.ValidationStatusValid, ""},
+		{"US ZIP+4", func(a *domain.AddressData) { a.PostalCode = "62701-1234" }, domain.ValidationStatusValid, ""},
+		{"bad US ZIP", func(a *domain.AddressData) { a.PostalCode = "6270" }, domain.ValidationStatusInvalid, ReasonPostalCodeFormat},
+		{"US missing state", func(a *domain.AddressData) { a.State = "" }, domain.ValidationStatusInvalid, ReasonStateRequired},
+		{"US unknown state", func(a *domain.AddressData) { a.State = "ZZ" }, domain.ValidationStatusInvalid, ReasonStateUnknown},
+		{"missing city", func(a *domain.AddressData) { a.City = " " }, domain.ValidationStatusInvalid, ReasonCityRequired},
+		{"CA lowercase postal", func(a *domain.AddressData) {
+			a.Country, a.State, a.PostalCode = "CA", "ON", "k1a 0b1"
+		}, domain.ValidationStatusValid, ""},
+		{"GB without state", func(a *domain.AddressData) {
+			a.Country, a.State, a.PostalCode = "GB", "", "SW1A 1AA"
+		}, domain.ValidationStatusValid, ""},
+		{"unknown country", func(a *domain.AddressData) {
+			a.Country, a.State, a.PostalCode = "ZW", "", "anything"
+		}, domain.ValidationStatusUnknown, ""},
+	}
+
+	v := NewLocalValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := usAddress()
+			tt.modify(addr)
+
+			res, err := v.Validate(context.Background(), addr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Status != tt.want {
+				t.Errorf("expected %s, got %s (reasons %v)", tt.want, res.Status, res.Reasons)
+			}
+			if tt.reason != "" && !contains(res.Reasons, tt.reason) {
+				t.Errorf("expected reason %s, got %v", tt.reason, res.Reasons)
+			}
+			if res.Source != SourceLocal {
+				t.Errorf("expected source %s, got %s", SourceLocal, res.Source)
+			}
+		})
+	}
+}
+
+func TestHTTPValidator_UsesProviderVerdict(t *testing.T) {
+	server := NewStubServer(func(addr *domain.AddressData) (domain.ValidationStatus, []string) {
+		if addr.StreetLine1 == "0 Nowhere Rd" {
+			return domain.ValidationStatusInvalid, []string{"not_deliverable"}
+		}
+		return domain.ValidationStatusValid, nil
+	})
+	defer server.Close()
+
+	v := NewHTTPValidator(HTTPValidatorConfig{URL: server.URL, Source: "STUB"}, nil)
+
+	res, err := v.Validate(context.Background(), usAddress())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Status != domain.ValidationStatusValid || res.Source != "STUB" {
+		t.Errorf("expected VALID from STUB, got %s from %s", res.Status, res.Source)
+	}
+
+	addr := usAddress()
+	addr.StreetLine1 = "0 Nowhere Rd"
+	res, err = v.Validate(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Status != domain.ValidationStatusInvalid || !contains(res.Reasons, "not_deliverable") {
+		t.Errorf("expected INVALID with provider reason, got %s %v", res.Status, res.Reasons)
+	}
+}
+
+func TestHTTPValidator_RejectsUnexpectedStatus(t *testing.T) {
+	server := NewStubServer(func(addr *domain.AddressData) (domain.ValidationStatus, []string) {
+		return domain.ValidationStatusPending, nil
+	})
+	defer server.Close()
+
+	v := NewHTTPValidator(HTTPValidatorConfig{URL: server.URL}, nil)
+	if _, err := v.Validate(context.Background(), usAddress()); err == nil {
+		t.Error("expected error for a status the provider may not return")
+	}
+}
+
+type fixedValidator struct {
+	res *Result
+	err error
+}
+
+func (v fixedValidator) Validate(ctx context.Context, addr *domain.AddressData) (*Result, error) {
+	return v.res, v.err
+}
+
+func TestPipeline(t *testing.T) {
+	valid := fixedValidator{res: &Result{Status: domain.ValidationStatusValid, Source: "A"}}
+	invalid := fixedValidator{res: &Result{Status: domain.ValidationStatusInvalid, Source: "B"}}
+	unknown := fixedValidator{res: &Result{Status: domain.ValidationStatusUnknown, Source: "C"}}
+	failing := fixedValidator{err: errors.New("provider down")}
+
+	tests := []struct {
+		name       string
+		validators []AddressValidator
+		want       domain.ValidationStatus
+		source     string
+		wantErr    bool
+	}{
+		{"invalid stops the pipeline", []AddressValidator{invalid, valid}, domain.ValidationStatusInvalid, "B", false},
+		{"provider overrules unknown", []AddressValidator{unknown, valid}, domain.ValidationStatusValid, "A", false},
+		{"unknown does not discard valid", []AddressValidator{valid, unknown}, domain.ValidationStatusValid, "A", false},
+		{"failing provider keeps local verdict", []AddressValidator{valid, failing}, domain.ValidationStatusValid, "A", false},
+		{"all failing is an error", []AddressValidator{failing}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewPipeline(tt.validators...).Validate(context.Background(), usAddress())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Status != tt.want || res.Source != tt.source {
+				t.Errorf("expected %s from %s, got %s from %s", tt.want, tt.source, res.Status, res.Source)
+			}
+		})
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
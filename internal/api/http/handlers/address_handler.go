@@ -12,6 +12,9 @@ import (
 	"github.com/banking/user-service/internal/service"
 )
 
+// DeviceFingerprintHeader carries the client's device fingerprint, as registered for the user's devices
+const DeviceFingerprintHeader = "X-Device-Fingerprint"
+
 // AddressHandler handles address-related HTTP requests
 type AddressHandler struct {
 	addressService *service.AddressService
@@ -65,7 +68,9 @@ func (h *AddressHandler) CreateAddress(c echo.Context) error {
 
 	clientIP := c.RealIP()
 
-	address, err := h.addressService.CreateAddress(ctx, userID, &req, clientIP, requestID)
+	fingerprint := c.Request().Header.Get(DeviceFingerprintHeader)
+
+	address, err := h.addressService.CreateAddress(ctx, userID, &req, fingerprint, clientIP, requestID)
 	if err != nil {
 		h.log.WithContext(ctx).Error("failed to create address", logger.ErrorField(err))
 		return handleServiceError(err)
@@ -118,12 +123,56 @@ func (h *AddressHandler) UpdateAddress(c echo.Context) error {
 
 	clientIP := c.RealIP()
 
-	address, err := h.addressService.UpdateAddress(ctx, userID, addressID, &req, clientIP, requestID)
+	fingerprint := c.Request().Header.Get(DeviceFingerprintHeader)
+
+	address, err := h.addressService.UpdateAddress(ctx, userID, addressID, &req, fingerprint, clientIP, requestID)
 	if err != nil {
 		h.log.WithContext(ctx).Error("failed to update address", logger.ErrorField(err))
 		return handleServiceError(err)
 	}
 
+	// Held for confirmation: accepted, but not applied yet
+	if address.ChangeStatus == domain.AddressChangePendingConfirmation {
+		return c.JSON(http.StatusAccepted, address)
+	}
+
+	return c.JSON(http.StatusOK, address)
+}
+
+// ConfirmAddressChange handles POST /api/v1/users/me/addresses/:id/confirm-change
+func (h *AddressHandler) ConfirmAddressChange(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	addressID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid address ID")
+	}
+
+	var req domain.ConfirmAddressChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	address, err := h.addressService.ConfirmAddressChange(ctx, userID, addressID, &req, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to confirm address change",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
 	return c.JSON(http.StatusOK, address)
 }
 
@@ -152,3 +201,61 @@ func (h *AddressHandler) DeleteAddress(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// GetAddressHistory handles GET /api/v1/users/me/addresses/:id/history
+func (h *AddressHandler) GetAddressHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	addressID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid address ID")
+	}
+
+	history, err := h.addressService.GetAddressHistory(ctx, userID, addressID)
+	if err != nil {
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// AdminGetAddressHistory handles GET /api/v1/admin/users/:id/addresses/:addressId/history
+func (h *AddressHandler) AdminGetAddressHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	// Investigator access must be attributable to a person, not a service
+	if middleware.IsServiceCall(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "admin token required")
+	}
+	adminID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+	addressID, err := uuid.Parse(c.Param("addressId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid address ID")
+	}
+
+	history, err := h.addressService.GetAddressHistoryAsAdmin(ctx, userID, addressID, adminID.String(), c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to get address history",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// AdminHandler handles back-office and system account management requests
+type AdminHandler struct {
+	userService *service.UserService
+	log         *logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(userService *service.UserService, log *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		userService: userService,
+		log:         log.Named("admin_handler"),
+	}
+}
+
+// SuspendUser handles POST /api/v1/admin/users/:id/suspend
+func (h *AdminHandler) SuspendUser(c echo.Context) error {
+	return h.changeStatusAsAdmin(c, domain.UserStatusSuspended)
+}
+
+// ReactivateUser handles POST /api/v1/admin/users/:id/reactivate
+func (h *AdminHandler) ReactivateUser(c echo.Context) error {
+	return h.changeStatusAsAdmin(c, domain.UserStatusActive)
+}
+
+// CloseUser handles POST /api/v1/admin/users/:id/close
+func (h *AdminHandler) CloseUser(c echo.Context) error {
+	return h.changeStatusAsAdmin(c, domain.UserStatusClosed)
+}
+
+// LockUser handles POST /api/v1/internal/users/:id/lock (service-to-service only)
+func (h *AdminHandler) LockUser(c echo.Context) error {
+	return h.changeStatusAsSystem(c, domain.UserStatusLocked)
+}
+
+// UnlockUser handles POST /api/v1/internal/users/:id/unlock (service-to-service only)
+func (h *AdminHandler) UnlockUser(c echo.Context) error {
+	return h.changeStatusAsSystem(c, domain.UserStatusActive)
+}
+
+// RestoreUser handles POST /api/v1/admin/users/:id/restore
+func (h *AdminHandler) RestoreUser(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if middleware.IsServiceCall(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "admin token required")
+	}
+	adminID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	return h.restore(c, targetID, adminID.String(), audit.ActorAdmin, "")
+}
+
+// RestoreUserForOwner handles POST /api/v1/internal/users/:id/restore
+// Called by the auth service once the account owner has re-authenticated
+func (h *AdminHandler) RestoreUserForOwner(c echo.Context) error {
+	serviceName := middleware.GetServiceName(c.Request().Context())
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	return h.restore(c, targetID, targetID.String(), audit.ActorUser, serviceName)
+}
+
+func (h *AdminHandler) restore(c echo.Context, targetID uuid.UUID, actorID string, actorType audit.ActorType, serviceName string) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	resp, err := h.userService.RestoreAccount(ctx, targetID, actorID, actorType, serviceName, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to restore user",
+			logger.RequestID(requestID),
+			logger.UserID(targetID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *AdminHandler) changeStatusAsAdmin(c echo.Context, to domain.UserStatus) error {
+	ctx := c.Request().Context()
+
+	// Admin actions must be attributable to a person, not a service
+	if middleware.IsServiceCall(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "admin token required")
+	}
+	adminID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+	if targetID == adminID {
+		return echo.NewHTTPError(http.StatusForbidden, "cannot change own account status")
+	}
+
+	return h.changeStatus(c, targetID, to, adminID.String(), audit.ActorAdmin, "")
+}
+
+func (h *AdminHandler) changeStatusAsSystem(c echo.Context, to domain.UserStatus) error {
+	serviceName := middleware.GetServiceName(c.Request().Context())
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	return h.changeStatus(c, targetID, to, serviceName, audit.ActorSystem, serviceName)
+}
+
+func (h *AdminHandler) changeStatus(c echo.Context, targetID uuid.UUID, to domain.UserStatus, actorID string, actorType audit.ActorType, serviceName string) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	var req domain.ChangeStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.userService.ChangeStatus(ctx, targetID, to, req.Reason, actorID, actorType, serviceName, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to change user status",
+			logger.RequestID(requestID),
+			logger.UserID(targetID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
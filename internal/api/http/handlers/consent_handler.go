@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// ConsentHandler handles consent-related HTTP requests
+type ConsentHandler struct {
+	consentService *service.ConsentService
+	log            *logger.Logger
+}
+
+// NewConsentHandler creates a new consent handler
+func NewConsentHandler(consentService *service.ConsentService, log *logger.Logger) *ConsentHandler {
+	return &ConsentHandler{
+		consentService: consentService,
+		log:            log.Named("consent_handler"),
+	}
+}
+
+// GetConsents handles GET /api/v1/users/me/consents
+func (h *ConsentHandler) GetConsents(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	consents, err := h.consentService.GetConsents(ctx, userID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to get consents", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, consents)
+}
+
+// GrantConsent handles POST /api/v1/users/me/consents/grant
+// Email marketing consent is returned as PENDING until the emailed link is confirmed
+func (h *ConsentHandler) GrantConsent(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var req domain.GrantConsentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	consent, err := h.consentService.GrantConsent(ctx, userID, &req, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to grant consent", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, consent)
+}
+
+// ConfirmConsent handles POST /api/v1/users/me/consents/confirm
+func (h *ConsentHandler) ConfirmConsent(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var req domain.ConfirmConsentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	consent, err := h.consentService.ConfirmConsent(ctx, userID, &req, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to confirm consent", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, consent)
+}
+
+// WithdrawConsent handles POST /api/v1/users/me/consents/withdraw
+func (h *ConsentHandler) WithdrawConsent(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var req domain.WithdrawConsentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	consent, err := h.consentService.WithdrawConsent(ctx, userID, &req, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to withdraw consent", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, consent)
+}
+
+// GetConsentHistory handles GET /api/v1/users/me/consents/history
+func (h *ConsentHandler) GetConsentHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	history, err := h.consentService.GetConsentHistory(ctx, userID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to get consent history", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// AdminGetConsentHistory handles GET /api/v1/admin/users/:id/consents/history
+func (h *ConsentHandler) AdminGetConsentHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	adminID, userID, err := adminTarget(c)
+	if err != nil {
+		return err
+	}
+
+	history, err := h.consentService.GetConsentHistoryAsAdmin(ctx, userID, adminID.String(), c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to get consent history",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// DataExportHandler handles data subject access export requests
+type DataExportHandler struct {
+	exportService *service.DataExportService
+	log           *logger.Logger
+}
+
+// NewDataExportHandler creates a new data export handler
+func NewDataExportHandler(exportService *service.DataExportService, log *logger.Logger) *DataExportHandler {
+	return &DataExportHandler{
+		exportService: exportService,
+		log:           log.Named("data_export_handler"),
+	}
+}
+
+// RequestExport handles POST /api/v1/users/me/exports
+func (h *DataExportHandler) RequestExport(c echo.Context) error {
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	return h.requestExport(c, userID, userID.String(), audit.ActorUser)
+}
+
+// GetExport handles GET /api/v1/users/me/exports/:exportId
+func (h *DataExportHandler) GetExport(c echo.Context) error {
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	return h.getExport(c, userID)
+}
+
+// DownloadExport handles GET /api/v1/users/me/exports/:exportId/download
+func (h *DataExportHandler) DownloadExport(c echo.Context) error {
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	return h.downloadExport(c, userID, userID.String(), audit.ActorUser)
+}
+
+// AdminRequestExport handles POST /api/v1/admin/users/:id/exports
+// Used by compliance staff answering a request received outside the app
+func (h *DataExportHandler) AdminRequestExport(c echo.Context) error {
+	adminID, targetID, err := h.adminTarget(c)
+	if err != nil {
+		return err
+	}
+
+	return h.requestExport(c, targetID, adminID.String(), audit.ActorAdmin)
+}
+
+// AdminGetExport handles GET /api/v1/admin/users/:id/exports/:exportId
+func (h *DataExportHandler) AdminGetExport(c echo.Context) error {
+	_, targetID, err := h.adminTarget(c)
+	if err != nil {
+		return err
+	}
+
+	return h.getExport(c, targetID)
+}
+
+// AdminDownloadExport handles GET /api/v1/admin/users/:id/exports/:exportId/download
+func (h *DataExportHandler) AdminDownloadExport(c echo.Context) error {
+	adminID, targetID, err := h.adminTarget(c)
+	if err != nil {
+		return err
+	}
+
+	return h.downloadExport(c, targetID, adminID.String(), audit.ActorAdmin)
+}
+
+// adminTarget resolves the acting admin and the target user
+// Exports contain all of a user's PII, so they must be attributable to a person
+func (h *DataExportHandler) adminTarget(c echo.Context) (uuid.UUID, uuid.UUID, error) {
+	if middleware.IsServiceCall(c.Request().Context()) {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusForbidden, "admin token required")
+	}
+	adminID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	return adminID, targetID, nil
+}
+
+func (h *DataExportHandler) requestExport(c echo.Context, userID uuid.UUID, actorID string, actorType audit.ActorType) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	job, err := h.exportService.RequestExport(ctx, userID, actorID, actorType, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to request data export",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
+
+func (h *DataExportHandler) getExport(c echo.Context, userID uuid.UUID) error {
+	ctx := c.Request().Context()
+
+	exportID, err := uuid.Parse(c.Param("exportId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid export ID")
+	}
+
+	job, err := h.exportService.GetExport(ctx, userID, exportID)
+	if err != nil {
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+func (h *DataExportHandler) downloadExport(c echo.Context, userID uuid.UUID, actorID string, actorType audit.ActorType) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	exportID, err := uuid.Parse(c.Param("exportId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid export ID")
+	}
+
+	bundle, err := h.exportService.DownloadExport(ctx, userID, exportID, actorID, actorType, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to download data export",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "data-export-"+exportID.String()+".json"))
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, bundle)
+}
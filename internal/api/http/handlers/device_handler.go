@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"encoding/hex"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/domain"
 	"github.com/banking/user-service/internal/pkg/logger"
 	"github.com/banking/user-service/internal/service"
 )
@@ -14,13 +17,17 @@ import (
 // DeviceHandler handles device-related HTTP requests
 type DeviceHandler struct {
 	deviceService *service.DeviceService
+	trustService  *service.DeviceTrustService
+	pushService   *service.PushTokenService
 	log           *logger.Logger
 }
 
 // NewDeviceHandler creates a new device handler
-func NewDeviceHandler(deviceService *service.DeviceService, log *logger.Logger) *DeviceHandler {
+func NewDeviceHandler(deviceService *service.DeviceService, trustService *service.DeviceTrustService, pushService *service.PushTokenService, log *logger.Logger) *DeviceHandler {
 	return &DeviceHandler{
 		deviceService: deviceService,
+		trustService:  trustService,
+		pushService:   pushService,
 		log:           log.Named("device_handler"),
 	}
 }
@@ -35,7 +42,7 @@ func (h *DeviceHandler) ListDevices(c echo.Context) error {
 	}
 
 	// Get current device fingerprint from header (if available)
-	currentFingerprint := c.Request().Header.Get("X-Device-Fingerprint")
+	currentFingerprint := c.Request().Header.Get(DeviceFingerprintHeader)
 
 	devices, err := h.deviceService.ListDevices(ctx, userID, currentFingerprint)
 	if err != nil {
@@ -46,6 +53,64 @@ func (h *DeviceHandler) ListDevices(c echo.Context) error {
 	return c.JSON(http.StatusOK, devices)
 }
 
+// RegisterDevice handles POST /api/v1/users/me/devices
+func (h *DeviceHandler) RegisterDevice(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var req domain.RegisterDeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	req.IP = c.RealIP()
+
+	device, created, err := h.deviceService.RegisterDevice(ctx, userID, &req, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to register device", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, device.ToListItem(device.FingerprintHash))
+}
+
+// Heartbeat handles POST /api/v1/users/me/devices/heartbeat
+// The device is identified by the fingerprint header; no body is needed
+func (h *DeviceHandler) Heartbeat(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	fingerprint, err := currentFingerprint(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.deviceService.Heartbeat(ctx, userID, fingerprint, c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Error("failed to record device heartbeat", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 // RemoveDevice handles DELETE /api/v1/users/me/devices/:id
 func (h *DeviceHandler) RemoveDevice(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -71,3 +136,354 @@ func (h *DeviceHandler) RemoveDevice(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// StartStepUp handles POST /api/v1/users/me/devices/step-up
+// Texts a code that trusts the device in the fingerprint header
+func (h *DeviceHandler) StartStepUp(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	fingerprint, err := currentFingerprint(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.trustService.StartStepUp(ctx, userID, fingerprint, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to start device step-up",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, resp)
+}
+
+// TrustCurrentDevice handles POST /api/v1/users/me/devices/trust
+// Trusts the device in the fingerprint header after a fresh step-up or with a step-up code
+func (h *DeviceHandler) TrustCurrentDevice(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	fingerprint, err := currentFingerprint(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.TrustDeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	var stepUp service.StepUp
+	stepUp.AuthTime, _ = middleware.GetAuthTime(ctx)
+	stepUp.ACR = middleware.GetACR(ctx)
+
+	device, err := h.trustService.TrustCurrentDevice(ctx, userID, fingerprint, stepUp, &req, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to trust device",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, device.ToListItem(device.FingerprintHash))
+}
+
+// UntrustDevice handles DELETE /api/v1/users/me/devices/:id/trust
+func (h *DeviceHandler) UntrustDevice(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	deviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid device ID")
+	}
+
+	if err := h.trustService.UntrustDevice(ctx, userID, deviceID, c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Error("failed to untrust device", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ReportCompromise handles POST /api/v1/users/me/devices/report-compromise
+// Revokes trust in all of the user's devices
+func (h *DeviceHandler) ReportCompromise(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := h.trustService.ReportCompromise(ctx, userID, c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Error("failed to untrust devices after compromise report",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeOtherDevices handles POST /api/v1/users/me/devices/revoke-others
+// Removes every device except the one in the fingerprint header
+func (h *DeviceHandler) RevokeOtherDevices(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	fingerprint, err := currentFingerprint(c)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := h.trustService.RevokeOtherDevices(ctx, userID, fingerprint, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to revoke other devices",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"revoked": revoked})
+}
+
+// UntrustUserDevices handles POST /api/v1/internal/users/:id/devices/untrust
+// Called by the auth service after a password change or compromise report it handled
+func (h *DeviceHandler) UntrustUserDevices(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+	serviceName := middleware.GetServiceName(ctx)
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	var req domain.UntrustDevicesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.trustService.UntrustAllForService(ctx, targetID, req.Reason, serviceName, requestID); err != nil {
+		h.log.WithContext(ctx).Error("failed to untrust devices",
+			logger.RequestID(requestID),
+			logger.UserID(targetID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetFraudData handles GET /api/v1/internal/users/:id/devices
+// Returns the user's devices, with fingerprint and IP hashes, to the fraud service
+func (h *DeviceHandler) GetFraudData(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+	serviceName := middleware.GetServiceName(ctx)
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	devices, err := h.deviceService.GetFraudData(ctx, targetID, serviceName, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to get device fraud data",
+			logger.RequestID(requestID),
+			logger.UserID(targetID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, devices)
+}
+
+// FindByFingerprint handles GET /api/v1/internal/devices/fingerprints/:hash
+// Returns the devices of every user sharing the fingerprint hash
+func (h *DeviceHandler) FindByFingerprint(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+	serviceName := middleware.GetServiceName(ctx)
+
+	fingerprintHash := c.Param("hash")
+	if _, err := hex.DecodeString(fingerprintHash); err != nil || len(fingerprintHash) != 64 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid fingerprint hash")
+	}
+
+	devices, err := h.deviceService.FindByFingerprint(ctx, strings.ToLower(fingerprintHash), serviceName, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to find devices by fingerprint",
+			logger.RequestID(requestID),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, devices)
+}
+
+// RegisterPushToken handles PUT /api/v1/users/me/devices/push-token
+// Sets or rotates the push token of the device in the fingerprint header
+func (h *DeviceHandler) RegisterPushToken(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	fingerprint, err := currentFingerprint(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.RegisterPushTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.pushService.RegisterPushToken(ctx, userID, fingerprint, &req, c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Error("failed to register push token",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RemovePushToken handles DELETE /api/v1/users/me/devices/push-token
+// Stops push notifications to the device in the fingerprint header
+func (h *DeviceHandler) RemovePushToken(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	fingerprint, err := currentFingerprint(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.pushService.RemovePushToken(ctx, userID, fingerprint, c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Error("failed to remove push token", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetPushTokens handles GET /api/v1/internal/users/:id/devices/push-tokens
+// Returns the push tokens of the user's trusted devices to the notification service
+func (h *DeviceHandler) GetPushTokens(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+	serviceName := middleware.GetServiceName(ctx)
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	tokens, err := h.pushService.GetActiveTokens(ctx, targetID, serviceName, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to get push tokens",
+			logger.RequestID(requestID),
+			logger.UserID(targetID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// InvalidatePushToken handles POST /api/v1/internal/devices/push-tokens/invalidate
+// Called by the notification service when APNs or FCM rejects a token
+func (h *DeviceHandler) InvalidatePushToken(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+	serviceName := middleware.GetServiceName(ctx)
+
+	var req domain.InvalidatePushTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	invalidated, err := h.pushService.InvalidateToken(ctx, req.Token, serviceName, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to invalidate push token",
+			logger.RequestID(requestID),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"invalidated": invalidated})
+}
+
+// currentFingerprint reads the fingerprint of the device making the request
+func currentFingerprint(c echo.Context) (string, error) {
+	fingerprint := c.Request().Header.Get(DeviceFingerprintHeader)
+	if len(fingerprint) < 32 || len(fingerprint) > 128 {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "device fingerprint header required")
+	}
+	return fingerprint, nil
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// FeatureFlagHandler handles feature flag evaluation and administration requests
+type FeatureFlagHandler struct {
+	flagService *service.FeatureFlagService
+	log         *logger.Logger
+}
+
+// NewFeatureFlagHandler creates a new feature flag handler
+func NewFeatureFlagHandler(flagService *service.FeatureFlagService, log *logger.Logger) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		flagService: flagService,
+		log:         log.Named("feature_flag_handler"),
+	}
+}
+
+// GetFeatureFlags handles GET /api/v1/users/me/feature-flags
+// The device fingerprint header, when sent, enables device OS targeting
+func (h *FeatureFlagHandler) GetFeatureFlags(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	flags, err := h.flagService.EvaluateFlags(ctx, userID, c.Request().Header.Get(DeviceFingerprintHeader))
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to evaluate feature flags", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, flags)
+}
+
+// ListFlags handles GET /api/v1/admin/feature-flags
+func (h *FeatureFlagHandler) ListFlags(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	flags, err := h.flagService.ListFlags(ctx)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to list feature flags", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, flags)
+}
+
+// GetFlag handles GET /api/v1/admin/feature-flags/:key
+func (h *FeatureFlagHandler) GetFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	flag, err := h.flagService.GetFlag(ctx, c.Param("key"))
+	if err != nil {
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, flag)
+}
+
+// CreateFlag handles POST /api/v1/admin/feature-flags
+func (h *FeatureFlagHandler) CreateFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	adminID, err := flagAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.CreateFeatureFlagRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	flag, err := h.flagService.CreateFlag(ctx, &req, adminID, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to create feature flag",
+			logger.RequestID(requestID),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, flag)
+}
+
+// UpdateFlag handles PATCH /api/v1/admin/feature-flags/:key
+func (h *FeatureFlagHandler) UpdateFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	adminID, err := flagAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.UpdateFeatureFlagRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	flag, err := h.flagService.UpdateFlag(ctx, c.Param("key"), &req, adminID, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to update feature flag",
+			logger.RequestID(requestID),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag handles DELETE /api/v1/admin/feature-flags/:key
+func (h *FeatureFlagHandler) DeleteFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	adminID, err := flagAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.flagService.DeleteFlag(ctx, c.Param("key"), adminID, c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Warn("failed to delete feature flag",
+			logger.RequestID(requestID),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// flagAdmin returns the admin changing flag definitions
+// Changes must be attributable to a person, so service tokens are rejected
+func flagAdmin(c echo.Context) (string, error) {
+	if middleware.IsServiceCall(c.Request().Context()) {
+		return "", echo.NewHTTPError(http.StatusForbidden, "admin token required")
+	}
+	adminID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return "", echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	return adminID.String(), nil
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// PhoneHandler handles phone verification HTTP requests
+type PhoneHandler struct {
+	phoneService *service.PhoneVerificationService
+	log          *logger.Logger
+}
+
+// NewPhoneHandler creates a new phone verification handler
+func NewPhoneHandler(phoneService *service.PhoneVerificationService, log *logger.Logger) *PhoneHandler {
+	return &PhoneHandler{
+		phoneService: phoneService,
+		log:          log.Named("phone_handler"),
+	}
+}
+
+// StartVerification handles POST /api/v1/users/me/phone/verification
+func (h *PhoneHandler) StartVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	resp, err := h.phoneService.StartVerification(ctx, userID, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to start phone verification",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, resp)
+}
+
+// ConfirmVerification handles POST /api/v1/users/me/phone/verification/confirm
+func (h *PhoneHandler) ConfirmVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var req domain.ConfirmPhoneVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.phoneService.ConfirmVerification(ctx, userID, &req, c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Warn("phone verification failed",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	"github.com/banking/user-service/internal/api/http/middleware"
@@ -84,6 +87,10 @@ func (h *PreferenceHandler) UpdateNotificationSettings(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
 
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	clientIP := c.RealIP()
 
 	prefs, err := h.prefService.UpdateNotificationSettings(ctx, userID, &req, clientIP, requestID)
@@ -94,3 +101,282 @@ func (h *PreferenceHandler) UpdateNotificationSettings(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, prefs)
 }
+
+// GetNotificationSummary handles GET /api/v1/internal/users/:id/preferences/notifications
+// Returns the user's notification channels and whether each type is deliverable at the
+// instant in the optional "at" query parameter (RFC 3339, default now)
+func (h *PreferenceHandler) GetNotificationSummary(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+	serviceName := middleware.GetServiceName(ctx)
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	at := time.Now()
+	if raw := c.QueryParam("at"); raw != "" {
+		at, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid at, expected RFC 3339")
+		}
+	}
+
+	summary, err := h.prefService.GetNotificationSummary(ctx, targetID, at, serviceName, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to get notification summary",
+			logger.RequestID(requestID),
+			logger.UserID(targetID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+// GetPreferenceVersions handles GET /api/v1/users/me/preferences/versions
+func (h *PreferenceHandler) GetPreferenceVersions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	versions, err := h.prefService.GetPreferenceVersions(ctx, userID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to list preference versions", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+// GetPreferencesAsOf handles GET /api/v1/users/me/preferences/as-of?at=
+func (h *PreferenceHandler) GetPreferencesAsOf(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	at, err := parseAsOf(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := h.prefService.GetPreferencesAsOf(ctx, userID, at)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to get preferences as of", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, version)
+}
+
+// DiffPreferences handles GET /api/v1/users/me/preferences/diff?from=&to=
+func (h *PreferenceHandler) DiffPreferences(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	from, to, err := parseVersionRange(c)
+	if err != nil {
+		return err
+	}
+
+	diff, err := h.prefService.DiffPreferenceVersions(ctx, userID, from, to)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to diff preference versions", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+// AdminGetPreferenceVersions handles GET /api/v1/admin/users/:id/preferences/versions
+func (h *PreferenceHandler) AdminGetPreferenceVersions(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	adminID, userID, err := adminTarget(c)
+	if err != nil {
+		return err
+	}
+
+	versions, err := h.prefService.GetPreferenceVersionsAsAdmin(ctx, userID, adminID.String(), c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to list preference versions",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+// AdminGetPreferencesAsOf handles GET /api/v1/admin/users/:id/preferences/as-of?at=
+func (h *PreferenceHandler) AdminGetPreferencesAsOf(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	adminID, userID, err := adminTarget(c)
+	if err != nil {
+		return err
+	}
+
+	at, err := parseAsOf(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := h.prefService.GetPreferencesAsOfAsAdmin(ctx, userID, at, adminID.String(), c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to get preferences as of",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, version)
+}
+
+// AdminDiffPreferences handles GET /api/v1/admin/users/:id/preferences/diff?from=&to=
+func (h *PreferenceHandler) AdminDiffPreferences(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	adminID, userID, err := adminTarget(c)
+	if err != nil {
+		return err
+	}
+
+	from, to, err := parseVersionRange(c)
+	if err != nil {
+		return err
+	}
+
+	diff, err := h.prefService.DiffPreferenceVersionsAsAdmin(ctx, userID, from, to, adminID.String(), c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to diff preference versions",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+// GetCustomSettings handles GET /api/v1/users/me/preferences/custom/:namespace
+func (h *PreferenceHandler) GetCustomSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	doc, err := h.prefService.GetCustomSettings(ctx, userID, c.Param("namespace"))
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to get custom settings", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, doc)
+}
+
+// UpdateCustomSettings handles PUT /api/v1/users/me/preferences/custom/:namespace
+// The body is the namespace's whole settings document
+func (h *PreferenceHandler) UpdateCustomSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Body only: Bind would also copy the :namespace path parameter into the document
+	var settings map[string]interface{}
+	if err := (&echo.DefaultBinder{}).BindBody(c, &settings); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	doc, err := h.prefService.UpdateCustomSettings(ctx, userID, c.Param("namespace"), settings, c.RealIP(), requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Warn("failed to update custom settings", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusOK, doc)
+}
+
+// DeleteCustomSettings handles DELETE /api/v1/users/me/preferences/custom/:namespace
+func (h *PreferenceHandler) DeleteCustomSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := h.prefService.DeleteCustomSettings(ctx, userID, c.Param("namespace"), c.RealIP(), requestID); err != nil {
+		h.log.WithContext(ctx).Warn("failed to delete custom settings", logger.ErrorField(err))
+		return handleServiceError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// adminTarget returns the investigating admin and the user in the :id path parameter
+// Investigator access must be attributable to a person, so service tokens are rejected
+func adminTarget(c echo.Context) (uuid.UUID, uuid.UUID, error) {
+	if middleware.IsServiceCall(c.Request().Context()) {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusForbidden, "admin token required")
+	}
+	adminID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+	return adminID, userID, nil
+}
+
+// parseAsOf reads the required "at" query parameter (RFC 3339)
+func parseAsOf(c echo.Context) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, c.QueryParam("at"))
+	if err != nil {
+		return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "invalid at, expected RFC 3339")
+	}
+	return at, nil
+}
+
+// parseVersionRange reads the required "from" and "to" version query parameters
+func parseVersionRange(c echo.Context) (int, int, error) {
+	from, err := strconv.Atoi(c.QueryParam("from"))
+	if err != nil || from < 1 {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "invalid from version")
+	}
+	to, err := strconv.Atoi(c.QueryParam("to"))
+	if err != nil || to < 1 {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "invalid to version")
+	}
+	return from, to, nil
+}
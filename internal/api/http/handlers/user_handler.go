@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/customsettings"
 	"github.com/banking/user-service/internal/domain"
 	"github.com/banking/user-service/internal/pkg/logger"
 	"github.com/banking/user-service/internal/service"
@@ -29,16 +31,54 @@ func NewUserHandler(userService *service.UserService, log *logger.Logger) *UserH
 
 // ProfileResponse is the response for profile endpoints
 type ProfileResponse struct {
-	ID        uuid.UUID          `json:"id"`
-	LegalName string             `json:"legal_name"`
-	Email     string             `json:"email"`
-	Phone     string             `json:"phone,omitempty"`
-	DOB       *string            `json:"dob,omitempty"`
-	Country   string             `json:"country"`
-	Status    domain.UserStatus  `json:"status"`
-	KYCStatus domain.KYCStatus   `json:"kyc_status"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
+	ID              uuid.UUID         `json:"id"`
+	LegalName       string            `json:"legal_name"`
+	Email           string            `json:"email"`
+	Phone           string            `json:"phone,omitempty"`
+	PhoneVerifiedAt *time.Time        `json:"phone_verified_at,omitempty"`
+	DOB             *string           `json:"dob,omitempty"`
+	Country         string            `json:"country"`
+	Status          domain.UserStatus `json:"status"`
+	KYCStatus       domain.KYCStatus  `json:"kyc_status"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// CreateUser handles POST /api/v1/users (service-to-service only)
+func (h *UserHandler) CreateUser(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	// Parse request body
+	var req domain.CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	// Validate request
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get calling service and client IP for audit
+	serviceName := middleware.GetServiceName(ctx)
+	clientIP := c.RealIP()
+
+	// Create user
+	user, err := h.userService.CreateUser(ctx, &req, serviceName, clientIP, requestID)
+	if err != nil {
+		if err != service.ErrUserAlreadyExists {
+			h.log.WithContext(ctx).Error("failed to create user",
+				logger.RequestID(requestID),
+				logger.ErrorField(err),
+			)
+		}
+		return handleServiceError(err)
+	}
+
+	// Convert to response
+	response := h.toProfileResponse(user)
+	return c.JSON(http.StatusCreated, response)
 }
 
 // GetProfile handles GET /api/v1/users/me
@@ -137,17 +177,96 @@ func (h *UserHandler) DeleteProfile(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// RequestEmailChange handles POST /api/v1/users/me/email
+func (h *UserHandler) RequestEmailChange(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	// Get authenticated user ID
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Parse request body
+	var req domain.ChangeEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	// Validate request
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get client IP for audit
+	clientIP := c.RealIP()
+
+	// Start email change; the address is not updated until confirmed
+	resp, err := h.userService.RequestEmailChange(ctx, userID, &req, clientIP, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to request email change",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, resp)
+}
+
+// ConfirmEmailChange handles POST /api/v1/users/me/email/confirm
+func (h *UserHandler) ConfirmEmailChange(c echo.Context) error {
+	ctx := c.Request().Context()
+	requestID := middleware.GetRequestIDFromEcho(c)
+
+	// Get authenticated user ID
+	userID, ok := middleware.GetUserIDFromEcho(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Parse request body
+	var req domain.ConfirmEmailChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	// Validate request
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get client IP for audit
+	clientIP := c.RealIP()
+
+	user, err := h.userService.ConfirmEmailChange(ctx, userID, &req, clientIP, requestID)
+	if err != nil {
+		h.log.WithContext(ctx).Error("failed to confirm email change",
+			logger.RequestID(requestID),
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+		return handleServiceError(err)
+	}
+
+	response := h.toProfileResponse(user)
+	return c.JSON(http.StatusOK, response)
+}
+
 func (h *UserHandler) toProfileResponse(user *domain.User) *ProfileResponse {
 	resp := &ProfileResponse{
-		ID:        user.ID,
-		LegalName: user.LegalName,
-		Email:     user.Email,
-		Phone:     user.Phone,
-		Country:   user.Country,
-		Status:    user.Status,
-		KYCStatus: user.KYCStatus,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		LegalName:       user.LegalName,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		Country:         user.Country,
+		Status:          user.Status,
+		KYCStatus:       user.KYCStatus,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 
 	if user.DOB != nil {
@@ -160,6 +279,12 @@ func (h *UserHandler) toProfileResponse(user *domain.User) *ProfileResponse {
 
 // handleServiceError converts service errors to HTTP errors
 func handleServiceError(err error) error {
+	// Schema violations say which value was rejected
+	var invalidSettings *customsettings.ValidationError
+	if errors.As(err, &invalidSettings) {
+		return echo.NewHTTPError(http.StatusBadRequest, invalidSettings.Error())
+	}
+
 	switch err {
 	case service.ErrUserNotFound:
 		return echo.NewHTTPError(http.StatusNotFound, "user not found")
@@ -169,6 +294,60 @@ func handleServiceError(err error) error {
 		return echo.NewHTTPError(http.StatusConflict, "resource was modified, please retry")
 	case service.ErrInvalidInput:
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid input")
+	case service.ErrInvalidToken:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid or expired token")
+	case service.ErrInvalidOTP:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid or expired verification code")
+	case service.ErrOTPAttemptsExceeded:
+		return echo.NewHTTPError(http.StatusTooManyRequests, "too many verification attempts")
+	case service.ErrOTPCooldown:
+		return echo.NewHTTPError(http.StatusTooManyRequests, "verification code sent too recently")
+	case service.ErrPhoneNotVerified:
+		return echo.NewHTTPError(http.StatusConflict, "phone number must be verified to use SMS")
+	case service.ErrAccountNotDeleted:
+		return echo.NewHTTPError(http.StatusConflict, "account is not deleted")
+	case service.ErrRestoreExpired:
+		return echo.NewHTTPError(http.StatusGone, "restore grace period has expired")
+	case service.ErrAddressNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, "address not found")
+	case service.ErrInvalidValidityWindow:
+		return echo.NewHTTPError(http.StatusBadRequest, "validity window is required for temporary addresses only and must end in the future")
+	case service.ErrTemporaryNotStarted:
+		return echo.NewHTTPError(http.StatusBadRequest, "a temporary address can only be made primary once its validity window has started")
+	case service.ErrDeviceNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, "device not found")
+	case service.ErrStepUpRequired:
+		return echo.NewHTTPError(http.StatusUnauthorized, "recent step-up authentication required")
+	case service.ErrExportInProgress:
+		return echo.NewHTTPError(http.StatusConflict, "data export already in progress")
+	case service.ErrExportNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, "data export not found")
+	case service.ErrExportNotReady:
+		return echo.NewHTTPError(http.StatusConflict, "data export not ready")
+	case service.ErrPreferenceNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, "preferences not found")
+	case service.ErrPreferenceVersionNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, "preference version not found")
+	case service.ErrFeatureFlagNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, "feature flag not found")
+	case service.ErrFeatureFlagExists:
+		return echo.NewHTTPError(http.StatusConflict, "feature flag already exists")
+	case service.ErrCustomSettingsNamespace:
+		return echo.NewHTTPError(http.StatusNotFound, "custom settings namespace not registered")
+	case service.ErrCustomSettingsTooLarge:
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "custom settings document too large")
+	case service.ErrConsentManaged:
+		return echo.NewHTTPError(http.StatusConflict, "this notification type follows your consent; use the consent API to change it")
+	case domain.ErrInvalidStatusTransition:
+		return echo.NewHTTPError(http.StatusConflict, "status transition not allowed")
+	case domain.ErrTransitionNotPermitted:
+		return echo.NewHTTPError(http.StatusForbidden, "not permitted to perform this status change")
+	case domain.ErrInvalidStatusReason:
+		return echo.NewHTTPError(http.StatusBadRequest, "reason not valid for this status change")
+	case domain.ErrInvalidQuietHours:
+		return echo.NewHTTPError(http.StatusBadRequest, "quiet hours must start and end at different hours")
+	case domain.ErrQuietHoursNotAllowed:
+		return echo.NewHTTPError(http.StatusBadRequest, "security notifications cannot have quiet hours")
 	default:
 		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
 	}
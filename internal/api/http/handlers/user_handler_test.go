@@ -12,6 +12,7 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/banking/user-service/internal/api/http/middleware"
+	"github.com/banking/user-service/internal/customsettings"
 	"github.com/banking/user-service/internal/domain"
 	"github.com/banking/user-service/internal/service"
 )
@@ -177,6 +178,34 @@ func TestHandleServiceError(t *testing.T) {
 		{"user already exists", service.ErrUserAlreadyExists, http.StatusConflict},
 		{"optimistic lock", service.ErrOptimisticLock, http.StatusConflict},
 		{"invalid input", service.ErrInvalidInput, http.StatusBadRequest},
+		{"invalid token", service.ErrInvalidToken, http.StatusBadRequest},
+		{"invalid otp", service.ErrInvalidOTP, http.StatusBadRequest},
+		{"otp attempts exceeded", service.ErrOTPAttemptsExceeded, http.StatusTooManyRequests},
+		{"otp cooldown", service.ErrOTPCooldown, http.StatusTooManyRequests},
+		{"phone not verified", service.ErrPhoneNotVerified, http.StatusConflict},
+		{"account not deleted", service.ErrAccountNotDeleted, http.StatusConflict},
+		{"restore expired", service.ErrRestoreExpired, http.StatusGone},
+		{"address not found", service.ErrAddressNotFound, http.StatusNotFound},
+		{"invalid validity window", service.ErrInvalidValidityWindow, http.StatusBadRequest},
+		{"temporary not started", service.ErrTemporaryNotStarted, http.StatusBadRequest},
+		{"device not found", service.ErrDeviceNotFound, http.StatusNotFound},
+		{"step-up required", service.ErrStepUpRequired, http.StatusUnauthorized},
+		{"export in progress", service.ErrExportInProgress, http.StatusConflict},
+		{"export not found", service.ErrExportNotFound, http.StatusNotFound},
+		{"export not ready", service.ErrExportNotReady, http.StatusConflict},
+		{"preference not found", service.ErrPreferenceNotFound, http.StatusNotFound},
+		{"preference version not found", service.ErrPreferenceVersionNotFound, http.StatusNotFound},
+		{"feature flag not found", service.ErrFeatureFlagNotFound, http.StatusNotFound},
+		{"feature flag exists", service.ErrFeatureFlagExists, http.StatusConflict},
+		{"custom settings namespace", service.ErrCustomSettingsNamespace, http.StatusNotFound},
+		{"custom settings too large", service.ErrCustomSettingsTooLarge, http.StatusRequestEntityTooLarge},
+		{"custom settings invalid", &customsettings.ValidationError{Namespace: "dashboard", Reason: "/widgets: is required"}, http.StatusBadRequest},
+		{"consent managed", service.ErrConsentManaged, http.StatusConflict},
+		{"invalid status transition", domain.ErrInvalidStatusTransition, http.StatusConflict},
+		{"transition not permitted", domain.ErrTransitionNotPermitted, http.StatusForbidden},
+		{"invalid status reason", domain.ErrInvalidStatusReason, http.StatusBadRequest},
+		{"invalid quiet hours", domain.ErrInvalidQuietHours, http.StatusBadRequest},
+		{"quiet hours not allowed", domain.ErrQuietHoursNotAllowed, http.StatusBadRequest},
 		{"unknown error", echo.ErrInternalServerError, http.StatusInternalServerError},
 	}
 
@@ -271,3 +300,19 @@ func TestRealIP_FromContext(t *testing.T) {
 		t.Error("expected IP address")
 	}
 }
+
+func TestRegisterPushToken_RequiresFingerprint(t *testing.T) {
+	h := &DeviceHandler{}
+	_, c, _ := setupTestContext(http.MethodPut, "/api/v1/users/me/devices/push-token", `{"provider": "FCM", "token": "token"}`)
+	c.Set(string(middleware.UserIDKey), uuid.New())
+
+	err := h.RegisterPushToken(c)
+
+	he, ok := err.(*echo.HTTPError)
+	if !ok {
+		t.Fatalf("expected HTTP error, got %v", err)
+	}
+	if he.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, he.Code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -18,6 +19,30 @@ const (
 	SubjectKey     ContextKey = "subject"
 	ScopesKey      ContextKey = "scopes"
 	ServiceNameKey ContextKey = "service_name"
+	AuthTimeKey    ContextKey = "auth_time"
+	ACRKey         ContextKey = "acr"
+)
+
+// Scopes required by privileged routes
+const (
+	// ScopeUserCreate allows a service to provision new user profiles
+	ScopeUserCreate = "user:create"
+	// ScopeUserAdmin allows back-office staff to manage other users' accounts
+	ScopeUserAdmin = "user:admin"
+	// ScopeUserStatus allows a service to lock and unlock accounts automatically
+	ScopeUserStatus = "user:status"
+	// ScopeUserRestore allows the auth service to restore an account after re-authenticating its owner
+	ScopeUserRestore = "user:restore"
+	// ScopeUserDevices allows the auth service to revoke device trust after a password change
+	ScopeUserDevices = "user:devices"
+	// ScopeDeviceFraud allows the fraud service to read device fingerprints and risk data
+	ScopeDeviceFraud = "device:fraud"
+	// ScopeDevicePush allows the notification service to read and invalidate push tokens
+	ScopeDevicePush = "device:push"
+	// ScopeNotificationPreferences allows the notification service to read delivery preferences
+	ScopeNotificationPreferences = "notification:preferences"
+	// ScopeFeatureFlagAdmin allows back-office staff to manage feature flag definitions
+	ScopeFeatureFlagAdmin = "feature_flag:admin"
 )
 
 // Common errors
@@ -43,6 +68,10 @@ type Claims struct {
 	jwt.RegisteredClaims
 	Scopes      []string `json:"scopes,omitempty"`
 	ServiceName string   `json:"service_name,omitempty"` // For service-to-service
+
+	// Step-up signals from the identity provider (OIDC auth_time and acr)
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"` // When the user last actively authenticated
+	ACR      string           `json:"acr,omitempty"`       // Strength of that authentication
 }
 
 // Auth middleware validates JWT tokens and extracts user identity
@@ -99,12 +128,27 @@ func Auth(cfg AuthConfig) echo.MiddlewareFunc {
 			if claims.ServiceName != "" {
 				ctx = context.WithValue(ctx, ServiceNameKey, claims.ServiceName)
 			}
+			if claims.AuthTime != nil {
+				ctx = context.WithValue(ctx, AuthTimeKey, claims.AuthTime.Time)
+			}
+			if claims.ACR != "" {
+				ctx = context.WithValue(ctx, ACRKey, claims.ACR)
+			}
 			c.SetRequest(c.Request().WithContext(ctx))
 
 			// Also set in Echo context
 			c.Set(string(UserIDKey), userID)
 			c.Set(string(SubjectKey), subject)
 			c.Set(string(ScopesKey), claims.Scopes)
+			if claims.ServiceName != "" {
+				c.Set(string(ServiceNameKey), claims.ServiceName)
+			}
+			if claims.AuthTime != nil {
+				c.Set(string(AuthTimeKey), claims.AuthTime.Time)
+			}
+			if claims.ACR != "" {
+				c.Set(string(ACRKey), claims.ACR)
+			}
 
 			return next(c)
 		}
@@ -222,6 +266,19 @@ func RequireScopes(requiredScopes ...string) echo.MiddlewareFunc {
 	}
 }
 
+// RequireServiceCall middleware restricts a route to service-to-service tokens
+// End-user tokens never carry a service_name claim, so they are rejected here
+func RequireServiceCall() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !IsServiceCall(c.Request().Context()) {
+				return echo.NewHTTPError(http.StatusForbidden, "service token required")
+			}
+			return next(c)
+		}
+	}
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(ctx context.Context) (uuid.UUID, bool) {
 	id, ok := ctx.Value(UserIDKey).(uuid.UUID)
@@ -239,3 +296,21 @@ func IsServiceCall(ctx context.Context) bool {
 	_, ok := ctx.Value(ServiceNameKey).(string)
 	return ok
 }
+
+// GetServiceName extracts the calling service name from context
+func GetServiceName(ctx context.Context) string {
+	name, _ := ctx.Value(ServiceNameKey).(string)
+	return name
+}
+
+// GetAuthTime extracts when the user last actively authenticated, if the token says
+func GetAuthTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(AuthTimeKey).(time.Time)
+	return t, ok
+}
+
+// GetACR extracts the authentication context class reference from context
+func GetACR(ctx context.Context) string {
+	acr, _ := ctx.Value(ACRKey).(string)
+	return acr
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -495,3 +496,40 @@ func TestGetUserID(t *testing.T) {
 		t.Errorf("expected %s, got %s", userID, gotID)
 	}
 }
+
+func TestRequireServiceCall_ServiceToken(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ServiceNameKey, "auth-service"))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := RequireServiceCall()(func(c echo.Context) error {
+		if got := GetServiceName(c.Request().Context()); got != "auth-service" {
+			t.Errorf("expected service name auth-service, got %q", got)
+		}
+		return c.String(http.StatusOK, "ok")
+	})
+
+	if err := handler(c); err != nil {
+		t.Errorf("expected no error for service token, got: %v", err)
+	}
+}
+
+func TestRequireServiceCall_UserToken(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(string(UserIDKey), uuid.New())
+
+	handler := RequireServiceCall()(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	err := handler(c)
+	httpErr, ok := err.(*echo.HTTPError)
+	if !ok || httpErr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 Forbidden, got: %v", err)
+	}
+}
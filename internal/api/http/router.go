@@ -34,9 +34,15 @@ type RouterDeps struct {
 	Logger         *logger.Logger
 	Health         *health.Health
 	UserService    *service.UserService
+	PhoneService   *service.PhoneVerificationService
+	ExportService  *service.DataExportService
 	AddressService *service.AddressService
 	DeviceService  *service.DeviceService
+	TrustService   *service.DeviceTrustService
+	PushService    *service.PushTokenService
 	PrefService    *service.PreferenceService
+	FlagService    *service.FeatureFlagService
+	ConsentService *service.ConsentService
 	RedisClient    *redis.Client
 	CircuitBreaker *resilience.CircuitBreaker
 	AuthPublicKey  interface{}
@@ -81,8 +87,8 @@ func (r *Router) setupMiddleware(deps RouterDeps) {
 	allowedOrigins := deps.Config.GetCORSAllowedOrigins()
 	r.echo.Use(echomiddleware.CORSWithConfig(echomiddleware.CORSConfig{
 		AllowOrigins:     allowedOrigins,
-		AllowMethods:     []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, middleware.RequestIDHeader},
+		AllowMethods:     []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, middleware.RequestIDHeader, handlers.DeviceFingerprintHeader},
 		ExposeHeaders:    []string{middleware.RequestIDHeader, "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           3600, // 1 hour preflight cache
@@ -151,12 +157,58 @@ func (r *Router) setupRoutes(deps RouterDeps) {
 	userHandler := handlers.NewUserHandler(deps.UserService, deps.Logger)
 	users := v1.Group("/users")
 	{
+		// Provisioning (auth service only)
+		users.POST("", userHandler.CreateUser,
+			middleware.RequireScopes(middleware.ScopeUserCreate),
+			middleware.RequireServiceCall(),
+		)
+
 		// Self routes (current user)
 		users.GET("/me", userHandler.GetProfile)
 		users.PUT("/me", userHandler.UpdateProfile)
 		users.DELETE("/me", userHandler.DeleteProfile)
+		users.POST("/me/email", userHandler.RequestEmailChange)
+		users.POST("/me/email/confirm", userHandler.ConfirmEmailChange)
+	}
+
+	// Back-office account management
+	adminHandler := handlers.NewAdminHandler(deps.UserService, deps.Logger)
+	adminUsers := v1.Group("/admin/users", middleware.RequireScopes(middleware.ScopeUserAdmin))
+	{
+		adminUsers.POST("/:id/suspend", adminHandler.SuspendUser)
+		adminUsers.POST("/:id/reactivate", adminHandler.ReactivateUser)
+		adminUsers.POST("/:id/close", adminHandler.CloseUser)
+		adminUsers.POST("/:id/restore", adminHandler.RestoreUser)
+	}
+
+	// Automated account controls (service-to-service only)
+	internalUsers := v1.Group("/internal/users", middleware.RequireServiceCall())
+	{
+		internalUsers.POST("/:id/lock", adminHandler.LockUser, middleware.RequireScopes(middleware.ScopeUserStatus))
+		internalUsers.POST("/:id/unlock", adminHandler.UnlockUser, middleware.RequireScopes(middleware.ScopeUserStatus))
+		internalUsers.POST("/:id/restore", adminHandler.RestoreUserForOwner, middleware.RequireScopes(middleware.ScopeUserRestore))
+	}
+
+	// Phone verification routes
+	phoneHandler := handlers.NewPhoneHandler(deps.PhoneService, deps.Logger)
+	phone := v1.Group("/users/me/phone")
+	{
+		phone.POST("/verification", phoneHandler.StartVerification)
+		phone.POST("/verification/confirm", phoneHandler.ConfirmVerification)
 	}
 
+	// Data subject access export routes
+	exportHandler := handlers.NewDataExportHandler(deps.ExportService, deps.Logger)
+	exports := v1.Group("/users/me/exports")
+	{
+		exports.POST("", exportHandler.RequestExport)
+		exports.GET("/:exportId", exportHandler.GetExport)
+		exports.GET("/:exportId/download", exportHandler.DownloadExport)
+	}
+	adminUsers.POST("/:id/exports", exportHandler.AdminRequestExport)
+	adminUsers.GET("/:id/exports/:exportId", exportHandler.AdminGetExport)
+	adminUsers.GET("/:id/exports/:exportId/download", exportHandler.AdminDownloadExport)
+
 	// Address routes
 	addressHandler := handlers.NewAddressHandler(deps.AddressService, deps.Logger)
 	addresses := v1.Group("/users/me/addresses")
@@ -166,16 +218,40 @@ func (r *Router) setupRoutes(deps RouterDeps) {
 		addresses.GET("/:id", addressHandler.GetAddress)
 		addresses.PUT("/:id", addressHandler.UpdateAddress)
 		addresses.DELETE("/:id", addressHandler.DeleteAddress)
+		addresses.GET("/:id/history", addressHandler.GetAddressHistory)
+		addresses.POST("/:id/confirm-change", addressHandler.ConfirmAddressChange)
 	}
+	adminUsers.GET("/:id/addresses/:addressId/history", addressHandler.AdminGetAddressHistory)
 
 	// Device routes
-	deviceHandler := handlers.NewDeviceHandler(deps.DeviceService, deps.Logger)
+	deviceHandler := handlers.NewDeviceHandler(deps.DeviceService, deps.TrustService, deps.PushService, deps.Logger)
 	devices := v1.Group("/users/me/devices")
 	{
 		devices.GET("", deviceHandler.ListDevices)
+		devices.POST("", deviceHandler.RegisterDevice)
+		devices.POST("/heartbeat", deviceHandler.Heartbeat)
 		devices.DELETE("/:id", deviceHandler.RemoveDevice)
+		devices.POST("/step-up", deviceHandler.StartStepUp)
+		devices.POST("/trust", deviceHandler.TrustCurrentDevice)
+		devices.DELETE("/:id/trust", deviceHandler.UntrustDevice)
+		devices.POST("/report-compromise", deviceHandler.ReportCompromise)
+		devices.POST("/revoke-others", deviceHandler.RevokeOtherDevices)
+		devices.PUT("/push-token", deviceHandler.RegisterPushToken)
+		devices.DELETE("/push-token", deviceHandler.RemovePushToken)
+	}
+	internalUsers.POST("/:id/devices/untrust", deviceHandler.UntrustUserDevices, middleware.RequireScopes(middleware.ScopeUserDevices))
+
+	// Fraud service device feed (service-to-service only)
+	internalUsers.GET("/:id/devices", deviceHandler.GetFraudData, middleware.RequireScopes(middleware.ScopeDeviceFraud))
+	internalDevices := v1.Group("/internal/devices", middleware.RequireServiceCall())
+	{
+		internalDevices.GET("/fingerprints/:hash", deviceHandler.FindByFingerprint, middleware.RequireScopes(middleware.ScopeDeviceFraud))
 	}
 
+	// Notification service push tokens (service-to-service only)
+	internalUsers.GET("/:id/devices/push-tokens", deviceHandler.GetPushTokens, middleware.RequireScopes(middleware.ScopeDevicePush))
+	internalDevices.POST("/push-tokens/invalidate", deviceHandler.InvalidatePushToken, middleware.RequireScopes(middleware.ScopeDevicePush))
+
 	// Preference routes
 	prefHandler := handlers.NewPreferenceHandler(deps.PrefService, deps.Logger)
 	prefs := v1.Group("/users/me/preferences")
@@ -183,6 +259,40 @@ func (r *Router) setupRoutes(deps RouterDeps) {
 		prefs.GET("", prefHandler.GetPreferences)
 		prefs.PUT("", prefHandler.UpdatePreferences)
 		prefs.PUT("/notifications", prefHandler.UpdateNotificationSettings)
+		prefs.GET("/versions", prefHandler.GetPreferenceVersions)
+		prefs.GET("/as-of", prefHandler.GetPreferencesAsOf)
+		prefs.GET("/diff", prefHandler.DiffPreferences)
+		prefs.GET("/custom/:namespace", prefHandler.GetCustomSettings)
+		prefs.PUT("/custom/:namespace", prefHandler.UpdateCustomSettings)
+		prefs.DELETE("/custom/:namespace", prefHandler.DeleteCustomSettings)
+	}
+	adminUsers.GET("/:id/preferences/versions", prefHandler.AdminGetPreferenceVersions)
+	adminUsers.GET("/:id/preferences/as-of", prefHandler.AdminGetPreferencesAsOf)
+	adminUsers.GET("/:id/preferences/diff", prefHandler.AdminDiffPreferences)
+	internalUsers.GET("/:id/preferences/notifications", prefHandler.GetNotificationSummary, middleware.RequireScopes(middleware.ScopeNotificationPreferences))
+
+	// Consent routes
+	consentHandler := handlers.NewConsentHandler(deps.ConsentService, deps.Logger)
+	consents := v1.Group("/users/me/consents")
+	{
+		consents.GET("", consentHandler.GetConsents)
+		consents.POST("/grant", consentHandler.GrantConsent)
+		consents.POST("/confirm", consentHandler.ConfirmConsent)
+		consents.POST("/withdraw", consentHandler.WithdrawConsent)
+		consents.GET("/history", consentHandler.GetConsentHistory)
+	}
+	adminUsers.GET("/:id/consents/history", consentHandler.AdminGetConsentHistory)
+
+	// Feature flag routes
+	flagHandler := handlers.NewFeatureFlagHandler(deps.FlagService, deps.Logger)
+	v1.GET("/users/me/feature-flags", flagHandler.GetFeatureFlags)
+	adminFlags := v1.Group("/admin/feature-flags", middleware.RequireScopes(middleware.ScopeFeatureFlagAdmin))
+	{
+		adminFlags.GET("", flagHandler.ListFlags)
+		adminFlags.POST("", flagHandler.CreateFlag)
+		adminFlags.GET("/:key", flagHandler.GetFlag)
+		adminFlags.PATCH("/:key", flagHandler.UpdateFlag)
+		adminFlags.DELETE("/:key", flagHandler.DeleteFlag)
 	}
 }
 
@@ -0,0 +1,132 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// maxPageResponse bounds how much of one page of the audit store's answer is read
+const maxPageResponse = 4 << 20
+
+// HTTPReaderConfig holds settings for the audit store's query API
+type HTTPReaderConfig struct {
+	URL     string        // Base URL; events are read from {URL}/users/{user_id}/events
+	APIKey  string        // Sent as a bearer token when set
+	Timeout time.Duration // Per-request timeout (default: 10s)
+}
+
+// HTTPReader reads a user's audit trail from the audit store the audit topic feeds
+//
+// The store answers pages of events oldest first, in the form they were published:
+//
+//	{"events": [...], "next_cursor": "..."}
+//
+// An empty next_cursor marks the last page
+type HTTPReader struct {
+	client *http.Client
+	cb     *resilience.CircuitBreaker
+	cfg    HTTPReaderConfig
+}
+
+// eventPage is one page of the store's answer
+type eventPage struct {
+	Events     []*audit.AuditEvent `json:"events"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// NewHTTPReader creates a new audit store reader
+// cb may be nil, e.g. in tests
+func NewHTTPReader(cfg HTTPReaderConfig, cb *resilience.CircuitBreaker) *HTTPReader {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &HTTPReader{
+		client: &http.Client{Timeout: cfg.Timeout},
+		cb:     cb,
+		cfg:    cfg,
+	}
+}
+
+// ListByUserID returns every audit event recorded about the user
+// A page that cannot be read fails the whole call, so a partial trail is never returned
+func (r *HTTPReader) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*audit.AuditEvent, error) {
+	events := []*audit.AuditEvent{}
+	cursor := ""
+	for {
+		page, err := r.fetchPage(ctx, userID, cursor)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page.Events...)
+		if page.NextCursor == "" {
+			return events, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func (r *HTTPReader) fetchPage(ctx context.Context, userID uuid.UUID, cursor string) (*eventPage, error) {
+	if r.cb == nil {
+		return r.fetch(ctx, userID, cursor)
+	}
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.fetch(ctx, userID, cursor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*eventPage), nil
+}
+
+func (r *HTTPReader) fetch(ctx context.Context, userID uuid.UUID, cursor string) (*eventPage, error) {
+	endpoint, err := url.JoinPath(r.cfg.URL, "users", userID.String(), "events")
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit store URL: %w", err)
+	}
+	if cursor != "" {
+		endpoint += "?" + url.Values{"cursor": {cursor}}.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build audit store request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.cfg.APIKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("audit store request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("audit store returned status %d", resp.StatusCode)
+	}
+
+	var page eventPage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPageResponse)).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode audit store response: %w", err)
+	}
+
+	// The store is queried by user, but a mismatch would leak another user's trail into the export
+	for _, e := range page.Events {
+		if e == nil || e.UserID != userID.String() {
+			return nil, fmt.Errorf("audit store returned an event for another user")
+		}
+	}
+
+	return &page, nil
+}
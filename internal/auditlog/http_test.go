@@ -0,0 +1,90 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain/audit"
+)
+
+func TestHTTPReader_FollowsCursor(t *testing.T) {
+	userID := uuid.New()
+	pages := map[string]eventPage{
+		"": {
+			Events:     []*audit.AuditEvent{{EventID: "1", UserID: userID.String()}},
+			NextCursor: "page-2",
+		},
+		"page-2": {
+			Events: []*audit.AuditEvent{{EventID: "2", UserID: userID.String()}},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/users/"+userID.String()+"/events" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(pages[r.URL.Query().Get("cursor")])
+	}))
+	defer server.Close()
+
+	reader := NewHTTPReader(HTTPReaderConfig{URL: server.URL + "/v1", APIKey: "secret"}, nil)
+
+	events, err := reader.ListByUserID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].EventID != "1" || events[1].EventID != "2" {
+		t.Errorf("expected events 1 and 2 in order, got %+v", events)
+	}
+}
+
+func TestHTTPReader_Errors(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"error status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+		{"malformed body", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("not json"))
+		}},
+		{"another user's event", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(eventPage{Events: []*audit.AuditEvent{{EventID: "1", UserID: uuid.NewString()}}})
+		}},
+		{"failing later page", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("cursor") != "" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(eventPage{
+				Events:     []*audit.AuditEvent{{EventID: "1", UserID: userID.String()}},
+				NextCursor: "page-2",
+			})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			reader := NewHTTPReader(HTTPReaderConfig{URL: server.URL}, nil)
+			if events, err := reader.ListByUserID(context.Background(), userID); err == nil {
+				t.Errorf("expected error, got %d events", len(events))
+			}
+		})
+	}
+}
@@ -10,16 +10,22 @@ import (
 
 // Config holds all configuration for the service
 type Config struct {
-	Server     ServerConfig
-	Database   DatabaseConfig
-	Redis      RedisConfig
-	MongoDB    MongoDBConfig
-	Kafka      KafkaConfig
-	Encryption EncryptionConfig
-	Auth       AuthConfig
-	RateLimit  RateLimitConfig
-	Tracing    TracingConfig
-	Logging    LoggingConfig
+	Server            ServerConfig
+	Database          DatabaseConfig
+	Redis             RedisConfig
+	MongoDB           MongoDBConfig
+	Kafka             KafkaConfig
+	Encryption        EncryptionConfig
+	Auth              AuthConfig
+	Account           AccountConfig
+	AddressValidation AddressValidationConfig `mapstructure:"address_validation"`
+	AuditStore        AuditStoreConfig        `mapstructure:"audit_store"`
+	Devices           DevicesConfig
+	Jobs              JobsConfig
+	RateLimit         RateLimitConfig
+	Tracing           TracingConfig
+	Metrics           MetricsConfig
+	Logging           LoggingConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -73,6 +79,8 @@ type KafkaConfig struct {
 	Brokers            []string `mapstructure:"brokers"`
 	AuditTopic         string   `mapstructure:"audit_topic"`
 	EventTopic         string   `mapstructure:"event_topic"`
+	DeviceTopic        string   `mapstructure:"device_topic"`       // Device changes for the fraud service
+	NotificationTopic  string   `mapstructure:"notification_topic"` // Contact notifications, read only by the notification service
 	ConsumerGroup      string   `mapstructure:"consumer_group"`
 	RequiredAcks       int      `mapstructure:"required_acks"`
 	EnableIdempotent   bool     `mapstructure:"enable_idempotent"`
@@ -100,6 +108,68 @@ type AuthConfig struct {
 	ServiceMTLSKey   string   `mapstructure:"service_mtls_key"`
 }
 
+// AccountConfig holds account lifecycle settings
+type AccountConfig struct {
+	EmailChangeTokenTTL    time.Duration `mapstructure:"email_change_token_ttl"`
+	PhoneOTPTTL            time.Duration `mapstructure:"phone_otp_ttl"`
+	PhoneOTPResendCooldown time.Duration `mapstructure:"phone_otp_resend_cooldown"`
+	PhoneOTPMaxAttempts    int           `mapstructure:"phone_otp_max_attempts"`
+	PhoneOTPLockout        time.Duration `mapstructure:"phone_otp_lockout"`
+	RestoreGracePeriod     time.Duration `mapstructure:"restore_grace_period"`
+	ErasureRetention       time.Duration `mapstructure:"erasure_retention"` // Time after deletion before PII is wiped
+	DataExportTTL          time.Duration `mapstructure:"data_export_ttl"`   // How long a finished export can be downloaded
+	DataExportTimeout      time.Duration `mapstructure:"data_export_timeout"`
+
+	// Risky address edits are held until confirmed; see service.AddressChangePolicy
+	AddressChangeConfirmationTTL time.Duration `mapstructure:"address_change_confirmation_ttl"`
+	AddressChangeVelocityWindow  time.Duration `mapstructure:"address_change_velocity_window"`
+	AddressChangeMaxPerWindow    int           `mapstructure:"address_change_max_per_window"`
+
+	// Email marketing consent is only granted once the emailed link is used
+	ConsentConfirmationTTL time.Duration `mapstructure:"consent_confirmation_ttl"`
+}
+
+// AddressValidationConfig holds the external address validation provider settings
+// Only the local rules run when no provider URL is set
+type AddressValidationConfig struct {
+	ProviderURL     string        `mapstructure:"provider_url"`
+	ProviderAPIKey  string        `mapstructure:"provider_api_key"`
+	ProviderName    string        `mapstructure:"provider_name"` // Recorded as validation_source
+	ProviderTimeout time.Duration `mapstructure:"provider_timeout"`
+}
+
+// AuditStoreConfig holds the audit store query API settings
+// Data exports report their audit events as incomplete when no URL is set
+type AuditStoreConfig struct {
+	URL     string        `mapstructure:"url"`
+	APIKey  string        `mapstructure:"api_key"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// DevicesConfig holds device registration settings
+type DevicesConfig struct {
+	MaxPerUser        int           `mapstructure:"max_per_user"`       // Oldest inactive device is evicted beyond this
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"` // Minimum time between recorded heartbeats
+	RiskThreshold     int           `mapstructure:"risk_threshold"`     // Suspicion score (0-100) that flags the user
+	RapidChangeWindow time.Duration `mapstructure:"rapid_change_window"`
+	TrustTTL          time.Duration `mapstructure:"trust_ttl"`       // How long a device stays trusted
+	StepUpMaxAge      time.Duration `mapstructure:"step_up_max_age"` // Max age of the token's auth_time to trust without a code
+	StepUpACRs        []string      `mapstructure:"step_up_acrs"`    // Accepted acr values; empty accepts any
+	InactiveDays      int           `mapstructure:"inactive_days"`   // Devices unused this long are pruned
+}
+
+// JobsConfig holds background job schedules
+type JobsConfig struct {
+	ErasureInterval            time.Duration `mapstructure:"erasure_interval"`
+	ErasureBatchSize           int           `mapstructure:"erasure_batch_size"`
+	AddressValidationInterval  time.Duration `mapstructure:"address_validation_interval"`
+	AddressValidationBatchSize int           `mapstructure:"address_validation_batch_size"`
+	AddressExpiryInterval      time.Duration `mapstructure:"address_expiry_interval"`
+	AddressExpiryBatchSize     int           `mapstructure:"address_expiry_batch_size"`
+	DevicePruneInterval        time.Duration `mapstructure:"device_prune_interval"`
+	DevicePruneBatchSize       int           `mapstructure:"device_prune_batch_size"`
+}
+
 // RateLimitConfig holds rate limiting settings
 type RateLimitConfig struct {
 	PerUserPerMinute       int  `mapstructure:"per_user_per_minute"`
@@ -118,6 +188,12 @@ type TracingConfig struct {
 	SampleRate   float64 `mapstructure:"sample_rate"`
 }
 
+// MetricsConfig holds metrics settings
+type MetricsConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"` // How often counts are reported to the log
+}
+
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
 	Level           string `mapstructure:"level"`
@@ -202,6 +278,8 @@ func setDefaults(v *viper.Viper) {
 	// Kafka defaults
 	v.SetDefault("kafka.audit_topic", "user-audit-events")
 	v.SetDefault("kafka.event_topic", "user-events")
+	v.SetDefault("kafka.device_topic", "user-device-events")
+	v.SetDefault("kafka.notification_topic", "user-notifications")
 	v.SetDefault("kafka.consumer_group", "user-service")
 	v.SetDefault("kafka.required_acks", -1) // WaitForAll
 	v.SetDefault("kafka.enable_idempotent", true)
@@ -213,6 +291,47 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("encryption.vault_enabled", false)
 	v.SetDefault("encryption.key_check_interval", 1*time.Hour)
 
+	// Account defaults
+	v.SetDefault("account.email_change_token_ttl", 24*time.Hour)
+	v.SetDefault("account.phone_otp_ttl", 5*time.Minute)
+	v.SetDefault("account.phone_otp_resend_cooldown", time.Minute)
+	v.SetDefault("account.phone_otp_max_attempts", 5)
+	v.SetDefault("account.phone_otp_lockout", 30*time.Minute)
+	v.SetDefault("account.restore_grace_period", 30*24*time.Hour)
+	v.SetDefault("account.erasure_retention", 90*24*time.Hour)
+	v.SetDefault("account.data_export_ttl", 24*time.Hour)
+	v.SetDefault("account.data_export_timeout", 5*time.Minute)
+	v.SetDefault("account.address_change_confirmation_ttl", 24*time.Hour)
+	v.SetDefault("account.address_change_velocity_window", 24*time.Hour)
+	v.SetDefault("account.address_change_max_per_window", 1)
+	v.SetDefault("account.consent_confirmation_ttl", 72*time.Hour)
+
+	// Job defaults
+	v.SetDefault("jobs.erasure_interval", time.Hour)
+	v.SetDefault("jobs.erasure_batch_size", 100)
+	v.SetDefault("jobs.address_validation_interval", time.Minute)
+	v.SetDefault("jobs.address_validation_batch_size", 50)
+	v.SetDefault("jobs.address_expiry_interval", 15*time.Minute)
+	v.SetDefault("jobs.address_expiry_batch_size", 100)
+	v.SetDefault("jobs.device_prune_interval", time.Hour)
+	v.SetDefault("jobs.device_prune_batch_size", 100)
+
+	// Address validation defaults
+	v.SetDefault("address_validation.provider_name", "HTTP_PROVIDER")
+	v.SetDefault("address_validation.provider_timeout", 5*time.Second)
+
+	// Audit store defaults
+	v.SetDefault("audit_store.timeout", 10*time.Second)
+
+	// Device defaults
+	v.SetDefault("devices.max_per_user", 10)
+	v.SetDefault("devices.heartbeat_interval", time.Minute)
+	v.SetDefault("devices.risk_threshold", 50)
+	v.SetDefault("devices.rapid_change_window", time.Hour)
+	v.SetDefault("devices.trust_ttl", 30*24*time.Hour)
+	v.SetDefault("devices.step_up_max_age", 5*time.Minute)
+	v.SetDefault("devices.inactive_days", 180)
+
 	// Rate limit defaults
 	v.SetDefault("ratelimit.per_user_per_minute", 100)
 	v.SetDefault("ratelimit.per_ip_per_minute", 50)
@@ -226,6 +345,10 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("tracing.service_name", "user-service")
 	v.SetDefault("tracing.sample_rate", 0.1)
 
+	// Metrics defaults
+	v.SetDefault("metrics.enabled", true)
+	v.SetDefault("metrics.interval", time.Minute)
+
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
@@ -280,6 +403,11 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("per_ip_per_minute rate limit too high (max 1000)")
 	}
 
+	// Erasing inside the restore window would leave restorable accounts without PII
+	if cfg.Account.ErasureRetention < cfg.Account.RestoreGracePeriod {
+		return fmt.Errorf("erasure retention must not be shorter than the restore grace period")
+	}
+
 	// SECURITY: Validate key rotation period (PCI-DSS requires rotation at least annually)
 	if cfg.Encryption.KeyRotationDays > 365 {
 		return fmt.Errorf("key rotation period exceeds 365 days, violates security best practices")
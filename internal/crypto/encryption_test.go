@@ -361,3 +361,46 @@ func TestGenerateKey(t *testing.T) {
 		t.Errorf("generated keys should be valid: %v", err)
 	}
 }
+
+func TestGenerateToken(t *testing.T) {
+	token1, err := GenerateToken(32)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	token2, err := GenerateToken(32)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if token1 == token2 {
+		t.Error("generated tokens should be unique")
+	}
+
+	// 32 bytes in unpadded base64url is 43 characters, safe for URLs
+	if len(token1) != 43 {
+		t.Errorf("expected 43 characters, got %d", len(token1))
+	}
+	if strings.ContainsAny(token1, "+/=") {
+		t.Errorf("token should be URL-safe: %s", token1)
+	}
+}
+
+func TestGenerateNumericCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := GenerateNumericCode(6)
+		if err != nil {
+			t.Fatalf("failed to generate code: %v", err)
+		}
+
+		// Leading zeros must be preserved
+		if len(code) != 6 {
+			t.Fatalf("expected 6 digits, got %q", code)
+		}
+		for _, c := range code {
+			if c < '0' || c > '9' {
+				t.Fatalf("code should be numeric: %q", code)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package customsettings
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("invalid test document: %v", err)
+	}
+	return doc
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	r := Default()
+
+	tests := []struct {
+		name      string
+		namespace string
+		doc       string
+		wantErr   string // Empty for a valid document
+	}{
+		{"valid dashboard", "dashboard", `{"widgets": [{"id": "CARDS", "position": 0, "collapsed": true}], "compact": false}`, ""},
+		{"missing required", "dashboard", `{"compact": true}`, "/widgets: is required"},
+		{"unknown key", "dashboard", `{"widgets": [], "colour": "red"}`, "/colour: is not allowed"},
+		{"value not in enum", "dashboard", `{"widgets": [{"id": "STOCKS", "position": 0}]}`, "/widgets/0/id: must be one of the allowed values"},
+		{"fractional integer", "dashboard", `{"widgets": [{"id": "CARDS", "position": 1.5}]}`, "/widgets/0/position: must be of type integer"},
+		{"above maximum", "dashboard", `{"widgets": [{"id": "CARDS", "position": 12}]}`, "/widgets/0/position: must be at most 11"},
+		{"pattern mismatch", "mobile_app", `{"last_seen_release_notes": "latest"}`, "/last_seen_release_notes: must match pattern"},
+		{"wrong type", "mobile_app", `{"hide_balances": "yes"}`, "/hide_balances: must be of type boolean"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Validate(tt.namespace, decode(t, tt.doc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected valid document, got %v", err)
+				}
+				return
+			}
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			if !strings.HasPrefix(invalid.Reason, tt.wantErr) {
+				t.Errorf("expected reason %q, got %q", tt.wantErr, invalid.Reason)
+			}
+		})
+	}
+}
+
+func TestRegistry_RejectsUnknownAndOversized(t *testing.T) {
+	r := Default()
+
+	if err := r.Validate("ads", map[string]interface{}{}); err != ErrUnknownNamespace {
+		t.Errorf("expected ErrUnknownNamespace, got %v", err)
+	}
+
+	r, err := NewRegistry(Namespace{Name: "notes", Version: 1, MaxBytes: 32, Schema: `{"type": "object"}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc := map[string]interface{}{"text": strings.Repeat("x", 32)}
+	if err := r.Validate("notes", doc); err != ErrTooLarge {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestNewRegistry_RejectsInvalidNamespaces(t *testing.T) {
+	tests := []struct {
+		name string
+		ns   Namespace
+	}{
+		{"bad name", Namespace{Name: "Dashboard", Version: 1, Schema: `{"type": "object"}`}},
+		{"unsupported keyword", Namespace{Name: "a", Version: 1, Schema: `{"type": "object", "oneOf": []}`}},
+		{"not an object", Namespace{Name: "a", Version: 1, Schema: `{"type": "string"}`}},
+		{"missing migration", Namespace{Name: "a", Version: 2, Schema: `{"type": "object"}`}},
+		{"size limit too large", Namespace{Name: "a", Version: 1, MaxBytes: MaxNamespaceBytes + 1, Schema: `{"type": "object"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRegistry(tt.ns); err == nil {
+				t.Error("expected namespace to be rejected")
+			}
+		})
+	}
+}
+
+func TestRegistry_Upgrade(t *testing.T) {
+	r := Default()
+
+	upgraded, err := r.Upgrade("dashboard", 1, decode(t, `{"widget_order": ["PAYMENTS", "ACCOUNTS"], "compact": true}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := decode(t, `{"widgets": [{"id": "PAYMENTS", "position": 0}, {"id": "ACCOUNTS", "position": 1}], "compact": true}`)
+	if !reflect.DeepEqual(upgraded, want) {
+		t.Errorf("expected %v, got %v", want, upgraded)
+	}
+
+	// Documents stored before the namespace was registered start at version 1
+	if _, err := r.Upgrade("dashboard", 0, decode(t, `{"widget_order": []}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// An upgrade that does not produce a valid document is reported
+	var invalid *ValidationError
+	if _, err := r.Upgrade("dashboard", 1, decode(t, `{"widget_order": ["STOCKS"]}`)); !errors.As(err, &invalid) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
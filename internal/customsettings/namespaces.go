@@ -0,0 +1,93 @@
+package customsettings
+
+import "fmt"
+
+// Namespaces client teams may store custom settings in
+// To change a schema in a way existing documents may not match, bump Version and add a
+// migration from the previous version
+var builtinNamespaces = []Namespace{
+	{
+		Name:    "dashboard",
+		Version: 2,
+		Schema: `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Web dashboard layout",
+			"type": "object",
+			"additionalProperties": false,
+			"required": ["widgets"],
+			"properties": {
+				"widgets": {
+					"type": "array",
+					"maxItems": 12,
+					"items": {
+						"type": "object",
+						"additionalProperties": false,
+						"required": ["id", "position"],
+						"properties": {
+							"id": {"type": "string", "enum": ["ACCOUNTS", "CARDS", "PAYMENTS", "SAVINGS_GOALS", "SPENDING", "OFFERS"]},
+							"position": {"type": "integer", "minimum": 0, "maximum": 11},
+							"collapsed": {"type": "boolean"}
+						}
+					}
+				},
+				"compact": {"type": "boolean"}
+			}
+		}`,
+		Migrations: map[int]Migration{
+			1: migrateDashboardWidgetOrder,
+		},
+	},
+	{
+		Name:    "mobile_app",
+		Version: 1,
+		Schema: `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "Mobile app settings",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"home_tab": {"type": "string", "enum": ["ACCOUNTS", "CARDS", "PAYMENTS"]},
+				"biometric_prompt": {"type": "boolean"},
+				"hide_balances": {"type": "boolean"},
+				"last_seen_release_notes": {"type": "string", "maxLength": 32, "pattern": "^[0-9]+\\.[0-9]+\\.[0-9]+$"}
+			}
+		}`,
+	},
+}
+
+// migrateDashboardWidgetOrder turns the version 1 list of widget IDs into positioned widgets
+func migrateDashboardWidgetOrder(doc map[string]interface{}) (map[string]interface{}, error) {
+	upgraded := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		if key != "widget_order" {
+			upgraded[key] = value
+		}
+	}
+
+	order, _ := doc["widget_order"].([]interface{})
+	widgets := make([]interface{}, 0, len(order))
+	for i, id := range order {
+		if _, ok := id.(string); !ok {
+			return nil, fmt.Errorf("widget_order[%d] is not a widget ID", i)
+		}
+		widgets = append(widgets, map[string]interface{}{"id": id, "position": float64(i)})
+	}
+	upgraded["widgets"] = widgets
+
+	return upgraded, nil
+}
+
+var defaultRegistry = mustNewRegistry(builtinNamespaces...)
+
+// Default returns the registry of the built-in namespaces
+func Default() *Registry {
+	return defaultRegistry
+}
+
+func mustNewRegistry(namespaces ...Namespace) *Registry {
+	r, err := NewRegistry(namespaces...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
@@ -0,0 +1,174 @@
+package customsettings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Size limits for a namespace's encoded JSON document
+const (
+	DefaultMaxBytes   = 4 * 1024
+	MaxNamespaceBytes = 16 * 1024
+)
+
+// Registry errors
+var (
+	ErrUnknownNamespace = errors.New("custom settings namespace is not registered")
+	ErrTooLarge         = errors.New("custom settings document is too large")
+)
+
+// ValidationError reports a document that does not match its namespace's schema
+type ValidationError struct {
+	Namespace string
+	Reason    string // JSON Pointer to the offending value and what is wrong with it
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("custom settings %q: %s", e.Namespace, e.Reason)
+}
+
+// Migration upgrades a document from one schema version of its namespace to the next
+type Migration func(doc map[string]interface{}) (map[string]interface{}, error)
+
+// Namespace registers a custom settings namespace
+// Every change to Schema that existing documents may not match needs a new Version and a
+// Migration from the previous one, so stored documents can always be upgraded
+type Namespace struct {
+	Name       string
+	Version    int               // Current schema version, starting at 1
+	Schema     string            // JSON Schema documents must match at Version; must describe an object
+	MaxBytes   int               // Limit on the encoded document; DefaultMaxBytes if zero
+	Migrations map[int]Migration // Keyed by the version each migration upgrades from
+}
+
+var namespaceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+type registeredNamespace struct {
+	Namespace
+	schema *Schema
+}
+
+// Registry holds the namespaces clients may store custom settings in
+type Registry struct {
+	namespaces map[string]*registeredNamespace
+}
+
+// NewRegistry compiles and checks the given namespaces
+func NewRegistry(namespaces ...Namespace) (*Registry, error) {
+	r := &Registry{namespaces: make(map[string]*registeredNamespace, len(namespaces))}
+
+	for _, ns := range namespaces {
+		if !namespaceNamePattern.MatchString(ns.Name) {
+			return nil, fmt.Errorf("namespace %q: name must be lower snake case, at most 32 characters", ns.Name)
+		}
+		if _, exists := r.namespaces[ns.Name]; exists {
+			return nil, fmt.Errorf("namespace %q: registered twice", ns.Name)
+		}
+		if ns.Version < 1 {
+			return nil, fmt.Errorf("namespace %q: version must be at least 1", ns.Name)
+		}
+		for from := 1; from < ns.Version; from++ {
+			if ns.Migrations[from] == nil {
+				return nil, fmt.Errorf("namespace %q: no migration from version %d", ns.Name, from)
+			}
+		}
+
+		if ns.MaxBytes == 0 {
+			ns.MaxBytes = DefaultMaxBytes
+		}
+		if ns.MaxBytes < 0 || ns.MaxBytes > MaxNamespaceBytes {
+			return nil, fmt.Errorf("namespace %q: size limit must be between 1 and %d bytes", ns.Name, MaxNamespaceBytes)
+		}
+
+		schema, err := CompileSchema(ns.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %q: %w", ns.Name, err)
+		}
+		if schema.typ != "object" {
+			return nil, fmt.Errorf("namespace %q: schema must describe an object", ns.Name)
+		}
+
+		r.namespaces[ns.Name] = &registeredNamespace{Namespace: ns, schema: schema}
+	}
+
+	return r, nil
+}
+
+// Names returns the registered namespace names in order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.namespaces))
+	for name := range r.namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Version returns the current schema version of a namespace
+func (r *Registry) Version(name string) (int, error) {
+	ns, ok := r.namespaces[name]
+	if !ok {
+		return 0, ErrUnknownNamespace
+	}
+	return ns.Version, nil
+}
+
+// Validate checks a document decoded from JSON against the namespace's size limit and
+// current schema
+func (r *Registry) Validate(name string, doc interface{}) error {
+	ns, ok := r.namespaces[name]
+	if !ok {
+		return ErrUnknownNamespace
+	}
+
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return &ValidationError{Namespace: name, Reason: "/: must be a JSON document"}
+	}
+	if len(encoded) > ns.MaxBytes {
+		return ErrTooLarge
+	}
+
+	if err := ns.schema.Validate(doc); err != nil {
+		return &ValidationError{Namespace: name, Reason: err.Error()}
+	}
+	return nil
+}
+
+// Upgrade migrates a stored document from its schema version to the namespace's current one
+// Version 0 is a document stored before its namespace was registered and is treated as
+// version 1. The result is validated, so a document that cannot be upgraded is reported
+// rather than returned in a shape clients do not expect
+func (r *Registry) Upgrade(name string, version int, doc interface{}) (map[string]interface{}, error) {
+	ns, ok := r.namespaces[name]
+	if !ok {
+		return nil, ErrUnknownNamespace
+	}
+	if version < 1 {
+		version = 1
+	}
+	if version > ns.Version {
+		return nil, fmt.Errorf("namespace %q: stored version %d is newer than %d", name, version, ns.Version)
+	}
+
+	current, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, &ValidationError{Namespace: name, Reason: "/: must be of type object"}
+	}
+
+	for from := version; from < ns.Version; from++ {
+		upgraded, err := ns.Migrations[from](current)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %q: migration from version %d: %w", name, from, err)
+		}
+		current = upgraded
+	}
+
+	if err := r.Validate(name, current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
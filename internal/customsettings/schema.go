@@ -0,0 +1,267 @@
+package customsettings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// schemaDoc is the JSON form of the supported JSON Schema subset
+// Decoding rejects any other keyword so nothing written in a schema goes silently unenforced
+type schemaDoc struct {
+	Schema               string                `json:"$schema"`
+	Title                string                `json:"title"`
+	Description          string                `json:"description"`
+	Type                 string                `json:"type"`
+	Properties           map[string]*schemaDoc `json:"properties"`
+	Required             []string              `json:"required"`
+	AdditionalProperties *bool                 `json:"additionalProperties"`
+	Enum                 []interface{}         `json:"enum"`
+	Minimum              *float64              `json:"minimum"`
+	Maximum              *float64              `json:"maximum"`
+	MinLength            *int                  `json:"minLength"`
+	MaxLength            *int                  `json:"maxLength"`
+	Pattern              string                `json:"pattern"`
+	Items                *schemaDoc            `json:"items"`
+	MinItems             *int                  `json:"minItems"`
+	MaxItems             *int                  `json:"maxItems"`
+}
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	typ                  string
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties bool
+	enum                 []interface{}
+	minimum              *float64
+	maximum              *float64
+	minLength            *int
+	maxLength            *int
+	pattern              *regexp.Regexp
+	items                *Schema
+	minItems             *int
+	maxItems             *int
+}
+
+// CompileSchema parses a JSON Schema
+// Supported keywords are type, properties, required, additionalProperties, enum, minimum,
+// maximum, minLength, maxLength, pattern, items, minItems and maxItems; $schema, title and
+// description are accepted as annotations
+func CompileSchema(raw string) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.DisallowUnknownFields()
+
+	var doc schemaDoc
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compile(&doc, "")
+}
+
+func compile(doc *schemaDoc, path string) (*Schema, error) {
+	switch doc.Type {
+	case "object", "array", "string", "integer", "number", "boolean", "null":
+	default:
+		return nil, fmt.Errorf("schema %s: unsupported type %q", pathOrRoot(path), doc.Type)
+	}
+
+	s := &Schema{
+		typ:                  doc.Type,
+		required:             doc.Required,
+		additionalProperties: doc.AdditionalProperties == nil || *doc.AdditionalProperties,
+		enum:                 doc.Enum,
+		minimum:              doc.Minimum,
+		maximum:              doc.Maximum,
+		minLength:            doc.MinLength,
+		maxLength:            doc.MaxLength,
+		minItems:             doc.MinItems,
+		maxItems:             doc.MaxItems,
+	}
+
+	if doc.Pattern != "" {
+		re, err := regexp.Compile(doc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: invalid pattern: %w", pathOrRoot(path), err)
+		}
+		s.pattern = re
+	}
+
+	if len(doc.Properties) > 0 {
+		s.properties = make(map[string]*Schema, len(doc.Properties))
+		for name, prop := range doc.Properties {
+			compiled, err := compile(prop, path+"/"+name)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = compiled
+		}
+	}
+
+	if doc.Items != nil {
+		items, err := compile(doc.Items, path+"/items")
+		if err != nil {
+			return nil, err
+		}
+		s.items = items
+	}
+
+	return s, nil
+}
+
+// Validate checks a value decoded from JSON against the schema
+// The returned error describes the first violation found
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate(value, "")
+}
+
+func (s *Schema) validate(value interface{}, path string) error {
+	if !s.hasType(value) {
+		return violation(path, "must be of type %s", s.typ)
+	}
+
+	if len(s.enum) > 0 && !s.inEnum(value) {
+		return violation(path, "must be one of the allowed values")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.validateObject(v, path)
+	case []interface{}:
+		return s.validateArray(v, path)
+	case string:
+		return s.validateString(v, path)
+	case float64:
+		return s.validateNumber(v, path)
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, path string) error {
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			return violation(path+"/"+name, "is required")
+		}
+	}
+
+	// Sorted so the reported violation does not depend on map order
+	for _, name := range sortedKeys(obj) {
+		prop, ok := s.properties[name]
+		if !ok {
+			if !s.additionalProperties {
+				return violation(path+"/"+name, "is not allowed")
+			}
+			continue
+		}
+		if err := prop.validate(obj[name], path+"/"+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(arr []interface{}, path string) error {
+	if s.minItems != nil && len(arr) < *s.minItems {
+		return violation(path, "must have at least %d items", *s.minItems)
+	}
+	if s.maxItems != nil && len(arr) > *s.maxItems {
+		return violation(path, "must have at most %d items", *s.maxItems)
+	}
+	if s.items != nil {
+		for i, item := range arr {
+			if err := s.items.validate(item, path+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateString(str, path string) error {
+	// Lengths count characters, not bytes
+	length := utf8.RuneCountInString(str)
+	if s.minLength != nil && length < *s.minLength {
+		return violation(path, "must be at least %d characters", *s.minLength)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		return violation(path, "must be at most %d characters", *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return violation(path, "must match pattern %s", s.pattern.String())
+	}
+	return nil
+}
+
+func (s *Schema) validateNumber(n float64, path string) error {
+	if s.minimum != nil && n < *s.minimum {
+		return violation(path, "must be at least %v", *s.minimum)
+	}
+	if s.maximum != nil && n > *s.maximum {
+		return violation(path, "must be at most %v", *s.maximum)
+	}
+	return nil
+}
+
+func (s *Schema) hasType(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.typ == "object"
+	case []interface{}:
+		return s.typ == "array"
+	case string:
+		return s.typ == "string"
+	case bool:
+		return s.typ == "boolean"
+	case nil:
+		return s.typ == "null"
+	case float64:
+		return s.typ == "number" || (s.typ == "integer" && v == math.Trunc(v))
+	default:
+		return false
+	}
+}
+
+func (s *Schema) inEnum(value interface{}) bool {
+	for _, allowed := range s.enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaViolation is a single reason a document does not match its schema
+type schemaViolation struct {
+	path   string // JSON Pointer to the offending value
+	reason string
+}
+
+func violation(path, format string, args ...interface{}) *schemaViolation {
+	return &schemaViolation{path: pathOrRoot(path), reason: fmt.Sprintf(format, args...)}
+}
+
+func (v *schemaViolation) Error() string {
+	return v.path + ": " + v.reason
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -43,8 +43,22 @@ type Address struct {
 	
 	// Encrypted compound field
 	AddressEncrypted     string           `json:"-" db:"address_encrypted"`
+
+	// Set by the service: the input as typed, kept in history, and the rendered mailing label
+	RawInput             *AddressData     `json:"-" db:"-"`
+	MailingLabel         []string         `json:"mailing_label,omitempty" db:"-"`
 	
 	IsPrimary            bool             `json:"is_primary" db:"is_primary"`
+
+	// Validity window, set only for TEMPORARY addresses
+	ValidFrom            *time.Time       `json:"valid_from,omitempty" db:"valid_from"`
+	ValidUntil           *time.Time       `json:"valid_until,omitempty" db:"valid_until"`
+	ReplacesAddressID    *uuid.UUID       `json:"-" db:"replaces_address_id"` // Primary displaced by this one
+
+	// Set while a risky edit awaits out-of-band confirmation; the fields above are unchanged until then
+	ChangeStatus         string           `json:"change_status,omitempty" db:"-"`
+	PendingChange        *AddressHold     `json:"pending_change,omitempty" db:"-"`
+
 	ValidationStatus     ValidationStatus `json:"validation_status" db:"validation_status"`
 	ValidationSource     string           `json:"validation_source,omitempty" db:"validation_source"`
 	ValidatedAt          *time.Time       `json:"validated_at,omitempty" db:"validated_at"`
@@ -92,21 +106,105 @@ func (a *Address) IsDeleted() bool {
 	return a.DeletedAt != nil
 }
 
+// IsTemporary returns true if the address only applies for its validity window
+func (a *Address) IsTemporary() bool {
+	return a.AddressType == AddressTypeTemporary
+}
+
 // IsValidated returns true if address has been validated
 func (a *Address) IsValidated() bool {
 	return a.ValidationStatus == ValidationStatusValid
 }
 
+// Address change types recorded in address_history
+const (
+	AddressChangeCreate = "CREATE"
+	AddressChangeUpdate = "UPDATE"
+	AddressChangeDelete = "DELETE"
+)
+
+// Address change sources recorded in address_history
+const (
+	AddressChangeSourceUser   = "USER"
+	AddressChangeSourceAdmin  = "ADMIN"
+	AddressChangeSourceSystem = "SYSTEM"
+	AddressChangeSourceAPI    = "API"
+)
+
+// AddressChangePendingConfirmation marks an address whose latest edit is held for confirmation
+const AddressChangePendingConfirmation = "PENDING_CONFIRMATION"
+
+// Reasons an address edit is held for confirmation
+const (
+	AddressHoldUntrustedDevice = "UNTRUSTED_DEVICE"
+	AddressHoldCountryChange   = "COUNTRY_CHANGE"
+	AddressHoldChangeVelocity  = "CHANGE_VELOCITY"
+)
+
+// AddressHold describes an edit awaiting confirmation
+// The proposed address is only disclosed through the confirmation channel
+type AddressHold struct {
+	Reasons     []string  `json:"reasons"`
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// PendingAddressChange is the held edit, stored encrypted on the address until confirmed
+type PendingAddressChange struct {
+	Address       *AddressData `json:"address"`
+	RawInput      *AddressData `json:"raw_input,omitempty"`
+	AddressType   AddressType  `json:"address_type"`
+	IsPrimary     bool         `json:"is_primary"`
+	ValidFrom     *time.Time   `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time   `json:"valid_until,omitempty"`
+	ChangedFields []string     `json:"changed_fields"`
+}
+
+// NewPendingAddressChange captures the edited state of addr for later confirmation
+func NewPendingAddressChange(addr *Address, changedFields []string) *PendingAddressChange {
+	return &PendingAddressChange{
+		Address:       addr.ToAddressData(),
+		RawInput:      addr.RawInput,
+		AddressType:   addr.AddressType,
+		IsPrimary:     addr.IsPrimary,
+		ValidFrom:     addr.ValidFrom,
+		ValidUntil:    addr.ValidUntil,
+		ChangedFields: changedFields,
+	}
+}
+
+// ApplyPendingChange overlays a confirmed edit onto the address
+// Validation starts over only if the address content changed; a held primary or type change
+// keeps the old verdict
+func (a *Address) ApplyPendingChange(p *PendingAddressChange) {
+	if *p.Address != *a.ToAddressData() {
+		a.ValidationStatus = ValidationStatusPending
+		a.ValidationSource = ""
+		a.ValidatedAt = nil
+	}
+	a.FromAddressData(p.Address)
+	a.RawInput = p.RawInput
+	a.AddressType = p.AddressType
+	a.IsPrimary = p.IsPrimary
+	a.ValidFrom = p.ValidFrom
+	a.ValidUntil = p.ValidUntil
+	a.ChangeStatus = ""
+	a.PendingChange = nil
+}
+
 // AddressHistory represents a historical version of an address
 type AddressHistory struct {
-	ID               uuid.UUID   `json:"id" db:"id"`
-	AddressID        uuid.UUID   `json:"address_id" db:"address_id"`
-	UserID           uuid.UUID   `json:"user_id" db:"user_id"`
-	AddressEncrypted string      `json:"-" db:"address_encrypted"`
-	Version          int         `json:"version" db:"version"`
-	ChangedBy        string      `json:"changed_by" db:"changed_by"`
-	ChangeSource     string      `json:"change_source" db:"change_source"` // USER, ADMIN, SYSTEM
-	CreatedAt        time.Time   `json:"created_at" db:"created_at"`
+	ID               uuid.UUID    `json:"id" db:"id"`
+	AddressID        uuid.UUID    `json:"address_id" db:"address_id"`
+	UserID           uuid.UUID    `json:"user_id" db:"user_id"`
+	AddressEncrypted string       `json:"-" db:"address_encrypted"`
+	Address          *AddressData `json:"address,omitempty" db:"-"`   // Decrypted snapshot; nil once erased
+	RawInput         *AddressData `json:"raw_input,omitempty" db:"-"` // As typed, before normalization
+	Version          int          `json:"version" db:"version"`
+	ChangedBy        string       `json:"changed_by" db:"changed_by"`
+	ChangeSource     string       `json:"change_source" db:"change_source"` // USER, ADMIN, SYSTEM
+	ChangeType       string       `json:"change_type" db:"change_type"`     // CREATE, UPDATE, DELETE
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
 }
 
 // CreateAddressRequest represents a request to create an address
@@ -119,6 +217,8 @@ type CreateAddressRequest struct {
 	PostalCode  string      `json:"postal_code" validate:"required,min=1,max=20"`
 	Country     string      `json:"country" validate:"required,iso3166_1_alpha2"`
 	IsPrimary   bool        `json:"is_primary"`
+	ValidFrom   *time.Time  `json:"valid_from,omitempty"`  // TEMPORARY only
+	ValidUntil  *time.Time  `json:"valid_until,omitempty"` // Required for TEMPORARY
 }
 
 // UpdateAddressRequest represents a request to update an address
@@ -131,6 +231,13 @@ type UpdateAddressRequest struct {
 	PostalCode  *string      `json:"postal_code,omitempty" validate:"omitempty,min=1,max=20"`
 	Country     *string      `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
 	IsPrimary   *bool        `json:"is_primary,omitempty"`
+	ValidFrom   *time.Time   `json:"valid_from,omitempty"`
+	ValidUntil  *time.Time   `json:"valid_until,omitempty"`
+}
+
+// ConfirmAddressChangeRequest releases a held address edit with the token sent out of band
+type ConfirmAddressChangeRequest struct {
+	Token string `json:"token" validate:"required,min=32,max=128"`
 }
 
 // AddressSummary is a minimal address representation
@@ -20,6 +20,7 @@ const (
 	ActionUpdate Action = "UPDATE"
 	ActionDelete Action = "DELETE"
 	ActionAccess Action = "ACCESS" // For PII access by internal services
+	ActionErased Action = "ERASED" // PII irreversibly wiped (GDPR erasure)
 )
 
 // ActorType represents who performed the action
@@ -41,6 +42,8 @@ const (
 	ResourceDevice      Resource = "device"
 	ResourcePreference  Resource = "preference"
 	ResourceKYCStatus   Resource = "kyc_status"
+	ResourceFeatureFlag Resource = "feature_flag"
+	ResourceConsent     Resource = "consent"
 )
 
 // AuditEvent represents an immutable audit event with HMAC signature
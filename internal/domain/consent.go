@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConsentPurpose is what a user consents to
+type ConsentPurpose string
+
+const (
+	ConsentMarketingEmail ConsentPurpose = "MARKETING_EMAIL"
+	ConsentMarketingSMS   ConsentPurpose = "MARKETING_SMS"
+	ConsentProfiling      ConsentPurpose = "PROFILING"
+	ConsentDataSharing    ConsentPurpose = "DATA_SHARING"
+)
+
+// ConsentPurposes lists every purpose consent is recorded for
+var ConsentPurposes = []ConsentPurpose{
+	ConsentMarketingEmail,
+	ConsentMarketingSMS,
+	ConsentProfiling,
+	ConsentDataSharing,
+}
+
+// ConsentAction is what a consent event records
+type ConsentAction string
+
+const (
+	ConsentActionRequested ConsentAction = "REQUESTED" // Double opt-in started; not yet consent
+	ConsentActionGranted   ConsentAction = "GRANTED"
+	ConsentActionWithdrawn ConsentAction = "WITHDRAWN"
+)
+
+// ConsentChannel is how consent was given or withdrawn
+type ConsentChannel string
+
+const (
+	ConsentChannelWeb     ConsentChannel = "WEB"
+	ConsentChannelMobile  ConsentChannel = "MOBILE"
+	ConsentChannelEmail   ConsentChannel = "EMAIL" // Double opt-in confirmation link
+	ConsentChannelSupport ConsentChannel = "SUPPORT"
+)
+
+// ConsentStatus is the consent in effect for a purpose
+type ConsentStatus string
+
+const (
+	ConsentStatusGranted    ConsentStatus = "GRANTED"
+	ConsentStatusPending    ConsentStatus = "PENDING" // Waiting for double opt-in confirmation
+	ConsentStatusNotGranted ConsentStatus = "NOT_GRANTED"
+)
+
+// ConsentEvent is one immutable entry in the consent ledger
+type ConsentEvent struct {
+	ID              uuid.UUID      `json:"id"`
+	UserID          uuid.UUID      `json:"user_id"`
+	Purpose         ConsentPurpose `json:"purpose"`
+	Action          ConsentAction  `json:"action"`
+	WordingVersion  string         `json:"wording_version"` // Version of the consent text shown to the user
+	Channel         ConsentChannel `json:"channel"`
+	ActorID         string         `json:"actor_id"`
+	IPHash          string         `json:"-"`
+	RequestID       string         `json:"request_id,omitempty"`
+	ConfirmsEventID *uuid.UUID     `json:"confirms_event_id,omitempty"` // The REQUESTED event a double opt-in grant confirms
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// ConsentRecord is the current consent for one purpose, derived from the latest ledger entry
+type ConsentRecord struct {
+	Purpose          ConsentPurpose `json:"purpose"`
+	Status           ConsentStatus  `json:"status"`
+	WordingVersion   string         `json:"wording_version,omitempty"`
+	Channel          ConsentChannel `json:"channel,omitempty"`
+	UpdatedAt        *time.Time     `json:"updated_at,omitempty"`
+	PendingExpiresAt *time.Time     `json:"pending_expires_at,omitempty"`
+}
+
+// GrantConsentRequest gives consent for a purpose
+type GrantConsentRequest struct {
+	Purpose        ConsentPurpose `json:"purpose" validate:"required,oneof=MARKETING_EMAIL MARKETING_SMS PROFILING DATA_SHARING"`
+	WordingVersion string         `json:"wording_version" validate:"required,max=64"`
+	Channel        ConsentChannel `json:"channel" validate:"required,oneof=WEB MOBILE"`
+}
+
+// WithdrawConsentRequest withdraws consent for a purpose, including a pending double opt-in
+type WithdrawConsentRequest struct {
+	Purpose ConsentPurpose `json:"purpose" validate:"required,oneof=MARKETING_EMAIL MARKETING_SMS PROFILING DATA_SHARING"`
+	Channel ConsentChannel `json:"channel" validate:"required,oneof=WEB MOBILE"`
+}
+
+// ConfirmConsentRequest completes a double opt-in with the token from the confirmation email
+type ConfirmConsentRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RequiresDoubleOptIn reports whether consent for the purpose is only given once confirmed by email
+func RequiresDoubleOptIn(purpose ConsentPurpose) bool {
+	return purpose == ConsentMarketingEmail
+}
+
+// NewConsentRecord derives the consent in effect from the purpose's latest ledger entry
+// latest is nil if nothing was ever recorded; pendingExpiresAt is set while a double
+// opt-in link is outstanding, and a request whose link lapsed grants nothing
+func NewConsentRecord(purpose ConsentPurpose, latest *ConsentEvent, pendingExpiresAt *time.Time) *ConsentRecord {
+	record := &ConsentRecord{Purpose: purpose, Status: ConsentStatusNotGranted}
+	if latest == nil {
+		return record
+	}
+
+	record.WordingVersion = latest.WordingVersion
+	record.Channel = latest.Channel
+	record.UpdatedAt = &latest.CreatedAt
+
+	switch latest.Action {
+	case ConsentActionGranted:
+		record.Status = ConsentStatusGranted
+	case ConsentActionRequested:
+		if pendingExpiresAt != nil {
+			record.Status = ConsentStatusPending
+			record.PendingExpiresAt = pendingExpiresAt
+		}
+	}
+	return record
+}
+
+// consentNotifications maps notification types that may only be sent with consent to that consent
+var consentNotifications = map[NotificationType]ConsentPurpose{
+	NotificationMarketingEmail: ConsentMarketingEmail,
+}
+
+// NotificationConsent returns the consent a notification type requires, if any
+// Such types are enabled exactly when the consent is granted
+func NotificationConsent(t NotificationType) (ConsentPurpose, bool) {
+	purpose, ok := consentNotifications[t]
+	return purpose, ok
+}
+
+// ApplyConsent enables consent-governed notification types exactly when their consent
+// is granted; granted holds the purposes with consent in effect
+func (p *Preference) ApplyConsent(granted map[ConsentPurpose]bool) {
+	if p.NotificationSettings == nil {
+		p.NotificationSettings = make(map[NotificationType]NotificationSetting)
+	}
+	for notificationType, purpose := range consentNotifications {
+		setting, ok := p.NotificationSettings[notificationType]
+		if !ok {
+			setting = NotificationSetting{Channels: []NotificationChannel{ChannelEmail}}
+		}
+		setting.Enabled = granted[purpose]
+		p.NotificationSettings[notificationType] = setting
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain/audit"
+)
+
+// DataExportStatus is the state of an asynchronous data subject access export
+type DataExportStatus string
+
+const (
+	DataExportStatusPending   DataExportStatus = "PENDING"
+	DataExportStatusRunning   DataExportStatus = "RUNNING"
+	DataExportStatusCompleted DataExportStatus = "COMPLETED"
+	DataExportStatusFailed    DataExportStatus = "FAILED"
+)
+
+// DataExportFormatVersion is bumped whenever the bundle layout changes
+const DataExportFormatVersion = 1
+
+// Sections of a data export bundle
+const (
+	DataExportSectionProfile        = "profile"
+	DataExportSectionAddresses      = "addresses"
+	DataExportSectionAddressHistory = "address_history"
+	DataExportSectionDevices        = "devices"
+	DataExportSectionPreferences    = "preferences"
+	DataExportSectionKYC            = "kyc"
+	DataExportSectionAuditEvents    = "audit_events"
+	DataExportSectionConsents       = "consents"
+)
+
+// DataExportJob tracks a data subject access export
+type DataExportJob struct {
+	ID                 uuid.UUID        `json:"export_id"`
+	UserID             uuid.UUID        `json:"user_id"`
+	Status             DataExportStatus `json:"status"`
+	RequestedAt        time.Time        `json:"requested_at"`
+	CompletedAt        *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt          *time.Time       `json:"expires_at,omitempty"` // When the bundle is discarded
+	IncompleteSections []string         `json:"incomplete_sections,omitempty"`
+}
+
+// IsFinished returns true once the export has completed or failed
+func (j *DataExportJob) IsFinished() bool {
+	return j.Status == DataExportStatusCompleted || j.Status == DataExportStatusFailed
+}
+
+// DataExportBundle is the machine-readable copy of everything held about a user
+type DataExportBundle struct {
+	FormatVersion      int                `json:"format_version"`
+	ExportID           uuid.UUID          `json:"export_id"`
+	UserID             uuid.UUID          `json:"user_id"`
+	GeneratedAt        time.Time          `json:"generated_at"`
+	Profile            *DataExportProfile `json:"profile"`
+	Addresses          []*Address         `json:"addresses"`
+	AddressHistory     []*AddressHistory  `json:"address_history"`
+	Devices            []*Device          `json:"devices"` // Fingerprint and IP hashes are never serialized
+	Preferences        *Preference        `json:"preferences,omitempty"`
+	KYC                *DataExportKYC     `json:"kyc"`
+	Consents           []*ConsentEvent    `json:"consents"` // The full consent ledger, as evidence of what was agreed
+	AuditEvents        []*DataExportAudit `json:"audit_events"`
+	IncompleteSections []string           `json:"incomplete_sections,omitempty"` // Sections whose source was unavailable
+}
+
+// DataExportProfile is the decrypted profile included in an export
+// Internal risk flags are withheld: disclosing fraud markers would prejudice their purpose
+type DataExportProfile struct {
+	LegalName       string       `json:"legal_name,omitempty"`
+	Email           string       `json:"email,omitempty"`
+	Phone           string       `json:"phone,omitempty"`
+	PhoneVerifiedAt *time.Time   `json:"phone_verified_at,omitempty"`
+	DOB             *time.Time   `json:"date_of_birth,omitempty"`
+	Country         string       `json:"country"`
+	Status          UserStatus   `json:"status"`
+	StatusReason    StatusReason `json:"status_reason,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+	DeletedAt       *time.Time   `json:"deleted_at,omitempty"`
+}
+
+// DataExportKYC is the KYC pointer held for the user; documents live in the KYC service
+type DataExportKYC struct {
+	Status      KYCStatus  `json:"status"`
+	ReferenceID *uuid.UUID `json:"reference_id,omitempty"`
+}
+
+// DataExportAudit is an audit event as disclosed to the data subject
+// Staff identities, IP hashes and signatures are omitted
+type DataExportAudit struct {
+	Timestamp     time.Time       `json:"timestamp"`
+	ActorType     audit.ActorType `json:"actor_type"`
+	Action        audit.Action    `json:"action"`
+	Resource      audit.Resource  `json:"resource"`
+	ResourceID    string          `json:"resource_id,omitempty"`
+	FieldsChanged []string        `json:"fields_changed,omitempty"`
+	Result        string          `json:"result"`
+}
+
+// NewDataExportProfile builds the export view of a user
+func NewDataExportProfile(u *User) *DataExportProfile {
+	return &DataExportProfile{
+		LegalName:       u.LegalName,
+		Email:           u.Email,
+		Phone:           u.Phone,
+		PhoneVerifiedAt: u.PhoneVerifiedAt,
+		DOB:             u.DOB,
+		Country:         u.Country,
+		Status:          u.Status,
+		StatusReason:    u.StatusReason,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+		DeletedAt:       u.DeletedAt,
+	}
+}
+
+// NewDataExportAudit builds the export view of an audit event
+func NewDataExportAudit(e *audit.AuditEvent) *DataExportAudit {
+	return &DataExportAudit{
+		Timestamp:     e.Timestamp,
+		ActorType:     e.ActorType,
+		Action:        e.Action,
+		Resource:      e.Resource,
+		ResourceID:    e.ResourceID,
+		FieldsChanged: e.FieldsChanged,
+		Result:        e.Result,
+	}
+}
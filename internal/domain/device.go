@@ -33,20 +33,23 @@ const (
 // Device represents a registered device for a user
 // Device fingerprint and IP are stored as hashes for privacy
 type Device struct {
-	ID              uuid.UUID  `json:"id" db:"id"`
-	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
-	FingerprintHash string     `json:"-" db:"fingerprint_hash"` // SHA-256 hash, never raw
-	DeviceType      DeviceType `json:"device_type" db:"device_type"`
-	OS              DeviceOS   `json:"os" db:"os"`
-	OSVersion       string     `json:"os_version,omitempty" db:"os_version"`
-	AppVersion      string     `json:"app_version,omitempty" db:"app_version"`
-	DeviceName      string     `json:"device_name,omitempty" db:"device_name"` // User-facing name like "John's iPhone"
-	LastIPHash      string     `json:"-" db:"last_ip_hash"`                    // Hashed for privacy
-	LastActiveAt    *time.Time `json:"last_active_at,omitempty" db:"last_active_at"`
-	IsTrusted       bool       `json:"is_trusted" db:"is_trusted"`
-	TrustReason     string     `json:"trust_reason,omitempty" db:"trust_reason"`
-	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
-	DeletedAt       *time.Time `json:"-" db:"deleted_at"`
+	ID              uuid.UUID        `json:"id" db:"id"`
+	UserID          uuid.UUID        `json:"user_id" db:"user_id"`
+	FingerprintHash string           `json:"-" db:"fingerprint_hash"` // SHA-256 hash, never raw
+	DeviceType      DeviceType       `json:"device_type" db:"device_type"`
+	OS              DeviceOS         `json:"os" db:"os"`
+	OSVersion       string           `json:"os_version,omitempty" db:"os_version"`
+	AppVersion      string           `json:"app_version,omitempty" db:"app_version"`
+	DeviceName      string           `json:"device_name,omitempty" db:"device_name"` // User-facing name like "John's iPhone"
+	LastIPHash      string           `json:"-" db:"last_ip_hash"`                    // Hashed for privacy
+	LastActiveAt    *time.Time       `json:"last_active_at,omitempty" db:"last_active_at"`
+	IsTrusted       bool             `json:"is_trusted" db:"is_trusted"`
+	TrustReason     string           `json:"trust_reason,omitempty" db:"trust_reason"` // Why trust was last granted or revoked
+	TrustedUntil    *time.Time       `json:"trusted_until,omitempty" db:"trusted_until"`
+	Suspicion       *DeviceSuspicion `json:"-" db:"-"` // Latest risk assessment; nil until first scored
+	ScoredAt        *time.Time       `json:"-" db:"scored_at"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+	DeletedAt       *time.Time       `json:"-" db:"deleted_at"`
 }
 
 // IsActive returns true if device is not deleted
@@ -63,6 +66,25 @@ func (d *Device) WasActiveRecently(days int) bool {
 	return d.LastActiveAt.After(threshold)
 }
 
+// Device trust reasons
+const (
+	TrustReasonStepUpAuth         = "STEP_UP_AUTH" // Fresh strong authentication in the access token
+	TrustReasonStepUpOTP          = "STEP_UP_OTP"  // Code sent to the verified phone
+	TrustReasonUserRevoked        = "USER_REVOKED"
+	TrustReasonPasswordChanged    = "PASSWORD_CHANGED"
+	TrustReasonCompromiseReported = "COMPROMISE_REPORTED"
+	TrustReasonExpired            = "EXPIRED"
+	TrustReasonInactive           = "INACTIVE" // Device pruned after a long inactivity
+)
+
+// Device removal reasons
+const (
+	DeviceRemovedByUser  = "USER_REMOVED"
+	DeviceRemovedEvicted = "EVICTED" // Made room for a new device under the per-user cap
+	DeviceRemovedRevoked = "REVOKED" // Removed by revoking all other devices
+	DeviceRemovedPruned  = "PRUNED"  // Inactive beyond the retention threshold
+)
+
 // DeviceSuspicion indicates fraud-related signals
 type DeviceSuspicion struct {
 	IsNewDevice       bool   `json:"is_new_device"`        // First time seeing this device
@@ -73,17 +95,84 @@ type DeviceSuspicion struct {
 	Reasons           []string `json:"reasons,omitempty"`
 }
 
+// Device suspicion reasons, one per signal in DeviceSuspicion
+const (
+	SuspicionNewDevice           = "NEW_DEVICE"
+	SuspicionUnusualLocation     = "UNUSUAL_LOCATION"
+	SuspicionMultipleUsers       = "MULTIPLE_USERS"
+	SuspicionRapidLocationChange = "RAPID_LOCATION_CHANGE"
+)
+
+// NewDeviceSuspicion restores an assessment from its stored score and reasons
+func NewDeviceSuspicion(score int, reasons []string) *DeviceSuspicion {
+	s := &DeviceSuspicion{SuspicionScore: score, Reasons: reasons}
+	for _, r := range reasons {
+		switch r {
+		case SuspicionNewDevice:
+			s.IsNewDevice = true
+		case SuspicionUnusualLocation:
+			s.UnusualLocation = true
+		case SuspicionMultipleUsers:
+			s.MultipleUsers = true
+		case SuspicionRapidLocationChange:
+			s.RapidLocationChange = true
+		}
+	}
+	return s
+}
+
 // RegisterDeviceRequest represents a request to register a device
 type RegisterDeviceRequest struct {
 	Fingerprint string     `json:"fingerprint" validate:"required,min=32,max=128"`
 	DeviceType  DeviceType `json:"device_type" validate:"required,oneof=MOBILE TABLET DESKTOP WEB UNKNOWN"`
-	OS          DeviceOS   `json:"os" validate:"required"`
+	OS          DeviceOS   `json:"os" validate:"required,oneof=iOS ANDROID WINDOWS MACOS LINUX WEB UNKNOWN"`
 	OSVersion   string     `json:"os_version,omitempty" validate:"max=50"`
 	AppVersion  string     `json:"app_version,omitempty" validate:"max=20"`
 	DeviceName  string     `json:"device_name,omitempty" validate:"max=100"`
 	IP          string     `json:"-"` // Set from request headers, not user input
 }
 
+// TrustDeviceRequest trusts the current device
+// Code is only needed when the access token does not carry a fresh step-up
+type TrustDeviceRequest struct {
+	Code string `json:"code,omitempty" validate:"omitempty,numeric,len=6"`
+}
+
+// UntrustDevicesRequest revokes trust in all of a user's devices on behalf of the auth service
+type UntrustDevicesRequest struct {
+	Reason string `json:"reason" validate:"required,oneof=PASSWORD_CHANGED COMPROMISE_REPORTED"`
+}
+
+// PushProvider is the push notification service a token was issued by
+type PushProvider string
+
+const (
+	PushProviderAPNS PushProvider = "APNS"
+	PushProviderFCM  PushProvider = "FCM"
+)
+
+// RegisterPushTokenRequest sets or rotates the push token of the current device
+type RegisterPushTokenRequest struct {
+	Provider PushProvider `json:"provider" validate:"required,oneof=APNS FCM"`
+	Token    string       `json:"token" validate:"required,min=32,max=4096"`
+}
+
+// InvalidatePushTokenRequest reports a token the provider no longer accepts
+type InvalidatePushTokenRequest struct {
+	Token string `json:"token" validate:"required,min=32,max=4096"`
+}
+
+// PushToken is an active push token of a trusted device (internal only)
+// Token is the raw provider token and must never be logged
+type PushToken struct {
+	DeviceID   uuid.UUID    `json:"device_id"`
+	DeviceType DeviceType   `json:"device_type"`
+	OS         DeviceOS     `json:"os"`
+	Provider   PushProvider `json:"provider"`
+	Token      string       `json:"token"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
 // DeviceListItem is a summary for device listing
 type DeviceListItem struct {
 	ID           uuid.UUID  `json:"id"`
@@ -91,6 +180,7 @@ type DeviceListItem struct {
 	OS           DeviceOS   `json:"os"`
 	DeviceName   string     `json:"device_name,omitempty"`
 	IsTrusted    bool       `json:"is_trusted"`
+	TrustedUntil *time.Time `json:"trusted_until,omitempty"`
 	LastActiveAt *time.Time `json:"last_active_at,omitempty"`
 	IsCurrent    bool       `json:"is_current"` // Is this the device making the request
 }
@@ -103,28 +193,33 @@ func (d *Device) ToListItem(currentFingerprintHash string) *DeviceListItem {
 		OS:           d.OS,
 		DeviceName:   d.DeviceName,
 		IsTrusted:    d.IsTrusted,
+		TrustedUntil: d.TrustedUntil,
 		LastActiveAt: d.LastActiveAt,
 		IsCurrent:    d.FingerprintHash == currentFingerprintHash,
 	}
 }
 
+
 // DeviceForFraud is the data sent to fraud service (internal only)
 type DeviceForFraud struct {
-	DeviceID        uuid.UUID  `json:"device_id"`
-	UserID          uuid.UUID  `json:"user_id"`
-	FingerprintHash string     `json:"fingerprint_hash"`
-	DeviceType      DeviceType `json:"device_type"`
-	OS              DeviceOS   `json:"os"`
-	OSVersion       string     `json:"os_version,omitempty"`
-	LastIPHash      string     `json:"last_ip_hash"`
-	LastActiveAt    *time.Time `json:"last_active_at,omitempty"`
-	IsTrusted       bool       `json:"is_trusted"`
-	CreatedAt       time.Time  `json:"created_at"`
+	DeviceID         uuid.UUID  `json:"device_id"`
+	UserID           uuid.UUID  `json:"user_id"`
+	FingerprintHash  string     `json:"fingerprint_hash"`
+	DeviceType       DeviceType `json:"device_type"`
+	OS               DeviceOS   `json:"os"`
+	OSVersion        string     `json:"os_version,omitempty"`
+	LastIPHash       string     `json:"last_ip_hash"`
+	LastActiveAt     *time.Time `json:"last_active_at,omitempty"`
+	IsTrusted        bool       `json:"is_trusted"`
+	SuspicionScore   int        `json:"suspicion_score"`
+	SuspicionReasons []string   `json:"suspicion_reasons,omitempty"`
+	ScoredAt         *time.Time `json:"scored_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
 }
 
 // ToFraudData converts a Device to DeviceForFraud
 func (d *Device) ToFraudData() *DeviceForFraud {
-	return &DeviceForFraud{
+	data := &DeviceForFraud{
 		DeviceID:        d.ID,
 		UserID:          d.UserID,
 		FingerprintHash: d.FingerprintHash,
@@ -134,6 +229,12 @@ func (d *Device) ToFraudData() *DeviceForFraud {
 		LastIPHash:      d.LastIPHash,
 		LastActiveAt:    d.LastActiveAt,
 		IsTrusted:       d.IsTrusted,
+		ScoredAt:        d.ScoredAt,
 		CreatedAt:       d.CreatedAt,
 	}
+	if d.Suspicion != nil {
+		data.SuspicionScore = d.Suspicion.SuspicionScore
+		data.SuspicionReasons = d.Suspicion.Reasons
+	}
+	return data
 }
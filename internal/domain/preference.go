@@ -47,6 +47,8 @@ type Preference struct {
 	UXPreferences         UXPreferences                             `json:"ux_preferences" bson:"ux_preferences"`
 	FeatureFlags          map[string]bool                           `json:"feature_flags" bson:"feature_flags"`
 	CustomSettings        map[string]interface{}                    `json:"custom_settings,omitempty" bson:"custom_settings,omitempty"`
+	CustomSettingsVersions map[string]int                           `json:"custom_settings_versions,omitempty" bson:"custom_settings_versions,omitempty"` // Schema version of each namespace
+	Version               int                                       `json:"version" bson:"version"` // Latest PreferenceVersion; 0 before the first save
 	UpdatedAt             time.Time                                 `json:"updated_at" bson:"updated_at"`
 }
 
@@ -132,11 +134,40 @@ func IsSecurityNotification(t NotificationType) bool {
 	}
 }
 
+// HasChannel reports whether channels contains the given channel
+func HasChannel(channels []NotificationChannel, channel NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveChannel drops a delivery channel from every notification setting
+// Used to hide channels the user cannot currently receive, e.g. SMS before phone verification
+func (p *Preference) RemoveChannel(channel NotificationChannel) {
+	for notifType, setting := range p.NotificationSettings {
+		if !HasChannel(setting.Channels, channel) {
+			continue
+		}
+		filtered := make([]NotificationChannel, 0, len(setting.Channels))
+		for _, c := range setting.Channels {
+			if c != channel {
+				filtered = append(filtered, c)
+			}
+		}
+		setting.Channels = filtered
+		p.NotificationSettings[notifType] = setting
+	}
+}
+
 // UpdateNotificationRequest represents a request to update notification settings
 type UpdateNotificationRequest struct {
-	Type     NotificationType   `json:"type" validate:"required"`
-	Enabled  *bool              `json:"enabled,omitempty"`
+	Type     NotificationType      `json:"type" validate:"required"`
+	Enabled  *bool                 `json:"enabled,omitempty"`
 	Channels []NotificationChannel `json:"channels,omitempty"`
+	Quiet    *QuietHoursRequest    `json:"quiet,omitempty"`
 }
 
 // UpdateUXPreferencesRequest represents a request to update UX preferences
@@ -151,34 +182,63 @@ type UpdateUXPreferencesRequest struct {
 	HighContrast      *bool   `json:"high_contrast,omitempty"`
 }
 
+// CustomSettingsDocument is one namespace of a user's custom settings
+// Client teams store settings only in registered namespaces, validated against the namespace's schema
+type CustomSettingsDocument struct {
+	Namespace     string                 `json:"namespace"`
+	SchemaVersion int                    `json:"schema_version"`
+	Settings      map[string]interface{} `json:"settings"`
+}
+
 // NotificationPreferenceSummary is a lean summary for notification service
 type NotificationPreferenceSummary struct {
-	UserID              uuid.UUID                               `json:"user_id"`
-	Channels            map[NotificationType][]NotificationChannel `json:"channels"`
-	Timezone            string                                  `json:"timezone"`
-	QuietHoursEnabled   bool                                    `json:"quiet_hours_enabled"`
+	UserID            uuid.UUID                                        `json:"user_id"`
+	Channels          map[NotificationType][]NotificationChannel       `json:"channels"`
+	Timezone          string                                           `json:"timezone"`
+	QuietHoursEnabled bool                                             `json:"quiet_hours_enabled"`
+	Delivery          map[NotificationType]*NotificationDeliveryWindow `json:"delivery"`
+	EvaluatedAt       time.Time                                        `json:"evaluated_at"`
+}
+
+// NotificationDeliveryWindow tells the notification service whether an enabled
+// notification type may be sent at the summary's evaluation time
+type NotificationDeliveryWindow struct {
+	DeliverableNow bool        `json:"deliverable_now"`
+	NextAllowedAt  *time.Time  `json:"next_allowed_at,omitempty"` // End of the current quiet hours
+	Quiet          *QuietHours `json:"quiet,omitempty"`
 }
 
-// ToNotificationSummary converts preferences to a summary for notification service
-func (p *Preference) ToNotificationSummary() *NotificationPreferenceSummary {
-	channels := make(map[NotificationType][]NotificationChannel)
-	hasQuietHours := false
-	
+// ToNotificationSummary converts preferences to a summary for notification service,
+// with delivery evaluated at the given instant
+// Security notifications are always deliverable, whatever quiet hours are stored
+func (p *Preference) ToNotificationSummary(at time.Time) *NotificationPreferenceSummary {
+	summary := &NotificationPreferenceSummary{
+		UserID:      p.UserID,
+		Channels:    make(map[NotificationType][]NotificationChannel),
+		Timezone:    p.UXPreferences.Timezone,
+		Delivery:    make(map[NotificationType]*NotificationDeliveryWindow),
+		EvaluatedAt: at.UTC(),
+	}
+
 	for notifType, setting := range p.NotificationSettings {
-		if setting.Enabled {
-			channels[notifType] = setting.Channels
+		if !setting.Enabled {
+			continue
 		}
-		if setting.Quiet != nil && setting.Quiet.Enabled {
-			hasQuietHours = true
+		summary.Channels[notifType] = setting.Channels
+
+		window := &NotificationDeliveryWindow{DeliverableNow: true}
+		if setting.Quiet != nil && setting.Quiet.Enabled && !IsSecurityNotification(notifType) {
+			summary.QuietHoursEnabled = true
+			window.Quiet = setting.Quiet
+			if ok, next := setting.Quiet.NextAllowed(at, p.UXPreferences.Timezone); !ok {
+				window.DeliverableNow = false
+				window.NextAllowedAt = &next
+			}
 		}
+		summary.Delivery[notifType] = window
 	}
-	
-	return &NotificationPreferenceSummary{
-		UserID:            p.UserID,
-		Channels:          channels,
-		Timezone:          p.UXPreferences.Timezone,
-		QuietHoursEnabled: hasQuietHours,
-	}
+
+	return summary
 }
 
 // GetFeatureFlag returns a feature flag value with a default
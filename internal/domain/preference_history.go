@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Preference change sources
+const (
+	PreferenceChangeSourceUser   = "USER"
+	PreferenceChangeSourceSystem = "SYSTEM"
+	PreferenceChangeSourceAPI    = "API" // Another service, e.g. the auth service provisioning a user
+)
+
+// PreferenceVersion is an immutable snapshot of a user's preferences after a change
+// Versions are numbered from 1 per user and are never updated or deleted
+type PreferenceVersion struct {
+	ID            string      `json:"id" bson:"_id"`
+	UserID        uuid.UUID   `json:"user_id" bson:"user_id"`
+	Version       int         `json:"version" bson:"version"`
+	Preference    *Preference `json:"preference,omitempty" bson:"preference,omitempty"` // Omitted from listings
+	ChangedFields []string    `json:"changed_fields" bson:"changed_fields"`
+	ChangedBy     string      `json:"changed_by" bson:"changed_by"`
+	ChangeSource  string      `json:"change_source" bson:"change_source"` // USER, SYSTEM, API
+	CreatedAt     time.Time   `json:"created_at" bson:"created_at"`
+}
+
+// PreferenceFieldChange is one setting that differs between two preference versions
+type PreferenceFieldChange struct {
+	Field string `json:"field"` // Dotted path, e.g. "notification_settings.FRAUD_ALERT.channels"
+	From  any    `json:"from"`  // Nil when the setting did not exist yet
+	To    any    `json:"to"`    // Nil when the setting was removed
+}
+
+// PreferenceDiff lists what changed between two versions of a user's preferences
+type PreferenceDiff struct {
+	UserID      uuid.UUID               `json:"user_id"`
+	FromVersion int                     `json:"from_version"`
+	ToVersion   int                     `json:"to_version"`
+	Changes     []PreferenceFieldChange `json:"changes"`
+}
+
+// preferenceDiffIgnored are bookkeeping fields that differ between any two versions
+var preferenceDiffIgnored = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"version":    true,
+	"updated_at": true,
+}
+
+// DiffPreferences compares two preference snapshots setting by setting, using the same
+// field names as the API. Lists such as channels are compared as a whole
+func DiffPreferences(from, to *Preference) ([]PreferenceFieldChange, error) {
+	fromFields, err := flattenPreference(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := flattenPreference(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []PreferenceFieldChange{}
+	for field, fromValue := range fromFields {
+		toValue, ok := toFields[field]
+		if !ok || !reflect.DeepEqual(fromValue, toValue) {
+			changes = append(changes, PreferenceFieldChange{Field: field, From: fromValue, To: toValue})
+		}
+	}
+	for field, toValue := range toFields {
+		if _, ok := fromFields[field]; !ok {
+			changes = append(changes, PreferenceFieldChange{Field: field, To: toValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// flattenPreference maps each leaf setting's dotted JSON path to its value
+func flattenPreference(p *Preference) (map[string]any, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode preferences: %w", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode preferences: %w", err)
+	}
+
+	fields := make(map[string]any)
+	for key, value := range doc {
+		if !preferenceDiffIgnored[key] {
+			flattenInto(fields, key, value)
+		}
+	}
+	return fields, nil
+}
+
+func flattenInto(fields map[string]any, path string, value any) {
+	obj, ok := value.(map[string]any)
+	if !ok || len(obj) == 0 {
+		fields[path] = value
+		return
+	}
+	for key, child := range obj {
+		flattenInto(fields, path+"."+key, child)
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Quiet hours errors
+var (
+	ErrInvalidQuietHours    = errors.New("quiet hours must start and end at different hours")
+	ErrQuietHoursNotAllowed = errors.New("security notifications cannot have quiet hours")
+)
+
+// QuietHoursRequest sets the quiet hours of a notification type; Enabled false removes them
+// Hours are local to Timezone, or to the user's UX timezone when it is empty
+type QuietHoursRequest struct {
+	Enabled   bool   `json:"enabled"`
+	StartHour int    `json:"start_hour" validate:"min=0,max=23"`
+	EndHour   int    `json:"end_hour" validate:"min=0,max=23"`
+	Timezone  string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+}
+
+// ToQuietHours converts the request to the stored quiet hours; nil removes them
+func (r *QuietHoursRequest) ToQuietHours() (*QuietHours, error) {
+	if !r.Enabled {
+		return nil, nil
+	}
+	// An empty window would mute nothing and a full-day one is what Enabled is for
+	if r.StartHour == r.EndHour {
+		return nil, ErrInvalidQuietHours
+	}
+	return &QuietHours{
+		Enabled:   true,
+		StartHour: r.StartHour,
+		EndHour:   r.EndHour,
+		Timezone:  r.Timezone,
+	}, nil
+}
+
+// Equal reports whether q and o silence the same hours
+func (q *QuietHours) Equal(o *QuietHours) bool {
+	if q == nil || o == nil {
+		return q == o
+	}
+	return *q == *o
+}
+
+// NextAllowed reports whether a notification may be delivered at t and, if not, when
+// the quiet hours end. The window runs from StartHour up to EndHour local time and
+// wraps past midnight when EndHour is earlier than StartHour. fallbackTimezone is used
+// when the quiet hours have no timezone of their own
+func (q *QuietHours) NextAllowed(t time.Time, fallbackTimezone string) (bool, time.Time) {
+	if q == nil || !q.Enabled || q.StartHour == q.EndHour {
+		return true, t
+	}
+
+	local := t.In(quietHoursLocation(q.Timezone, fallbackTimezone))
+	hour := local.Hour()
+	y, m, d := local.Date()
+	endOn := func(day int) time.Time {
+		return time.Date(y, m, day, q.EndHour, 0, 0, 0, local.Location()).UTC()
+	}
+
+	if q.StartHour < q.EndHour {
+		if hour >= q.StartHour && hour < q.EndHour {
+			return false, endOn(d)
+		}
+		return true, t
+	}
+
+	// Overnight window, e.g. 22:00-07:00
+	switch {
+	case hour >= q.StartHour:
+		return false, endOn(d + 1)
+	case hour < q.EndHour:
+		return false, endOn(d)
+	default:
+		return true, t
+	}
+}
+
+// quietHoursLocation resolves the zone quiet hours are evaluated in
+// Zones are validated on write, so UTC is only reached for data stored before that
+func quietHoursLocation(timezone, fallback string) *time.Location {
+	for _, name := range []string{timezone, fallback} {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
@@ -22,6 +22,8 @@ const (
 	UserStatusActive    UserStatus = "ACTIVE"
 	UserStatusSuspended UserStatus = "SUSPENDED"
 	UserStatusPending   UserStatus = "PENDING"
+	UserStatusLocked    UserStatus = "LOCKED"  // Temporarily blocked, e.g. after failed logins
+	UserStatusClosed    UserStatus = "CLOSED"  // Terminal, retained for regulatory purposes
 	UserStatusDeleted   UserStatus = "DELETED" // Soft deleted
 )
 
@@ -38,28 +40,39 @@ const (
 // User represents a user profile entity
 // PII fields are stored encrypted in the database
 type User struct {
-	ID                   uuid.UUID  `json:"id" db:"id"`
-	LegalName            string     `json:"legal_name" db:"-"`             // Decrypted, HIGH PII
-	LegalNameEncrypted   string     `json:"-" db:"legal_name_encrypted"`   // Stored encrypted
-	Email                string     `json:"email" db:"-"`                  // Decrypted, HIGH PII
-	EmailEncrypted       string     `json:"-" db:"email_encrypted"`        // Stored encrypted
-	EmailHash            string     `json:"-" db:"email_hash"`             // For lookups
-	Phone                string     `json:"phone,omitempty" db:"-"`        // Decrypted, HIGH PII
-	PhoneEncrypted       *string    `json:"-" db:"phone_encrypted"`        // Stored encrypted
-	PhoneHash            *string    `json:"-" db:"phone_hash"`             // For lookups
-	DOB                  *time.Time `json:"dob,omitempty" db:"-"`          // Decrypted, HIGH PII
-	DOBEncrypted         *string    `json:"-" db:"dob_encrypted"`          // Stored encrypted
-	Country              string     `json:"country" db:"country"`          // ISO 3166-1 alpha-2
-	Status               UserStatus `json:"status" db:"status"`
-	KYCStatus            KYCStatus  `json:"kyc_status" db:"kyc_status"`
-	KYCReferenceID       *uuid.UUID `json:"kyc_reference_id,omitempty" db:"kyc_reference_id"`
-	RiskFlags            []string   `json:"risk_flags,omitempty" db:"risk_flags"`
-	EncryptionKeyVersion int        `json:"-" db:"encryption_key_version"`
-	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
-	DeletedAt            *time.Time `json:"-" db:"deleted_at"` // Soft delete
+	ID                   uuid.UUID    `json:"id" db:"id"`
+	LegalName            string       `json:"legal_name" db:"-"`           // Decrypted, HIGH PII
+	LegalNameEncrypted   string       `json:"-" db:"legal_name_encrypted"` // Stored encrypted
+	Email                string       `json:"email" db:"-"`                // Decrypted, HIGH PII
+	EmailEncrypted       string       `json:"-" db:"email_encrypted"`      // Stored encrypted
+	EmailHash            string       `json:"-" db:"email_hash"`           // For lookups
+	Phone                string       `json:"phone,omitempty" db:"-"`      // Decrypted, HIGH PII
+	PhoneEncrypted       *string      `json:"-" db:"phone_encrypted"`      // Stored encrypted
+	PhoneHash            *string      `json:"-" db:"phone_hash"`           // For lookups
+	DOB                  *time.Time   `json:"dob,omitempty" db:"-"`        // Decrypted, HIGH PII
+	DOBEncrypted         *string      `json:"-" db:"dob_encrypted"`        // Stored encrypted
+	Country              string       `json:"country" db:"country"`        // ISO 3166-1 alpha-2
+	Status               UserStatus   `json:"status" db:"status"`
+	KYCStatus            KYCStatus    `json:"kyc_status" db:"kyc_status"`
+	KYCReferenceID       *uuid.UUID   `json:"kyc_reference_id,omitempty" db:"kyc_reference_id"`
+	RiskFlags            []string     `json:"risk_flags,omitempty" db:"risk_flags"`
+	EncryptionKeyVersion int          `json:"-" db:"encryption_key_version"`
+	CreatedAt            time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at" db:"updated_at"`
+	DeletedAt            *time.Time   `json:"-" db:"deleted_at"` // Soft delete
+	PhoneVerifiedAt      *time.Time   `json:"phone_verified_at,omitempty" db:"phone_verified_at"`
+	StatusReason         StatusReason `json:"status_reason,omitempty" db:"status_reason"`
+	StatusChangedAt      *time.Time   `json:"status_changed_at,omitempty" db:"status_changed_at"`
 }
 
+// Risk flags with special handling
+const (
+	// RiskFlagLegalHold blocks erasure while litigation or an investigation is pending
+	RiskFlagLegalHold = "LEGAL_HOLD"
+	// RiskFlagSuspiciousDevice marks a user seen on a device whose suspicion score crossed the threshold
+	RiskFlagSuspiciousDevice = "SUSPICIOUS_DEVICE"
+)
+
 // IsActive returns true if the user is active
 func (u *User) IsActive() bool {
 	return u.Status == UserStatusActive && u.DeletedAt == nil
@@ -75,6 +88,11 @@ func (u *User) CanPerformHighRiskOps() bool {
 	return u.IsActive() && u.KYCStatus == KYCStatusApproved
 }
 
+// IsPhoneVerified returns true if the current phone number passed OTP verification
+func (u *User) IsPhoneVerified() bool {
+	return u.Phone != "" && u.PhoneVerifiedAt != nil
+}
+
 // HasRiskFlag checks if user has a specific risk flag
 func (u *User) HasRiskFlag(flag string) bool {
 	for _, f := range u.RiskFlags {
@@ -101,23 +119,52 @@ type UpdateUserRequest struct {
 	Country   *string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
 }
 
+// ChangeEmailRequest starts a two-step email change
+type ChangeEmailRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+// ConfirmEmailChangeRequest completes an email change with the token sent to the new address
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required,min=32,max=128"`
+}
+
+// EmailChangeResponse acknowledges a pending email change
+type EmailChangeResponse struct {
+	PendingEmail string    `json:"pending_email"` // Masked
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// ConfirmPhoneVerificationRequest submits the OTP sent to the user's phone
+type ConfirmPhoneVerificationRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// PhoneVerificationResponse acknowledges a sent verification code
+type PhoneVerificationResponse struct {
+	Phone     string    `json:"phone"` // Masked
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 // UserSummary is a lean DTO for other services (minimal PII)
 type UserSummary struct {
-	ID        uuid.UUID  `json:"id"`
-	Country   string     `json:"country"`
-	Status    UserStatus `json:"status"`
-	KYCStatus KYCStatus  `json:"kyc_status"`
-	RiskFlags []string   `json:"risk_flags,omitempty"`
+	ID            uuid.UUID  `json:"id"`
+	Country       string     `json:"country"`
+	Status        UserStatus `json:"status"`
+	KYCStatus     KYCStatus  `json:"kyc_status"`
+	RiskFlags     []string   `json:"risk_flags,omitempty"`
+	PhoneVerified bool       `json:"phone_verified"` // SMS is only deliverable when true
 }
 
 // ToSummary converts a User to UserSummary
 func (u *User) ToSummary() *UserSummary {
 	return &UserSummary{
-		ID:        u.ID,
-		Country:   u.Country,
-		Status:    u.Status,
-		KYCStatus: u.KYCStatus,
-		RiskFlags: u.RiskFlags,
+		ID:            u.ID,
+		Country:       u.Country,
+		Status:        u.Status,
+		KYCStatus:     u.KYCStatus,
+		RiskFlags:     u.RiskFlags,
+		PhoneVerified: u.IsPhoneVerified(),
 	}
 }
 
@@ -137,6 +184,14 @@ func (u *User) MaskedPhone() string {
 	return maskPhone(u.Phone)
 }
 
+// MaskEmail returns a masked email for display in notifications
+func MaskEmail(email string) string {
+	if email == "" {
+		return ""
+	}
+	return maskEmail(email)
+}
+
 func maskEmail(email string) string {
 	// Implementation matches logger's maskEmail
 	if len(email) < 3 {
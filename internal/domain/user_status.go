@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain/audit"
+)
+
+// Status transition errors
+var (
+	ErrInvalidStatusTransition = errors.New("status transition not allowed")
+	ErrTransitionNotPermitted  = errors.New("actor not permitted to perform status transition")
+	ErrInvalidStatusReason     = errors.New("status reason not valid for transition")
+)
+
+// StatusReason is a coded reason for an account status change
+// Free text is deliberately not supported: reasons end up in audit logs and events
+type StatusReason string
+
+const (
+	StatusReasonFraudSuspected    StatusReason = "FRAUD_SUSPECTED"
+	StatusReasonComplianceReview  StatusReason = "COMPLIANCE_REVIEW"
+	StatusReasonSanctionsHit      StatusReason = "SANCTIONS_HIT"
+	StatusReasonKYCFailed         StatusReason = "KYC_FAILED"
+	StatusReasonFailedLogins      StatusReason = "FAILED_LOGINS"
+	StatusReasonCompromised       StatusReason = "ACCOUNT_COMPROMISED"
+	StatusReasonReviewCleared     StatusReason = "REVIEW_CLEARED"
+	StatusReasonOnboardingDone    StatusReason = "ONBOARDING_COMPLETE"
+	StatusReasonCustomerRequest   StatusReason = "CUSTOMER_REQUEST"
+	StatusReasonBankInitiated     StatusReason = "BANK_INITIATED"
+	StatusReasonDeceased          StatusReason = "DECEASED"
+	StatusReasonSelfServiceDelete StatusReason = "SELF_SERVICE_DELETE"
+	StatusReasonAccountRestored   StatusReason = "ACCOUNT_RESTORED"
+)
+
+// statusRule describes one allowed edge of the status state machine
+type statusRule struct {
+	actors  []audit.ActorType
+	reasons []StatusReason
+}
+
+// statusTransitions is the account status state machine: from -> to -> rule
+// CLOSED is terminal; DELETED can only be undone by a restore within the grace period
+var statusTransitions = map[UserStatus]map[UserStatus]statusRule{
+	UserStatusPending: {
+		UserStatusDeleted: {
+			actors:  []audit.ActorType{audit.ActorUser},
+			reasons: []StatusReason{StatusReasonSelfServiceDelete},
+		},
+		UserStatusActive: {
+			actors:  []audit.ActorType{audit.ActorAdmin, audit.ActorSystem},
+			reasons: []StatusReason{StatusReasonOnboardingDone, StatusReasonReviewCleared},
+		},
+		UserStatusSuspended: {
+			actors:  []audit.ActorType{audit.ActorAdmin, audit.ActorSystem},
+			reasons: []StatusReason{StatusReasonFraudSuspected, StatusReasonComplianceReview, StatusReasonSanctionsHit, StatusReasonKYCFailed},
+		},
+		UserStatusClosed: {
+			actors:  []audit.ActorType{audit.ActorAdmin},
+			reasons: []StatusReason{StatusReasonCustomerRequest, StatusReasonBankInitiated, StatusReasonKYCFailed},
+		},
+	},
+	UserStatusActive: {
+		UserStatusSuspended: {
+			actors:  []audit.ActorType{audit.ActorAdmin, audit.ActorSystem},
+			reasons: []StatusReason{StatusReasonFraudSuspected, StatusReasonComplianceReview, StatusReasonSanctionsHit, StatusReasonKYCFailed},
+		},
+		UserStatusLocked: {
+			actors:  []audit.ActorType{audit.ActorAdmin, audit.ActorSystem},
+			reasons: []StatusReason{StatusReasonFailedLogins, StatusReasonCompromised},
+		},
+		UserStatusClosed: {
+			actors:  []audit.ActorType{audit.ActorAdmin},
+			reasons: []StatusReason{StatusReasonCustomerRequest, StatusReasonBankInitiated, StatusReasonDeceased},
+		},
+		UserStatusDeleted: {
+			actors:  []audit.ActorType{audit.ActorUser},
+			reasons: []StatusReason{StatusReasonSelfServiceDelete},
+		},
+	},
+	UserStatusSuspended: {
+		UserStatusActive: {
+			actors:  []audit.ActorType{audit.ActorAdmin},
+			reasons: []StatusReason{StatusReasonReviewCleared},
+		},
+		UserStatusClosed: {
+			actors:  []audit.ActorType{audit.ActorAdmin},
+			reasons: []StatusReason{StatusReasonBankInitiated, StatusReasonSanctionsHit, StatusReasonFraudSuspected, StatusReasonDeceased},
+		},
+	},
+	UserStatusLocked: {
+		UserStatusActive: {
+			actors:  []audit.ActorType{audit.ActorAdmin, audit.ActorSystem},
+			reasons: []StatusReason{StatusReasonReviewCleared},
+		},
+		UserStatusSuspended: {
+			actors:  []audit.ActorType{audit.ActorAdmin, audit.ActorSystem},
+			reasons: []StatusReason{StatusReasonFraudSuspected, StatusReasonCompromised},
+		},
+		UserStatusClosed: {
+			actors:  []audit.ActorType{audit.ActorAdmin},
+			reasons: []StatusReason{StatusReasonBankInitiated, StatusReasonDeceased},
+		},
+	},
+	UserStatusDeleted: {
+		UserStatusActive: {
+			actors:  []audit.ActorType{audit.ActorAdmin, audit.ActorUser},
+			reasons: []StatusReason{StatusReasonAccountRestored},
+		},
+	},
+}
+
+// CanTransitionTo reports whether any actor may move an account from s to target
+func (s UserStatus) CanTransitionTo(target UserStatus) bool {
+	_, ok := statusTransitions[s][target]
+	return ok
+}
+
+// ValidateStatusTransition checks a transition against the state machine
+func ValidateStatusTransition(from, to UserStatus, actor audit.ActorType, reason StatusReason) error {
+	rule, ok := statusTransitions[from][to]
+	if !ok {
+		return ErrInvalidStatusTransition
+	}
+	if !containsActor(rule.actors, actor) {
+		return ErrTransitionNotPermitted
+	}
+	if !containsReason(rule.reasons, reason) {
+		return ErrInvalidStatusReason
+	}
+	return nil
+}
+
+func containsActor(actors []audit.ActorType, actor audit.ActorType) bool {
+	for _, a := range actors {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
+
+func containsReason(reasons []StatusReason, reason StatusReason) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeStatusRequest is the body for admin status endpoints
+type ChangeStatusRequest struct {
+	Reason StatusReason `json:"reason" validate:"required,max=40"`
+}
+
+// StatusChangeResponse reports the result of a status transition
+type StatusChangeResponse struct {
+	UserID          uuid.UUID    `json:"user_id"`
+	PreviousStatus  UserStatus   `json:"previous_status"`
+	Status          UserStatus   `json:"status"`
+	Reason          StatusReason `json:"reason"`
+	StatusChangedAt time.Time    `json:"status_changed_at"`
+}
+
+// RestoreAccountResponse reports the result of restoring a soft-deleted account
+type RestoreAccountResponse struct {
+	UserID            uuid.UUID  `json:"user_id"`
+	Status            UserStatus `json:"status"`
+	RestoredAt        time.Time  `json:"restored_at"`
+	AddressesRestored int64      `json:"addresses_restored"`
+	DevicesRestored   int64      `json:"devices_restored"`
+}
@@ -0,0 +1,173 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+)
+
+// Domain event types published to the user events topic
+const (
+	// EventEmailChangeRequested asks the notification service to deliver a verification token to the new address
+	EventEmailChangeRequested = "user.email_change.requested"
+	// EventEmailChangeAlert warns the current address that an email change was requested
+	EventEmailChangeAlert = "user.email_change.alert"
+	// EventEmailChanged warns the previous address that the email change completed
+	EventEmailChanged = "user.email_changed"
+	// EventPhoneVerificationRequested asks the notification service to text an OTP to the user's phone
+	EventPhoneVerificationRequested = "user.phone_verification.requested"
+	// EventPhoneVerified signals that SMS may now be used for the user
+	EventPhoneVerified = "user.phone_verified"
+	// EventUserStatusChanged tells downstream services to start or stop serving the user
+	EventUserStatusChanged = "user.status_changed"
+	// EventUserErased tells downstream services to erase their copies of the user's PII
+	EventUserErased = "user.erased"
+	// EventAddressExpired tells statement delivery that a temporary address no longer applies
+	EventAddressExpired = "user.address.expired"
+	// EventPrimaryAddressChanged tells statement delivery which address to mail to from now on
+	EventPrimaryAddressChanged = "user.address.primary_changed"
+	// EventAddressChangeRequested asks the notification service to deliver the token confirming a held address edit
+	EventAddressChangeRequested = "user.address_change.requested"
+	// EventAddressChangeAlert warns the address on file that an edit replacing it was requested
+	EventAddressChangeAlert = "user.address_change.alert"
+	// EventDeviceRiskDetected tells fraud monitoring that a device crossed the suspicion threshold
+	EventDeviceRiskDetected = "user.device.risk_detected"
+	// EventDeviceStepUpRequested asks the notification service to text the code for trusting a device
+	EventDeviceStepUpRequested = "user.device.step_up_requested"
+	// EventDevicesUntrusted tells the auth service to stop skipping step-up on the listed devices
+	EventDevicesUntrusted = "user.devices.untrusted"
+	// EventDevicesRevoked tells the auth service to end the sessions of the listed devices
+	EventDevicesRevoked = "user.devices.revoked"
+	// EventDevicePruned asks the notification service to tell the user an inactive device was removed
+	EventDevicePruned = "device.pruned"
+	// EventConsentConfirmationRequested asks the notification service to email a double opt-in link
+	EventConsentConfirmationRequested = "user.consent.confirmation_requested"
+	// EventConsentChanged tells marketing and analytics systems that consent for a purpose was granted or withdrawn
+	EventConsentChanged = "user.consent.changed"
+)
+
+// Device change events published to the device topic for the fraud service
+const (
+	// EventDeviceRegistered reports a device seen for the first time for a user
+	EventDeviceRegistered = "device.registered"
+	// EventDeviceUpdated reports new details, activity or a new risk score for a device
+	EventDeviceUpdated = "device.updated"
+	// EventDeviceTrustChanged reports a device being trusted or untrusted
+	EventDeviceTrustChanged = "device.trust_changed"
+	// EventDeviceRemoved reports a device being removed by the user, evicted or revoked
+	EventDeviceRemoved = "device.removed"
+)
+
+// Notification templates understood by the notification service
+const (
+	TemplateEmailVerification  = "email_verification"
+	TemplateEmailChangeAlert   = "email_change_alert"
+	TemplateEmailChangedNotice = "email_changed_notice"
+	TemplatePhoneVerification  = "phone_verification"
+	TemplateDeviceStepUp       = "device_step_up"
+
+	TemplateAddressChangeConfirmation = "address_change_confirmation"
+	TemplateAddressChangeAlert        = "address_change_alert"
+
+	TemplateConsentConfirmation = "consent_confirmation"
+)
+
+// ChannelPostal delivers a ContactNotification by letter; the recipient is the
+// mailing label with one line per label line
+const ChannelPostal = "POSTAL"
+
+// ContactNotification addresses a notification to an explicit contact point.
+// Used when the recipient is not (or no longer) the contact on the user's profile,
+// e.g. a new email awaiting verification or the previous email after a change.
+// Published to the notification topic only, never the user events topic.
+type ContactNotification struct {
+	Channel   string            `json:"channel"`
+	Recipient string            `json:"recipient"`
+	Template  string            `json:"template"`
+	Params    map[string]string `json:"params,omitempty"`
+}
+
+// StatusChangedEvent is the payload of EventUserStatusChanged
+type StatusChangedEvent struct {
+	PreviousStatus domain.UserStatus   `json:"previous_status"`
+	Status         domain.UserStatus   `json:"status"`
+	Reason         domain.StatusReason `json:"reason"`
+	ActorType      audit.ActorType     `json:"actor_type"`
+	ChangedAt      time.Time           `json:"changed_at"`
+}
+
+// UserErasedEvent is the payload of EventUserErased
+type UserErasedEvent struct {
+	ErasedAt time.Time `json:"erased_at"`
+}
+
+// AddressExpiredEvent is the payload of EventAddressExpired
+// Addresses are referenced by ID only; consumers fetch details through the API
+type AddressExpiredEvent struct {
+	AddressID  uuid.UUID  `json:"address_id"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	ExpiredAt  time.Time  `json:"expired_at"`
+}
+
+// PrimaryAddressChangedEvent is the payload of EventPrimaryAddressChanged
+type PrimaryAddressChangedEvent struct {
+	AddressID         uuid.UUID          `json:"address_id"`
+	AddressType       domain.AddressType `json:"address_type"`
+	PreviousAddressID uuid.UUID          `json:"previous_address_id"`
+	ActorType         audit.ActorType    `json:"actor_type"`
+	ChangedAt         time.Time          `json:"changed_at"`
+}
+
+// DeviceRiskDetectedEvent is the payload of EventDeviceRiskDetected
+type DeviceRiskDetectedEvent struct {
+	DeviceID   uuid.UUID `json:"device_id"`
+	Score      int       `json:"score"`
+	Reasons    []string  `json:"reasons"`
+	RiskFlag   string    `json:"risk_flag"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// DevicesUntrustedEvent is the payload of EventDevicesUntrusted
+type DevicesUntrustedEvent struct {
+	DeviceIDs   []uuid.UUID     `json:"device_ids"`
+	Reason      string          `json:"reason"`
+	ActorType   audit.ActorType `json:"actor_type"`
+	UntrustedAt time.Time       `json:"untrusted_at"`
+}
+
+// DevicesRevokedEvent is the payload of EventDevicesRevoked
+type DevicesRevokedEvent struct {
+	DeviceIDs []uuid.UUID `json:"device_ids"`
+	RevokedAt time.Time   `json:"revoked_at"`
+}
+
+// DeviceChangedEvent is the payload of the device topic events
+// Device is omitted when only the ID is known, e.g. for bulk changes and removals
+type DeviceChangedEvent struct {
+	DeviceID  uuid.UUID              `json:"device_id"`
+	Device    *domain.DeviceForFraud `json:"device,omitempty"`
+	Reason    string                 `json:"reason,omitempty"`
+	ChangedAt time.Time              `json:"changed_at"`
+}
+
+// DevicePrunedEvent is the payload of EventDevicePruned
+type DevicePrunedEvent struct {
+	DeviceID     uuid.UUID         `json:"device_id"`
+	DeviceName   string            `json:"device_name,omitempty"`
+	DeviceType   domain.DeviceType `json:"device_type"`
+	OS           domain.DeviceOS   `json:"os"`
+	LastActiveAt *time.Time        `json:"last_active_at,omitempty"`
+	WasTrusted   bool              `json:"was_trusted"`
+	PrunedAt     time.Time         `json:"pruned_at"`
+}
+
+// ConsentChangedEvent is the payload of EventConsentChanged
+type ConsentChangedEvent struct {
+	Purpose        domain.ConsentPurpose `json:"purpose"`
+	Status         domain.ConsentStatus  `json:"status"`
+	WordingVersion string                `json:"wording_version"`
+	ChangedAt      time.Time             `json:"changed_at"`
+}
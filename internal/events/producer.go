@@ -59,11 +59,17 @@ func NewAuditProducer(cfg AuditProducerConfig, cb *resilience.CircuitBreaker, pe
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
 	}
 
+	return NewAuditProducerWithClient(producer, cfg.Topic, cfg.BufferSize, cb, persistFn, log), nil
+}
+
+// NewAuditProducerWithClient creates an audit event producer sending through producer,
+// e.g. a mock in tests; producer must return successes and errors
+func NewAuditProducerWithClient(producer sarama.AsyncProducer, topic string, bufferSize int, cb *resilience.CircuitBreaker, persistFn func(ctx context.Context, events []resilience.BufferedEvent) error, log *logger.Logger) *AuditProducer {
 	ap := &AuditProducer{
 		producer: producer,
-		topic:    cfg.Topic,
+		topic:    topic,
 		cb:       cb,
-		buffer:   resilience.NewEventBuffer(cfg.BufferSize, persistFn),
+		buffer:   resilience.NewEventBuffer(bufferSize, persistFn),
 		log:      log.Named("audit_producer"),
 	}
 
@@ -77,7 +83,7 @@ func NewAuditProducer(cfg AuditProducerConfig, cb *resilience.CircuitBreaker, pe
 		return ap.sendDirect(event.Payload, event.Key)
 	})
 
-	return ap, nil
+	return ap
 }
 
 // Produce sends an audit event to Kafka
@@ -208,6 +214,12 @@ func NewEventProducer(brokers []string, topic string, cb *resilience.CircuitBrea
 		return nil, err
 	}
 
+	return NewEventProducerWithClient(producer, topic, cb, log), nil
+}
+
+// NewEventProducerWithClient creates a domain event producer sending through producer,
+// e.g. a mock in tests; producer must return errors
+func NewEventProducerWithClient(producer sarama.AsyncProducer, topic string, cb *resilience.CircuitBreaker, log *logger.Logger) *EventProducer {
 	ep := &EventProducer{
 		producer: producer,
 		topic:    topic,
@@ -218,7 +230,7 @@ func NewEventProducer(brokers []string, topic string, cb *resilience.CircuitBrea
 	// Start error handler
 	go ep.handleErrors()
 
-	return ep, nil
+	return ep
 }
 
 // UserEvent represents a user-related domain event
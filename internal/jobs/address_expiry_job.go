@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// AddressExpiryJob periodically retires temporary addresses past their validity window
+type AddressExpiryJob struct {
+	expiryService *service.AddressExpiryService
+	log           *logger.Logger
+}
+
+// NewAddressExpiryJob creates a new temporary address expiry job
+func NewAddressExpiryJob(expiryService *service.AddressExpiryService, log *logger.Logger) *AddressExpiryJob {
+	return &AddressExpiryJob{
+		expiryService: expiryService,
+		log:           log.Named("address_expiry_job"),
+	}
+}
+
+// Name implements Job
+func (j *AddressExpiryJob) Name() string {
+	return "address_expiry"
+}
+
+// Run implements Job
+func (j *AddressExpiryJob) Run(ctx context.Context) error {
+	expired, err := j.expiryService.ExpireTemporary(ctx)
+	if expired > 0 {
+		j.log.Info("expired temporary addresses", zap.Int("count", expired))
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// AddressValidationJob periodically validates addresses still in PENDING
+type AddressValidationJob struct {
+	validationService *service.AddressValidationService
+	log               *logger.Logger
+}
+
+// NewAddressValidationJob creates a new address validation job
+func NewAddressValidationJob(validationService *service.AddressValidationService, log *logger.Logger) *AddressValidationJob {
+	return &AddressValidationJob{
+		validationService: validationService,
+		log:               log.Named("address_validation_job"),
+	}
+}
+
+// Name implements Job
+func (j *AddressValidationJob) Name() string {
+	return "address_validation"
+}
+
+// Run implements Job
+func (j *AddressValidationJob) Run(ctx context.Context) error {
+	settled, err := j.validationService.ValidatePending(ctx)
+	if settled > 0 {
+		j.log.Info("validated pending addresses", zap.Int("count", settled))
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// DevicePruneJob periodically removes devices inactive beyond the configured threshold
+// It must run under Exclusive so replicas do not notify users twice
+type DevicePruneJob struct {
+	pruneService *service.DevicePruneService
+	pruned       metric.Int64Counter
+	log          *logger.Logger
+}
+
+// NewDevicePruneJob creates a new stale device pruning job
+func NewDevicePruneJob(pruneService *service.DevicePruneService, log *logger.Logger) *DevicePruneJob {
+	return &DevicePruneJob{
+		pruneService: pruneService,
+		pruned:       newCounter(log, "devices.pruned", "Devices removed after long inactivity"),
+		log:          log.Named("device_prune_job"),
+	}
+}
+
+// Name implements Job
+func (j *DevicePruneJob) Name() string {
+	return "device_prune"
+}
+
+// Run implements Job
+func (j *DevicePruneJob) Run(ctx context.Context) error {
+	pruned, err := j.pruneService.PruneInactive(ctx)
+	if pruned > 0 {
+		j.pruned.Add(ctx, int64(pruned))
+		j.log.Info("pruned inactive devices", zap.Int("count", pruned))
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/service"
+)
+
+// ErasureJob periodically wipes PII of accounts deleted longer than the retention period
+type ErasureJob struct {
+	erasureService *service.ErasureService
+	log            *logger.Logger
+}
+
+// NewErasureJob creates a new erasure job
+func NewErasureJob(erasureService *service.ErasureService, log *logger.Logger) *ErasureJob {
+	return &ErasureJob{
+		erasureService: erasureService,
+		log:            log.Named("erasure_job"),
+	}
+}
+
+// Name implements Job
+func (j *ErasureJob) Name() string {
+	return "erasure"
+}
+
+// Run implements Job
+func (j *ErasureJob) Run(ctx context.Context) error {
+	erased, err := j.erasureService.EraseExpired(ctx)
+	if erased > 0 {
+		j.log.Info("erased expired accounts", zap.Int("count", erased))
+	}
+	return err
+}
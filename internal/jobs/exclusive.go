@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+// Locker runs work only while no other replica is running work of the same name
+type Locker interface {
+	TryWithLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error)
+}
+
+// ExclusiveJob runs a job on one replica at a time; the others skip their run
+type ExclusiveJob struct {
+	job     Job
+	locker  Locker
+	skipped metric.Int64Counter
+	log     *logger.Logger
+}
+
+// Exclusive wraps job so that only the replica holding the job's lock runs it
+func Exclusive(job Job, locker Locker, log *logger.Logger) *ExclusiveJob {
+	return &ExclusiveJob{
+		job:     job,
+		locker:  locker,
+		skipped: newCounter(log, "jobs.skipped", "Job runs skipped because another replica held the lock"),
+		log:     log.Named("exclusive_job"),
+	}
+}
+
+// Name implements Job
+func (j *ExclusiveJob) Name() string {
+	return j.job.Name()
+}
+
+// Run implements Job
+func (j *ExclusiveJob) Run(ctx context.Context) error {
+	ran, err := j.locker.TryWithLock(ctx, j.job.Name(), j.job.Run)
+	if !ran && err == nil {
+		j.skipped.Add(ctx, 1, metric.WithAttributes(attribute.String("job", j.job.Name())))
+		j.log.Debug("job skipped, running on another replica", logger.Component(j.job.Name()))
+	}
+	return err
+}
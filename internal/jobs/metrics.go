@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+// meterName scopes the job instruments
+const meterName = "github.com/banking/user-service/internal/jobs"
+
+// newCounter creates a counter on the global meter provider, which metrics.New installs
+// Metrics must never stop a job, so a failure falls back to a no-op counter
+func newCounter(log *logger.Logger, name, description string) metric.Int64Counter {
+	counter, err := otel.Meter(meterName).Int64Counter(name, metric.WithDescription(description))
+	if err != nil {
+		log.Warn("failed to create metric, falling back to no-op", logger.Component(name), logger.ErrorField(err))
+		return noop.Int64Counter{}
+	}
+	return counter
+}
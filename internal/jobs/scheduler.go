@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+// Job is a unit of periodic background work
+// Run must be safe to execute on several instances at once, or the job wrapped with Exclusive
+type Job interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+type scheduledJob struct {
+	job      Job
+	interval time.Duration
+}
+
+// Scheduler runs registered jobs at fixed intervals until stopped
+type Scheduler struct {
+	jobs   []scheduledJob
+	log    *logger.Logger
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a new job scheduler
+func NewScheduler(log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		log: log.Named("scheduler"),
+	}
+}
+
+// Register adds a job; must be called before Start
+func (s *Scheduler) Register(job Job, interval time.Duration) {
+	if interval <= 0 {
+		s.log.Warn("job disabled, interval must be positive", logger.Component(job.Name()))
+		return
+	}
+	s.jobs = append(s.jobs, scheduledJob{job: job, interval: interval})
+}
+
+// Start launches one goroutine per registered job
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, sj := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, sj)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, sj scheduledJob) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(sj.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, sj.job)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	// A panicking job must not take the service down
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Error("job panicked", logger.Component(job.Name()))
+		}
+	}()
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		s.log.Error("job failed",
+			logger.Component(job.Name()),
+			logger.Duration(time.Since(start).Milliseconds()),
+			logger.ErrorField(err),
+		)
+		return
+	}
+
+	s.log.Debug("job completed",
+		logger.Component(job.Name()),
+		logger.Duration(time.Since(start).Milliseconds()),
+	)
+}
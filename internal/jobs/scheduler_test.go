@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+type countingJob struct {
+	runs  atomic.Int32
+	panic bool
+}
+
+func (j *countingJob) Name() string { return "counting" }
+
+func (j *countingJob) Run(ctx context.Context) error {
+	j.runs.Add(1)
+	if j.panic {
+		panic("boom")
+	}
+	return nil
+}
+
+func newTestLogger(t *testing.T) *logger.Logger {
+	log, err := logger.New(logger.Config{Level: "error", OutputPath: "stderr"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	return log
+}
+
+func TestScheduler_RunsJobsUntilStopped(t *testing.T) {
+	s := NewScheduler(newTestLogger(t))
+	job := &countingJob{}
+	s.Register(job, 5*time.Millisecond)
+
+	s.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+
+	runs := job.runs.Load()
+	if runs == 0 {
+		t.Fatal("expected job to run at least once")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if job.runs.Load() != runs {
+		t.Error("job ran after Stop")
+	}
+}
+
+func TestScheduler_RecoversFromPanic(t *testing.T) {
+	s := NewScheduler(newTestLogger(t))
+	job := &countingJob{panic: true}
+	s.Register(job, 5*time.Millisecond)
+
+	s.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+
+	if job.runs.Load() < 2 {
+		t.Errorf("expected job to keep running after a panic, ran %d times", job.runs.Load())
+	}
+}
+
+func TestScheduler_SkipsNonPositiveInterval(t *testing.T) {
+	s := NewScheduler(newTestLogger(t))
+	s.Register(&countingJob{}, 0)
+
+	if len(s.jobs) != 0 {
+		t.Errorf("expected job with zero interval to be skipped, got %d jobs", len(s.jobs))
+	}
+}
+
+// fakeLocker grants the lock unless held is set
+type fakeLocker struct {
+	held bool
+	name string
+}
+
+func (l *fakeLocker) TryWithLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	l.name = name
+	if l.held {
+		return false, nil
+	}
+	return true, fn(ctx)
+}
+
+func TestExclusive_RunsOnlyWithLock(t *testing.T) {
+	job := &countingJob{}
+	locker := &fakeLocker{}
+	exclusive := Exclusive(job, locker, newTestLogger(t))
+
+	if err := exclusive.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.runs.Load() != 1 {
+		t.Errorf("expected job to run with the lock free, ran %d times", job.runs.Load())
+	}
+	if locker.name != job.Name() {
+		t.Errorf("expected lock named after the job, got %q", locker.name)
+	}
+
+	locker.held = true
+	if err := exclusive.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.runs.Load() != 1 {
+		t.Error("expected job to be skipped while another replica holds the lock")
+	}
+}
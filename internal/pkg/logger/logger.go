@@ -255,6 +255,10 @@ func Operation(name string) zap.Field {
 	return zap.String("operation", name)
 }
 
+func EventType(t string) zap.Field {
+	return zap.String("event_type", t)
+}
+
 func Duration(d int64) zap.Field {
 	return zap.Int64("duration_ms", d)
 }
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+// Config holds metrics settings
+type Config struct {
+	Enabled  bool
+	Interval time.Duration // How often counts are reported (default: 1m)
+}
+
+// Provider owns the global meter provider
+type Provider struct {
+	provider *sdkmetric.MeterProvider
+}
+
+// New installs the global meter provider
+// Counts are reported to the service log once per interval, as the change since the last
+// report; instruments created before New, e.g. by jobs, pick the provider up as well
+func New(cfg Config, log *logger.Logger) *Provider {
+	if !cfg.Enabled {
+		return &Provider{}
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(
+			&logExporter{log: log.Named("metrics")},
+			sdkmetric.WithInterval(cfg.Interval),
+		)),
+	)
+	otel.SetMeterProvider(provider)
+
+	return &Provider{provider: provider}
+}
+
+// Shutdown reports the counts not yet reported and stops the provider
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider != nil {
+		return p.provider.Shutdown(ctx)
+	}
+	return nil
+}
+
+// logExporter writes sums to the log, one entry per metric and attribute set
+// Only counters are reported; other aggregations are not used by the service
+type logExporter struct {
+	log *logger.Logger
+}
+
+// Temporality implements sdkmetric.Exporter; deltas keep each entry meaningful on its own
+func (e *logExporter) Temporality(sdkmetric.InstrumentKind) metricdata.Temporality {
+	return metricdata.DeltaTemporality
+}
+
+// Aggregation implements sdkmetric.Exporter
+func (e *logExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+// Export implements sdkmetric.Exporter
+// Counts that did not change since the last report are skipped
+func (e *logExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				if dp.Value == 0 {
+					continue
+				}
+				fields := []zap.Field{zap.String("metric", m.Name), zap.Int64("value", dp.Value)}
+				for _, kv := range dp.Attributes.ToSlice() {
+					fields = append(fields, attributeField(kv))
+				}
+				e.log.Info("metric", fields...)
+			}
+		}
+	}
+	return nil
+}
+
+// ForceFlush implements sdkmetric.Exporter; entries are written as they are exported
+func (e *logExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// Shutdown implements sdkmetric.Exporter
+func (e *logExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func attributeField(kv attribute.KeyValue) zap.Field {
+	return zap.String(string(kv.Key), kv.Value.Emit())
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+func TestProvider_ReportsCountsToLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.log")
+	log, err := logger.New(logger.Config{Level: "info", OutputPath: path})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	// Created before the provider, as the jobs' counters are
+	counter, err := otel.Meter("test").Int64Counter("jobs.skipped")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	idle, err := otel.Meter("test").Int64Counter("devices.pruned")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	idle.Add(context.Background(), 0)
+
+	provider := New(Config{Enabled: true, Interval: time.Hour}, log)
+	counter.Add(context.Background(), 2, metric.WithAttributes(attribute.String("job", "device_prune")))
+	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("job", "device_prune")))
+
+	// Shutdown reports what was counted since the last interval
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log.Sync()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		if entry["msg"] == "metric" {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected one metric entry, got %v", entries)
+	}
+	if entries[0]["metric"] != "jobs.skipped" || entries[0]["value"] != float64(3) || entries[0]["job"] != "device_prune" {
+		t.Errorf("expected jobs.skipped=3 for device_prune, got %v", entries[0])
+	}
+}
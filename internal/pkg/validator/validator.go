@@ -18,6 +18,7 @@ func New() *CustomValidator {
 	// Register custom validations
 	_ = v.RegisterValidation("e164", validateE164)
 	_ = v.RegisterValidation("iso3166_1_alpha2", validateISO3166Alpha2)
+	_ = v.RegisterValidation("feature_flag_key", validateFeatureFlagKey)
 
 	return &CustomValidator{validator: v}
 }
@@ -40,3 +41,10 @@ var iso3166Regex = regexp.MustCompile(`^[A-Z]{2}$`)
 func validateISO3166Alpha2(fl validator.FieldLevel) bool {
 	return iso3166Regex.MatchString(fl.Field().String())
 }
+
+// Feature flag keys are lowercase, e.g. "new_dashboard" or "payments.instant-transfer"
+var featureFlagKeyRegex = regexp.MustCompile(`^[a-z][a-z0-9_.-]{1,63}$`)
+
+func validateFeatureFlagKey(fl validator.FieldLevel) bool {
+	return featureFlagKeyRegex.MatchString(fl.Field().String())
+}
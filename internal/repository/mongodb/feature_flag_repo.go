@@ -0,0 +1,160 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// Feature flag repository errors
+var (
+	ErrFeatureFlagNotFound = errors.New("feature flag not found")
+	ErrFeatureFlagExists   = errors.New("feature flag already exists")
+)
+
+// featureFlagsCollection holds one definition per flag, keyed by flag key
+const featureFlagsCollection = "feature_flags"
+
+// FeatureFlagRepository handles feature flag definitions in MongoDB
+type FeatureFlagRepository struct {
+	coll *mongo.Collection
+	cb   *resilience.CircuitBreaker
+}
+
+// NewFeatureFlagRepository creates a new feature flag repository
+func NewFeatureFlagRepository(db *mongo.Database, cb *resilience.CircuitBreaker) *FeatureFlagRepository {
+	return &FeatureFlagRepository{
+		coll: db.Collection(featureFlagsCollection),
+		cb:   cb,
+	}
+}
+
+// List returns every flag definition ordered by key
+func (r *FeatureFlagRepository) List(ctx context.Context) ([]*domain.FeatureFlag, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.list(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.FeatureFlag), nil
+}
+
+func (r *FeatureFlagRepository) list(ctx context.Context) ([]*domain.FeatureFlag, error) {
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+
+	flags := []*domain.FeatureFlag{}
+	if err := cursor.All(ctx, &flags); err != nil {
+		return nil, fmt.Errorf("failed to decode feature flags: %w", err)
+	}
+
+	return flags, nil
+}
+
+// Get retrieves a flag definition by key
+func (r *FeatureFlagRepository) Get(ctx context.Context, key string) (*domain.FeatureFlag, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.get(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*domain.FeatureFlag), nil
+}
+
+func (r *FeatureFlagRepository) get(ctx context.Context, key string) (*domain.FeatureFlag, error) {
+	var flag domain.FeatureFlag
+	err := r.coll.FindOne(ctx, bson.M{"_id": key}).Decode(&flag)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrFeatureFlagNotFound
+		}
+		return nil, fmt.Errorf("failed to get feature flag: %w", err)
+	}
+
+	return &flag, nil
+}
+
+// Create stores a new flag definition as version 1
+func (r *FeatureFlagRepository) Create(ctx context.Context, flag *domain.FeatureFlag) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.create(ctx, flag)
+	})
+	return err
+}
+
+func (r *FeatureFlagRepository) create(ctx context.Context, flag *domain.FeatureFlag) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	flag.Version = 1
+	flag.CreatedAt = now
+	flag.UpdatedAt = now
+
+	if _, err := r.coll.InsertOne(ctx, flag); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrFeatureFlagExists
+		}
+		return fmt.Errorf("failed to create feature flag: %w", err)
+	}
+	return nil
+}
+
+// Update replaces a flag definition if flag.Version is still the stored version
+// On success flag.Version is advanced; ErrOptimisticLock is returned if the flag was changed since
+func (r *FeatureFlagRepository) Update(ctx context.Context, flag *domain.FeatureFlag) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.update(ctx, flag)
+	})
+	return err
+}
+
+func (r *FeatureFlagRepository) update(ctx context.Context, flag *domain.FeatureFlag) error {
+	doc := *flag
+	doc.Version = flag.Version + 1
+	doc.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+
+	result, err := r.coll.ReplaceOne(ctx, bson.M{"_id": flag.Key, "version": flag.Version}, doc)
+	if err != nil {
+		return fmt.Errorf("failed to update feature flag: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		// Distinguish a deleted flag from a concurrent update
+		if _, err := r.get(ctx, flag.Key); err != nil {
+			return err
+		}
+		return ErrOptimisticLock
+	}
+
+	flag.Version = doc.Version
+	flag.UpdatedAt = doc.UpdatedAt
+	return nil
+}
+
+// Delete removes a flag definition; users' overrides of it are left in place
+func (r *FeatureFlagRepository) Delete(ctx context.Context, key string) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.delete(ctx, key)
+	})
+	return err
+}
+
+func (r *FeatureFlagRepository) delete(ctx context.Context, key string) error {
+	result, err := r.coll.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrFeatureFlagNotFound
+	}
+	return nil
+}
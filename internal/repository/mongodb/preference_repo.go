@@ -0,0 +1,296 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// Preference repository errors
+var (
+	ErrPreferenceNotFound        = errors.New("preference not found")
+	ErrPreferenceVersionNotFound = errors.New("preference version not found")
+	ErrOptimisticLock            = errors.New("optimistic lock conflict: preferences were modified")
+)
+
+const (
+	// preferencesCollection holds one document per user with their current preferences
+	preferencesCollection = "preferences"
+	// preferenceVersionsCollection holds every saved version; it is insert-only
+	preferenceVersionsCollection = "preference_versions"
+)
+
+// PreferenceRepository handles preference persistence in MongoDB
+// Every save appends an immutable version to preference_versions before the current
+// document is replaced, so the history is authoritative and the current document a cache of it
+type PreferenceRepository struct {
+	coll     *mongo.Collection
+	versions *mongo.Collection
+	cb       *resilience.CircuitBreaker
+}
+
+// NewPreferenceRepository creates a new preference repository
+func NewPreferenceRepository(db *mongo.Database, cb *resilience.CircuitBreaker) *PreferenceRepository {
+	return &PreferenceRepository{
+		coll:     db.Collection(preferencesCollection),
+		versions: db.Collection(preferenceVersionsCollection),
+		cb:       cb,
+	}
+}
+
+// EnsureIndexes creates the collections' indexes; it is safe to run on every start
+// The unique (user_id, version) index is what turns concurrent saves into a conflict
+func (r *PreferenceRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetName("uniq_user_id").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create preference indexes: %w", err)
+	}
+
+	_, err = r.versions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetName("uniq_user_id_version").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create preference version indexes: %w", err)
+	}
+	return nil
+}
+
+// GetByUserID retrieves the user's preferences
+func (r *PreferenceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Preference, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.getByUserID(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*domain.Preference), nil
+}
+
+func (r *PreferenceRepository) getByUserID(ctx context.Context, userID uuid.UUID) (*domain.Preference, error) {
+	var pref domain.Preference
+	err := r.coll.FindOne(ctx, bson.M{"user_id": userID}).Decode(&pref)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPreferenceNotFound
+		}
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	if err := normalizeCustomSettings(&pref); err != nil {
+		return nil, err
+	}
+
+	return &pref, nil
+}
+
+// normalizeCustomSettings turns custom settings decoded from BSON (primitive.D documents,
+// int32 numbers) into the maps, slices and float64 numbers JSON decoding produces, so
+// stored settings validate, migrate and compare like settings from a request
+func normalizeCustomSettings(pref *domain.Preference) error {
+	if len(pref.CustomSettings) == 0 {
+		return nil
+	}
+	raw, err := bson.MarshalExtJSON(pref.CustomSettings, false, false)
+	if err != nil {
+		return fmt.Errorf("failed to read custom settings: %w", err)
+	}
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return fmt.Errorf("failed to read custom settings: %w", err)
+	}
+	pref.CustomSettings = settings
+	return nil
+}
+
+// Upsert saves pref as the version after pref.Version, recording who changed which fields
+// ErrOptimisticLock is returned if that version already exists, i.e. another write got
+// there first. On success pref.Version and pref.UpdatedAt are advanced
+func (r *PreferenceRepository) Upsert(ctx context.Context, pref *domain.Preference, changedBy, changeSource string, changedFields []string) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.upsert(ctx, pref, changedBy, changeSource, changedFields)
+	})
+	return err
+}
+
+func (r *PreferenceRepository) upsert(ctx context.Context, pref *domain.Preference, changedBy, changeSource string, changedFields []string) error {
+	// MongoDB stores milliseconds, so the timestamp is truncated to match what is read back
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	snapshot := *pref
+	snapshot.Version = pref.Version + 1
+	snapshot.UpdatedAt = now
+
+	if changedFields == nil {
+		changedFields = []string{}
+	}
+	version := &domain.PreferenceVersion{
+		ID:            uuid.New().String(),
+		UserID:        pref.UserID,
+		Version:       snapshot.Version,
+		Preference:    &snapshot,
+		ChangedFields: changedFields,
+		ChangedBy:     changedBy,
+		ChangeSource:  changeSource,
+		CreatedAt:     now,
+	}
+
+	if _, err := r.versions.InsertOne(ctx, version); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// The winning write may have failed before updating the current document;
+			// bring it forward so the caller reloads what the history says
+			_ = r.rollForward(ctx, pref.UserID)
+			return ErrOptimisticLock
+		}
+		return fmt.Errorf("failed to save preference version: %w", err)
+	}
+
+	if err := r.applyVersion(ctx, version); err != nil {
+		return err
+	}
+
+	pref.Version = snapshot.Version
+	pref.UpdatedAt = now
+	return nil
+}
+
+// applyVersion makes version the user's current preferences unless a later one already is
+func (r *PreferenceRepository) applyVersion(ctx context.Context, version *domain.PreferenceVersion) error {
+	// Documents saved before versioning have no version field
+	filter := bson.M{
+		"user_id": version.UserID,
+		"$or": bson.A{
+			bson.M{"version": bson.M{"$lt": version.Version}},
+			bson.M{"version": bson.M{"$exists": false}},
+		},
+	}
+
+	// If a later version is current the filter matches nothing and the upsert's insert
+	// is rejected by the unique user_id index, which leaves that version in place
+	_, err := r.coll.ReplaceOne(ctx, filter, version.Preference, options.Replace().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
+
+// rollForward applies the user's latest version to their current preferences
+func (r *PreferenceRepository) rollForward(ctx context.Context, userID uuid.UUID) error {
+	var latest domain.PreferenceVersion
+	err := r.versions.FindOne(ctx,
+		bson.M{"user_id": userID},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}),
+	).Decode(&latest)
+	if err != nil {
+		return fmt.Errorf("failed to get latest preference version: %w", err)
+	}
+	return r.applyVersion(ctx, &latest)
+}
+
+// GetVersion retrieves one version of the user's preferences
+func (r *PreferenceRepository) GetVersion(ctx context.Context, userID uuid.UUID, version int) (*domain.PreferenceVersion, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.findVersion(ctx, bson.M{"user_id": userID, "version": version})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*domain.PreferenceVersion), nil
+}
+
+// GetAsOf retrieves the version of the user's preferences that was in effect at the given time
+// ErrPreferenceVersionNotFound is returned if the user had no saved preferences yet
+func (r *PreferenceRepository) GetAsOf(ctx context.Context, userID uuid.UUID, at time.Time) (*domain.PreferenceVersion, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.findVersion(ctx,
+			bson.M{"user_id": userID, "created_at": bson.M{"$lte": at}},
+			options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*domain.PreferenceVersion), nil
+}
+
+func (r *PreferenceRepository) findVersion(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*domain.PreferenceVersion, error) {
+	var version domain.PreferenceVersion
+	err := r.versions.FindOne(ctx, filter, opts...).Decode(&version)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPreferenceVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to get preference version: %w", err)
+	}
+	if version.Preference != nil {
+		if err := normalizeCustomSettings(version.Preference); err != nil {
+			return nil, err
+		}
+	}
+
+	return &version, nil
+}
+
+// ListVersions returns the user's most recent preference versions, newest first
+// Snapshots are left out; fetch a version with GetVersion to see its settings
+func (r *PreferenceRepository) ListVersions(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.PreferenceVersion, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listVersions(ctx, userID, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.PreferenceVersion), nil
+}
+
+func (r *PreferenceRepository) listVersions(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.PreferenceVersion, error) {
+	cursor, err := r.versions.Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().
+			SetSort(bson.D{{Key: "version", Value: -1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.M{"preference": 0}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list preference versions: %w", err)
+	}
+
+	versions := []*domain.PreferenceVersion{}
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, fmt.Errorf("failed to decode preference versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// DeleteByUserID removes the user's preferences and every saved version
+// Versions go first, so an interrupted delete never leaves history the current document
+// could be rebuilt from; deleting again finishes the job
+func (r *PreferenceRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.deleteByUserID(ctx, userID)
+	})
+	return err
+}
+
+func (r *PreferenceRepository) deleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.versions.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete preference versions: %w", err)
+	}
+	if _, err := r.coll.DeleteOne(ctx, bson.M{"user_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete preferences: %w", err)
+	}
+	return nil
+}
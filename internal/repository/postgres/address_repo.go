@@ -20,6 +20,7 @@ import (
 // Address repository errors
 var (
 	ErrAddressNotFound = errors.New("address not found")
+	ErrAddressChanged  = errors.New("address changed since it was read")
 )
 
 // AddressRepository handles address persistence in PostgreSQL
@@ -54,6 +55,8 @@ func (r *AddressRepository) listByUserID(ctx context.Context, userID uuid.UUID)
 		SELECT 
 			id, user_id, address_type, address_encrypted,
 			is_primary, validation_status, validation_source, validated_at,
+			valid_from, valid_until, replaces_address_id,
+			pending_change_reasons, pending_change_requested_at, pending_change_expires_at,
 			version, encryption_key_version, created_at, updated_at, deleted_at
 		FROM addresses
 		WHERE user_id = $1 AND deleted_at IS NULL
@@ -93,6 +96,8 @@ func (r *AddressRepository) getByID(ctx context.Context, userID, addressID uuid.
 		SELECT 
 			id, user_id, address_type, address_encrypted,
 			is_primary, validation_status, validation_source, validated_at,
+			valid_from, valid_until, replaces_address_id,
+			pending_change_reasons, pending_change_requested_at, pending_change_expires_at,
 			version, encryption_key_version, created_at, updated_at, deleted_at
 		FROM addresses
 		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
@@ -108,100 +113,150 @@ func (r *AddressRepository) getByID(ctx context.Context, userID, addressID uuid.
 	return addr, nil
 }
 
-// Create creates a new address
-func (r *AddressRepository) Create(ctx context.Context, addr *domain.Address) error {
+// Create creates a new address and records its first history version
+func (r *AddressRepository) Create(ctx context.Context, addr *domain.Address, changedBy, changeSource string) error {
 	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
-		return nil, r.create(ctx, addr)
+		return nil, r.create(ctx, addr, changedBy, changeSource)
 	})
 	return err
 }
 
-func (r *AddressRepository) create(ctx context.Context, addr *domain.Address) error {
-	// Encrypt address data
-	addressData := addr.ToAddressData()
-	dataJSON, err := json.Marshal(addressData)
+func (r *AddressRepository) create(ctx context.Context, addr *domain.Address, changedBy, changeSource string) error {
+	addressEnc, err := r.encryptAddress(addr)
 	if err != nil {
-		return fmt.Errorf("failed to marshal address data: %w", err)
+		return err
 	}
-
-	addressEnc, err := r.encryptor.EncryptString(string(dataJSON))
+	rawEnc, err := r.encryptRawInput(addr)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt address: %w", err)
+		return err
 	}
 
 	if addr.ID == uuid.Nil {
 		addr.ID = uuid.New()
 	}
 
-	// If setting as primary, unset other primaries first
-	if addr.IsPrimary {
-		_, err := r.pool.Exec(ctx, 
-			"UPDATE addresses SET is_primary = false WHERE user_id = $1 AND deleted_at IS NULL",
-			addr.UserID)
-		if err != nil {
-			return fmt.Errorf("failed to unset primary addresses: %w", err)
+	// Serializable so a concurrent primary flip for the same user retries instead of
+	// tripping idx_addresses_unique_primary
+	return withTx(ctx, r.pool, serializableTx, func(tx pgx.Tx) error {
+		// If setting as primary, unset other primaries first
+		var replaces *uuid.UUID
+		if addr.IsPrimary {
+			displaced, err := r.unsetPrimary(ctx, tx, addr.UserID, addr.ID, changedBy, changeSource)
+			if err != nil {
+				return err
+			}
+			if addr.IsTemporary() {
+				replaces = displaced
+			}
 		}
-	}
 
-	query := `
-		INSERT INTO addresses (
-			id, user_id, address_type, address_encrypted,
-			is_primary, validation_status, version, encryption_key_version,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING created_at, updated_at`
+		query := `
+			INSERT INTO addresses (
+				id, user_id, address_type, address_encrypted,
+				is_primary, validation_status, version, encryption_key_version,
+				valid_from, valid_until, replaces_address_id,
+				created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING created_at, updated_at`
+
+		now := time.Now().UTC()
+		err := tx.QueryRow(ctx, query,
+			addr.ID,
+			addr.UserID,
+			addr.AddressType,
+			addressEnc,
+			addr.IsPrimary,
+			domain.ValidationStatusPending,
+			1, // Initial version
+			r.encryptor.CurrentKeyVersion(),
+			addr.ValidFrom,
+			addr.ValidUntil,
+			replaces,
+			now,
+			now,
+		).Scan(&addr.CreatedAt, &addr.UpdatedAt)
 
-	now := time.Now().UTC()
-	err = r.pool.QueryRow(ctx, query,
-		addr.ID,
-		addr.UserID,
-		addr.AddressType,
-		addressEnc,
-		addr.IsPrimary,
-		domain.ValidationStatusPending,
-		1, // Initial version
-		r.encryptor.CurrentKeyVersion(),
-		now,
-		now,
-	).Scan(&addr.CreatedAt, &addr.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert address: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("failed to insert address: %w", err)
-	}
+		if err := r.insertHistory(ctx, tx, addr.ID, addr.UserID, addressEnc, rawEnc, 1, changedBy, changeSource, domain.AddressChangeCreate); err != nil {
+			return err
+		}
 
-	addr.Version = 1
-	addr.ValidationStatus = domain.ValidationStatusPending
-	return nil
+		addr.Version = 1
+		addr.ValidationStatus = domain.ValidationStatusPending
+		addr.ReplacesAddressID = replaces
+		return nil
+	})
 }
 
 // Update updates an address (creates new version)
-func (r *AddressRepository) Update(ctx context.Context, addr *domain.Address) error {
+func (r *AddressRepository) Update(ctx context.Context, addr *domain.Address, changedBy, changeSource string) error {
 	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
-		return nil, r.update(ctx, addr)
+		return nil, r.update(ctx, addr, changedBy, changeSource)
 	})
 	return err
 }
 
-func (r *AddressRepository) update(ctx context.Context, addr *domain.Address) error {
-	// Encrypt address data
-	addressData := addr.ToAddressData()
-	dataJSON, err := json.Marshal(addressData)
+func (r *AddressRepository) update(ctx context.Context, addr *domain.Address, changedBy, changeSource string) error {
+	var res addressUpdate
+	err := withTx(ctx, r.pool, serializableTx, func(tx pgx.Tx) error {
+		var err error
+		res, err = r.updateTx(ctx, tx, addr, changedBy, changeSource)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal address data: %w", err)
+		return err
 	}
 
-	addressEnc, err := r.encryptor.EncryptString(string(dataJSON))
+	res.applyTo(addr)
+	return nil
+}
+
+// addressUpdate holds what the database assigned when versioning an address
+// It is applied to the address only once the transaction commits, so retries start clean
+type addressUpdate struct {
+	version   int
+	updatedAt time.Time
+	replaces  *uuid.UUID
+}
+
+func (u addressUpdate) applyTo(addr *domain.Address) {
+	addr.Version = u.version
+	addr.UpdatedAt = u.updatedAt
+	addr.ReplacesAddressID = u.replaces
+	addr.ChangeStatus = ""
+	addr.PendingChange = nil
+}
+
+// updateTx writes addr as a new version inside the caller's transaction
+// Any held change is discarded: it was proposed against the version being replaced
+func (r *AddressRepository) updateTx(ctx context.Context, tx pgx.Tx, addr *domain.Address, changedBy, changeSource string) (addressUpdate, error) {
+	var res addressUpdate
+
+	addressEnc, err := r.encryptAddress(addr)
+	if err != nil {
+		return res, err
+	}
+	rawEnc, err := r.encryptRawInput(addr)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt address: %w", err)
+		return res, err
+	}
+
+	// A temporary address keeps pointing at the primary it first displaced
+	if addr.IsTemporary() {
+		res.replaces = addr.ReplacesAddressID
 	}
 
 	// If setting as primary, unset other primaries
 	if addr.IsPrimary {
-		_, err := r.pool.Exec(ctx, 
-			"UPDATE addresses SET is_primary = false WHERE user_id = $1 AND id != $2 AND deleted_at IS NULL",
-			addr.UserID, addr.ID)
+		displaced, err := r.unsetPrimary(ctx, tx, addr.UserID, addr.ID, changedBy, changeSource)
 		if err != nil {
-			return fmt.Errorf("failed to unset primary addresses: %w", err)
+			return res, err
+		}
+		if addr.IsTemporary() && res.replaces == nil {
+			res.replaces = displaced
 		}
 	}
 
@@ -212,66 +267,636 @@ func (r *AddressRepository) update(ctx context.Context, addr *domain.Address) er
 			is_primary = $3,
 			version = version + 1,
 			encryption_key_version = $4,
+			validation_status = $7,
+			validation_source = NULLIF($8, ''),
+			validated_at = $9,
+			valid_from = $10,
+			valid_until = $11,
+			replaces_address_id = $12,
+			pending_change_encrypted = NULL,
+			pending_change_token_hash = NULL,
+			pending_change_reasons = NULL,
+			pending_change_requested_at = NULL,
+			pending_change_expires_at = NULL,
 			updated_at = NOW()
 		WHERE id = $5 AND user_id = $6 AND deleted_at IS NULL
 		RETURNING version, updated_at`
 
-	var newVersion int
-	var newUpdatedAt time.Time
-	err = r.pool.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		addr.AddressType,
 		addressEnc,
 		addr.IsPrimary,
 		r.encryptor.CurrentKeyVersion(),
 		addr.ID,
 		addr.UserID,
-	).Scan(&newVersion, &newUpdatedAt)
+		addr.ValidationStatus,
+		addr.ValidationSource,
+		addr.ValidatedAt,
+		addr.ValidFrom,
+		addr.ValidUntil,
+		res.replaces,
+	).Scan(&res.version, &res.updatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrAddressNotFound
+		}
+		return res, fmt.Errorf("failed to update address: %w", err)
+	}
+
+	if err := r.insertHistory(ctx, tx, addr.ID, addr.UserID, addressEnc, rawEnc, res.version, changedBy, changeSource, domain.AddressChangeUpdate); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// SetPendingChange parks an edit on the address until it is confirmed with token
+// Only the token's hash is stored, and a newer hold replaces an older one. ErrAddressChanged
+// is returned if the address was edited or deleted since it was read
+func (r *AddressRepository) SetPendingChange(ctx context.Context, addr *domain.Address, change *domain.PendingAddressChange, reasons []string, token string, expiresAt time.Time) (time.Time, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.setPendingChange(ctx, addr, change, reasons, token, expiresAt)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return result.(time.Time), nil
+}
+
+func (r *AddressRepository) setPendingChange(ctx context.Context, addr *domain.Address, change *domain.PendingAddressChange, reasons []string, token string, expiresAt time.Time) (time.Time, error) {
+	changeJSON, err := json.Marshal(change)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to marshal pending address change: %w", err)
+	}
+	changeEnc, err := r.encryptor.EncryptString(string(changeJSON))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to encrypt pending address change: %w", err)
+	}
 
+	var requestedAt time.Time
+	err = r.pool.QueryRow(ctx, `
+		UPDATE addresses SET
+			pending_change_encrypted = $4,
+			pending_change_token_hash = $5,
+			pending_change_reasons = $6,
+			pending_change_requested_at = NOW(),
+			pending_change_expires_at = $7
+		WHERE id = $1 AND user_id = $2 AND version = $3 AND deleted_at IS NULL
+		RETURNING pending_change_requested_at`,
+		addr.ID, addr.UserID, addr.Version,
+		changeEnc, r.encryptor.Hash(token), reasons, expiresAt,
+	).Scan(&requestedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrAddressNotFound
+			return time.Time{}, ErrAddressChanged
+		}
+		return time.Time{}, fmt.Errorf("failed to hold address change: %w", err)
+	}
+
+	return requestedAt, nil
+}
+
+// ConfirmPendingChange applies the edit held on the address if token matches and has not
+// expired, versioning it like any other update. It returns the updated address and the
+// applied change; ErrInvalidToken covers a wrong, expired or already used token
+func (r *AddressRepository) ConfirmPendingChange(ctx context.Context, userID, addressID uuid.UUID, token, changedBy, changeSource string) (*domain.Address, *domain.PendingAddressChange, error) {
+	type confirmed struct {
+		addr   *domain.Address
+		change *domain.PendingAddressChange
+	}
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		addr, change, err := r.confirmPendingChange(ctx, userID, addressID, token, changedBy, changeSource)
+		return confirmed{addr, change}, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	c := result.(confirmed)
+	return c.addr, c.change, nil
+}
+
+func (r *AddressRepository) confirmPendingChange(ctx context.Context, userID, addressID uuid.UUID, token, changedBy, changeSource string) (*domain.Address, *domain.PendingAddressChange, error) {
+	var addr *domain.Address
+	var change *domain.PendingAddressChange
+	var res addressUpdate
+	err := withTx(ctx, r.pool, serializableTx, func(tx pgx.Tx) error {
+		var changeEnc string
+		err := tx.QueryRow(ctx, `
+			SELECT pending_change_encrypted FROM addresses
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+				AND pending_change_encrypted IS NOT NULL
+				AND pending_change_token_hash = $3
+				AND pending_change_expires_at > NOW()
+			FOR UPDATE`,
+			addressID, userID, r.encryptor.Hash(token),
+		).Scan(&changeEnc)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvalidToken
+			}
+			return fmt.Errorf("failed to read pending address change: %w", err)
+		}
+
+		decrypted, _, err := r.encryptor.DecryptString(changeEnc)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt pending address change: %w", err)
+		}
+		change = &domain.PendingAddressChange{}
+		if err := json.Unmarshal([]byte(decrypted), change); err != nil {
+			return fmt.Errorf("failed to unmarshal pending address change: %w", err)
 		}
-		return fmt.Errorf("failed to update address: %w", err)
+
+		addr, err = r.scanAddress(tx.QueryRow(ctx, `
+			SELECT
+				id, user_id, address_type, address_encrypted,
+				is_primary, validation_status, validation_source, validated_at,
+				valid_from, valid_until, replaces_address_id,
+				pending_change_reasons, pending_change_requested_at, pending_change_expires_at,
+				version, encryption_key_version, created_at, updated_at, deleted_at
+			FROM addresses
+			WHERE id = $1`, addressID))
+		if err != nil {
+			return fmt.Errorf("failed to read address: %w", err)
+		}
+		addr.ApplyPendingChange(change)
+
+		res, err = r.updateTx(ctx, tx, addr, changedBy, changeSource)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res.applyTo(addr)
+	return addr, change, nil
+}
+
+// CountRecentChanges counts the addresses the user created or edited since the given time
+// System and admin changes are not counted
+func (r *AddressRepository) CountRecentChanges(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.countRecentChanges(ctx, userID, since)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int), nil
+}
+
+func (r *AddressRepository) countRecentChanges(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM address_history
+		WHERE user_id = $1 AND created_at >= $2
+			AND change_source = $3 AND change_type IN ($4, $5)`,
+		userID, since, domain.AddressChangeSourceUser, domain.AddressChangeCreate, domain.AddressChangeUpdate,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recent address changes: %w", err)
+	}
+	return count, nil
+}
+
+// unsetPrimary clears the user's primary flag on every address except exceptID
+// Each demoted address gets a new version and history entry, so concurrent editors of it
+// see a conflict and the trail shows the flip. It returns the address that was primary, if any
+func (r *AddressRepository) unsetPrimary(ctx context.Context, tx pgx.Tx, userID, exceptID uuid.UUID, changedBy, changeSource string) (*uuid.UUID, error) {
+	type demoted struct {
+		id         uuid.UUID
+		addressEnc string
+		version    int
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE addresses SET
+			is_primary = false,
+			version = version + 1,
+			updated_at = NOW()
+		WHERE user_id = $1 AND id != $2 AND is_primary AND deleted_at IS NULL
+		RETURNING id, address_encrypted, version`,
+		userID, exceptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unset primary addresses: %w", err)
+	}
+
+	demotedRows, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (demoted, error) {
+		var d demoted
+		err := row.Scan(&d.id, &d.addressEnc, &d.version)
+		return d, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unset primary addresses: %w", err)
+	}
+	if len(demotedRows) == 0 {
+		return nil, nil
+	}
+
+	for _, d := range demotedRows {
+		if err := r.insertHistory(ctx, tx, d.id, userID, d.addressEnc, "", d.version, changedBy, changeSource, domain.AddressChangeUpdate); err != nil {
+			return nil, err
+		}
+	}
+	return &demotedRows[0].id, nil
+}
+
+// ListPendingValidation returns up to limit addresses awaiting validation, oldest first
+func (r *AddressRepository) ListPendingValidation(ctx context.Context, limit int) ([]*domain.Address, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listPendingValidation(ctx, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.Address), nil
+}
+
+func (r *AddressRepository) listPendingValidation(ctx context.Context, limit int) ([]*domain.Address, error) {
+	query := `
+		SELECT
+			id, user_id, address_type, address_encrypted,
+			is_primary, validation_status, validation_source, validated_at,
+			valid_from, valid_until, replaces_address_id,
+			pending_change_reasons, pending_change_requested_at, pending_change_expires_at,
+			version, encryption_key_version, created_at, updated_at, deleted_at
+		FROM addresses
+		WHERE validation_status = 'PENDING' AND deleted_at IS NULL
+		ORDER BY created_at
+		LIMIT $1`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses pending validation: %w", err)
+	}
+	defer rows.Close()
+
+	var addresses []*domain.Address
+	for rows.Next() {
+		addr, err := r.scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, addr)
+	}
+
+	return addresses, rows.Err()
+}
+
+// SetValidation records a validation outcome for the given address version
+// Validation does not create a version; if the address was edited or deleted since it
+// was read, ErrAddressChanged is returned and the newer content awaits its own validation
+func (r *AddressRepository) SetValidation(ctx context.Context, addressID uuid.UUID, version int, status domain.ValidationStatus, source string) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.setValidation(ctx, addressID, version, status, source)
+	})
+	return err
+}
+
+func (r *AddressRepository) setValidation(ctx context.Context, addressID uuid.UUID, version int, status domain.ValidationStatus, source string) error {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE addresses SET
+			validation_status = $3,
+			validation_source = $4,
+			validated_at = NOW()
+		WHERE id = $1 AND version = $2 AND deleted_at IS NULL`,
+		addressID, version, status, source,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set address validation: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrAddressChanged
 	}
 
-	addr.Version = newVersion
-	addr.UpdatedAt = newUpdatedAt
 	return nil
 }
 
+// TemporaryExpiry reports what expiring a temporary address changed
+type TemporaryExpiry struct {
+	ExpiredAt           time.Time
+	Version             int        // Version recording the expiry
+	RestoredAddressID   *uuid.UUID // Address made primary again; nil if none was
+	RestoredAddressType domain.AddressType
+	RestoredVersion     int
+}
+
+// ListExpiredTemporary returns up to limit live temporary addresses whose window closed by now
+func (r *AddressRepository) ListExpiredTemporary(ctx context.Context, now time.Time, limit int) ([]*domain.Address, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listExpiredTemporary(ctx, now, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.Address), nil
+}
+
+func (r *AddressRepository) listExpiredTemporary(ctx context.Context, now time.Time, limit int) ([]*domain.Address, error) {
+	query := `
+		SELECT
+			id, user_id, address_type, address_encrypted,
+			is_primary, validation_status, validation_source, validated_at,
+			valid_from, valid_until, replaces_address_id,
+			pending_change_reasons, pending_change_requested_at, pending_change_expires_at,
+			version, encryption_key_version, created_at, updated_at, deleted_at
+		FROM addresses
+		WHERE address_type = 'TEMPORARY' AND valid_until <= $1 AND deleted_at IS NULL
+		ORDER BY valid_until
+		LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired temporary addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var addresses []*domain.Address
+	for rows.Next() {
+		addr, err := r.scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, addr)
+	}
+
+	return addresses, rows.Err()
+}
+
+// ExpireTemporary soft-deletes an expired temporary address and, if it was the primary,
+// hands primary status back to the address it displaced or else the latest MAILING address
+// Both steps are versioned in history as SYSTEM changes. ErrAddressChanged is returned if
+// the window was extended or the address deleted since it was listed
+func (r *AddressRepository) ExpireTemporary(ctx context.Context, addr *domain.Address, now time.Time, changedBy string) (*TemporaryExpiry, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.expireTemporary(ctx, addr, now, changedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*TemporaryExpiry), nil
+}
+
+func (r *AddressRepository) expireTemporary(ctx context.Context, addr *domain.Address, now time.Time, changedBy string) (*TemporaryExpiry, error) {
+	res := &TemporaryExpiry{}
+	err := withTx(ctx, r.pool, serializableTx, func(tx pgx.Tx) error {
+		*res = TemporaryExpiry{}
+
+		var addressEnc string
+		var wasPrimary bool
+		var replacesID *uuid.UUID
+		err := tx.QueryRow(ctx, `
+			UPDATE addresses SET
+				deleted_at = NOW(),
+				version = version + 1,
+				updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+				AND address_type = 'TEMPORARY' AND valid_until <= $3 AND deleted_at IS NULL
+			RETURNING address_encrypted, version, is_primary, replaces_address_id, deleted_at`,
+			addr.ID, addr.UserID, now,
+		).Scan(&addressEnc, &res.Version, &wasPrimary, &replacesID, &res.ExpiredAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAddressChanged
+			}
+			return fmt.Errorf("failed to expire temporary address: %w", err)
+		}
+
+		if err := r.insertHistory(ctx, tx, addr.ID, addr.UserID, addressEnc, "", res.Version, changedBy, domain.AddressChangeSourceSystem, domain.AddressChangeDelete); err != nil {
+			return err
+		}
+
+		if !wasPrimary {
+			return nil
+		}
+
+		var restoredID uuid.UUID
+		var restoredEnc string
+		err = tx.QueryRow(ctx, `
+			UPDATE addresses SET
+				is_primary = true,
+				version = version + 1,
+				updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM addresses
+				WHERE user_id = $1 AND deleted_at IS NULL
+					AND (id = $2 OR address_type = 'MAILING')
+				ORDER BY (id = $2) IS TRUE DESC, updated_at DESC
+				LIMIT 1
+			)
+			RETURNING id, address_type, address_encrypted, version`,
+			addr.UserID, replacesID,
+		).Scan(&restoredID, &res.RestoredAddressType, &restoredEnc, &res.RestoredVersion)
+		if err != nil {
+			// Nothing left to fall back to; the user simply has no primary address
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("failed to restore primary address: %w", err)
+		}
+		res.RestoredAddressID = &restoredID
+
+		return r.insertHistory(ctx, tx, restoredID, addr.UserID, restoredEnc, "", res.RestoredVersion, changedBy, domain.AddressChangeSourceSystem, domain.AddressChangeUpdate)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // SoftDelete marks an address as deleted
-func (r *AddressRepository) SoftDelete(ctx context.Context, userID, addressID uuid.UUID) error {
+// The deletion is itself a version so the history shows what was removed and by whom
+func (r *AddressRepository) SoftDelete(ctx context.Context, userID, addressID uuid.UUID, changedBy, changeSource string) error {
 	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
-		return nil, r.softDelete(ctx, userID, addressID)
+		return nil, r.softDelete(ctx, userID, addressID, changedBy, changeSource)
 	})
 	return err
 }
 
-func (r *AddressRepository) softDelete(ctx context.Context, userID, addressID uuid.UUID) error {
-	query := `
-		UPDATE addresses SET
-			deleted_at = NOW(),
-			updated_at = NOW()
-		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+func (r *AddressRepository) softDelete(ctx context.Context, userID, addressID uuid.UUID, changedBy, changeSource string) error {
+	return withTx(ctx, r.pool, serializableTx, func(tx pgx.Tx) error {
+		query := `
+			UPDATE addresses SET
+				deleted_at = NOW(),
+				version = version + 1,
+				updated_at = NOW()
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			RETURNING address_encrypted, version`
+
+		var addressEnc string
+		var newVersion int
+		err := tx.QueryRow(ctx, query, addressID, userID).Scan(&addressEnc, &newVersion)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAddressNotFound
+			}
+			return fmt.Errorf("failed to soft delete address: %w", err)
+		}
+
+		return r.insertHistory(ctx, tx, addressID, userID, addressEnc, "", newVersion, changedBy, changeSource, domain.AddressChangeDelete)
+	})
+}
+
+// encryptAddress serializes and encrypts the address components
+func (r *AddressRepository) encryptAddress(addr *domain.Address) (string, error) {
+	return r.encryptAddressData(addr.ToAddressData())
+}
 
-	result, err := r.pool.Exec(ctx, query, addressID, userID)
+// encryptRawInput encrypts the input as typed, if the service supplied it
+func (r *AddressRepository) encryptRawInput(addr *domain.Address) (string, error) {
+	if addr.RawInput == nil {
+		return "", nil
+	}
+	return r.encryptAddressData(addr.RawInput)
+}
+
+func (r *AddressRepository) encryptAddressData(data *domain.AddressData) (string, error) {
+	dataJSON, err := json.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("failed to soft delete address: %w", err)
+		return "", fmt.Errorf("failed to marshal address data: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return ErrAddressNotFound
+	addressEnc, err := r.encryptor.EncryptString(string(dataJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt address: %w", err)
 	}
 
+	return addressEnc, nil
+}
+
+// insertHistory records an address version inside the caller's transaction
+// rawEnc is the encrypted input as typed; empty when there was none, e.g. for deletions
+func (r *AddressRepository) insertHistory(ctx context.Context, tx pgx.Tx, addressID, userID uuid.UUID, addressEnc, rawEnc string, version int, changedBy, changeSource, changeType string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO address_history (
+			address_id, user_id, address_encrypted, raw_input_encrypted, version,
+			changed_by, change_source, change_type, encryption_key_version
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9)`,
+		addressID, userID, addressEnc, rawEnc, version,
+		changedBy, changeSource, changeType, r.encryptor.CurrentKeyVersion(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record address history: %w", err)
+	}
 	return nil
 }
 
+// ListHistoryByAddressID retrieves every version of one of the user's addresses, newest first
+// Deleted addresses keep their history; ErrAddressNotFound means the user never owned it
+func (r *AddressRepository) ListHistoryByAddressID(ctx context.Context, userID, addressID uuid.UUID) ([]*domain.AddressHistory, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listHistory(ctx, "WHERE user_id = $1 AND address_id = $2", userID, addressID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	history := result.([]*domain.AddressHistory)
+	if len(history) == 0 {
+		return nil, ErrAddressNotFound
+	}
+	return history, nil
+}
+
+// ListHistoryByUserID retrieves every recorded address version for a user, newest first
+func (r *AddressRepository) ListHistoryByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.AddressHistory, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listHistoryByUserID(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.AddressHistory), nil
+}
+
+func (r *AddressRepository) listHistoryByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.AddressHistory, error) {
+	return r.listHistory(ctx, "WHERE user_id = $1", userID)
+}
+
+func (r *AddressRepository) listHistory(ctx context.Context, where string, args ...any) ([]*domain.AddressHistory, error) {
+	query := `
+		SELECT id, address_id, user_id, address_encrypted, raw_input_encrypted, version,
+			changed_by, change_source, change_type, created_at
+		FROM address_history
+		` + where + `
+		ORDER BY created_at DESC, version DESC`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list address history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*domain.AddressHistory
+	for rows.Next() {
+		entry, err := r.scanAddressHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
+
+// scanAddressHistory scans an address_history row and decrypts the snapshot
+func (r *AddressRepository) scanAddressHistory(row pgx.Row) (*domain.AddressHistory, error) {
+	var entry domain.AddressHistory
+	var addressEnc, rawEnc sql.NullString
+
+	err := row.Scan(
+		&entry.ID,
+		&entry.AddressID,
+		&entry.UserID,
+		&addressEnc,
+		&rawEnc,
+		&entry.Version,
+		&entry.ChangedBy,
+		&entry.ChangeSource,
+		&entry.ChangeType,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshots are NULL once the erasure job has run
+	if addressEnc.Valid {
+		if entry.Address, err = r.decryptAddressData(addressEnc.String); err != nil {
+			return nil, fmt.Errorf("failed to read address history: %w", err)
+		}
+	}
+	if rawEnc.Valid {
+		if entry.RawInput, err = r.decryptAddressData(rawEnc.String); err != nil {
+			return nil, fmt.Errorf("failed to read address history input: %w", err)
+		}
+	}
+
+	return &entry, nil
+}
+
+func (r *AddressRepository) decryptAddressData(enc string) (*domain.AddressData, error) {
+	decrypted, _, err := r.encryptor.DecryptString(enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt address: %w", err)
+	}
+
+	var data domain.AddressData
+	if err := json.Unmarshal([]byte(decrypted), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal address data: %w", err)
+	}
+	return &data, nil
+}
+
 // scanAddress scans a row into an Address struct and decrypts data
 func (r *AddressRepository) scanAddress(row pgx.Row) (*domain.Address, error) {
 	var addr domain.Address
 	var addressEnc string
 	var validationSource sql.NullString
 	var validatedAt sql.NullTime
+	var validFrom, validUntil sql.NullTime
+	var replacesID *uuid.UUID
+	var holdReasons []string
+	var holdRequestedAt, holdExpiresAt sql.NullTime
 	var deletedAt sql.NullTime
 
 	err := row.Scan(
@@ -283,6 +908,12 @@ func (r *AddressRepository) scanAddress(row pgx.Row) (*domain.Address, error) {
 		&addr.ValidationStatus,
 		&validationSource,
 		&validatedAt,
+		&validFrom,
+		&validUntil,
+		&replacesID,
+		&holdReasons,
+		&holdRequestedAt,
+		&holdExpiresAt,
 		&addr.Version,
 		&addr.EncryptionKeyVersion,
 		&addr.CreatedAt,
@@ -312,6 +943,22 @@ func (r *AddressRepository) scanAddress(row pgx.Row) (*domain.Address, error) {
 	if validatedAt.Valid {
 		addr.ValidatedAt = &validatedAt.Time
 	}
+	if validFrom.Valid {
+		addr.ValidFrom = &validFrom.Time
+	}
+	if validUntil.Valid {
+		addr.ValidUntil = &validUntil.Time
+	}
+	addr.ReplacesAddressID = replacesID
+	// An unconfirmed hold lapses silently; the columns are overwritten by the next edit
+	if holdExpiresAt.Valid && holdExpiresAt.Time.After(time.Now()) {
+		addr.ChangeStatus = domain.AddressChangePendingConfirmation
+		addr.PendingChange = &domain.AddressHold{
+			Reasons:     holdReasons,
+			RequestedAt: holdRequestedAt.Time,
+			ExpiresAt:   holdExpiresAt.Time,
+		}
+	}
 	if deletedAt.Valid {
 		addr.DeletedAt = &deletedAt.Time
 	}
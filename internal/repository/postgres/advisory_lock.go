@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLocker runs work under a Postgres session advisory lock so that only one
+// replica performs it at a time
+type AdvisoryLocker struct {
+	pool *pgxpool.Pool
+}
+
+// NewAdvisoryLocker creates a new advisory locker
+func NewAdvisoryLocker(pool *pgxpool.Pool) *AdvisoryLocker {
+	return &AdvisoryLocker{pool: pool}
+}
+
+// TryWithLock runs fn while holding the advisory lock for name
+// It reports false without running fn if another session holds the lock
+func (l *AdvisoryLocker) TryWithLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	// Session locks belong to a connection, so hold one for the whole run
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection for advisory lock: %w", err)
+	}
+	defer conn.Release()
+
+	key := advisoryLockKey(name)
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	defer func() {
+		// The lock must be released even if fn's context was cancelled
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", key); err != nil {
+			// A connection that cannot unlock must not return to the pool still holding the lock
+			conn.Conn().Close(ctx)
+		}
+	}()
+
+	return true, fn(ctx)
+}
+
+// advisoryLockKey maps a lock name to the bigint key space of pg advisory locks
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("user-service:" + name))
+	return int64(h.Sum64())
+}
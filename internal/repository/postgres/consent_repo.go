@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/banking/user-service/internal/crypto"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// ConsentRepository handles the consent ledger in PostgreSQL
+// Events are only ever inserted; the current consent is derived from the latest one
+type ConsentRepository struct {
+	pool      *pgxpool.Pool
+	encryptor *crypto.FieldEncryptor
+	cb        *resilience.CircuitBreaker
+}
+
+// NewConsentRepository creates a new consent repository
+func NewConsentRepository(pool *pgxpool.Pool, encryptor *crypto.FieldEncryptor, cb *resilience.CircuitBreaker) *ConsentRepository {
+	return &ConsentRepository{
+		pool:      pool,
+		encryptor: encryptor,
+		cb:        cb,
+	}
+}
+
+// consentEventColumns are scanned in the order of the ConsentEvent fields
+const consentEventColumns = `
+	id, user_id, purpose, action, wording_version, channel,
+	actor_id, COALESCE(ip_hash, ''), COALESCE(request_id, ''), confirms_event_id, created_at`
+
+// Record appends a grant or withdrawal to the ledger
+// A withdrawal also cancels any outstanding double opt-in link for the purpose
+func (r *ConsentRepository) Record(ctx context.Context, event *domain.ConsentEvent) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+			if event.Action == domain.ConsentActionWithdrawn {
+				if _, err := tx.Exec(ctx,
+					"DELETE FROM consent_confirmations WHERE user_id = $1 AND purpose = $2",
+					event.UserID, event.Purpose,
+				); err != nil {
+					return fmt.Errorf("failed to cancel consent confirmation: %w", err)
+				}
+			}
+			return r.insertEvent(ctx, tx, event)
+		})
+	})
+	return err
+}
+
+// RequestConfirmation records the start of a double opt-in and stores the hash of the
+// token that confirms it, replacing any earlier link for the purpose
+func (r *ConsentRepository) RequestConfirmation(ctx context.Context, event *domain.ConsentEvent, token string, expiresAt time.Time) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+			if err := r.insertEvent(ctx, tx, event); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `
+				INSERT INTO consent_confirmations (user_id, purpose, request_event_id, token_hash, expires_at)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (user_id, purpose) DO UPDATE SET
+					request_event_id = EXCLUDED.request_event_id,
+					token_hash = EXCLUDED.token_hash,
+					expires_at = EXCLUDED.expires_at`,
+				event.UserID, event.Purpose, event.ID, r.encryptor.Hash(token), expiresAt,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to store consent confirmation: %w", err)
+			}
+			return nil
+		})
+	})
+	return err
+}
+
+// Confirm completes the double opt-in the token belongs to by recording the grant
+// The grant carries the wording version of the request it confirms. ErrInvalidToken
+// covers a wrong, expired or already used token
+func (r *ConsentRepository) Confirm(ctx context.Context, userID uuid.UUID, token string, grant *domain.ConsentEvent) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.confirm(ctx, userID, token, grant)
+	})
+	return err
+}
+
+func (r *ConsentRepository) confirm(ctx context.Context, userID uuid.UUID, token string, grant *domain.ConsentEvent) error {
+	return withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		var requestID uuid.UUID
+		err := tx.QueryRow(ctx, `
+			DELETE FROM consent_confirmations
+			WHERE user_id = $1 AND token_hash = $2 AND expires_at > NOW()
+			RETURNING purpose, request_event_id`,
+			userID, r.encryptor.Hash(token),
+		).Scan(&grant.Purpose, &requestID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvalidToken
+			}
+			return fmt.Errorf("failed to read consent confirmation: %w", err)
+		}
+
+		err = tx.QueryRow(ctx,
+			"SELECT wording_version FROM consent_events WHERE id = $1",
+			requestID,
+		).Scan(&grant.WordingVersion)
+		if err != nil {
+			return fmt.Errorf("failed to read consent request: %w", err)
+		}
+
+		grant.UserID = userID
+		grant.Action = domain.ConsentActionGranted
+		grant.ConfirmsEventID = &requestID
+		return r.insertEvent(ctx, tx, grant)
+	})
+}
+
+func (r *ConsentRepository) insertEvent(ctx context.Context, tx pgx.Tx, event *domain.ConsentEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	err := tx.QueryRow(ctx, `
+		INSERT INTO consent_events (
+			id, user_id, purpose, action, wording_version, channel,
+			actor_id, ip_hash, request_id, confirms_event_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10)
+		RETURNING created_at`,
+		event.ID, event.UserID, event.Purpose, event.Action, event.WordingVersion, event.Channel,
+		event.ActorID, event.IPHash, event.RequestID, event.ConfirmsEventID,
+	).Scan(&event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record consent event: %w", err)
+	}
+	return nil
+}
+
+// ListCurrent returns the consent in effect for every purpose
+func (r *ConsentRepository) ListCurrent(ctx context.Context, userID uuid.UUID) ([]*domain.ConsentRecord, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listCurrent(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.ConsentRecord), nil
+}
+
+func (r *ConsentRepository) listCurrent(ctx context.Context, userID uuid.UUID) ([]*domain.ConsentRecord, error) {
+	// Only a live link keeps a request pending
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT ON (e.purpose)
+			e.id, e.user_id, e.purpose, e.action, e.wording_version, e.channel,
+			e.actor_id, COALESCE(e.ip_hash, ''), COALESCE(e.request_id, ''), e.confirms_event_id, e.created_at,
+			c.expires_at
+		FROM consent_events e
+		LEFT JOIN consent_confirmations c
+			ON c.request_event_id = e.id AND c.expires_at > NOW()
+		WHERE e.user_id = $1
+		ORDER BY e.purpose, e.created_at DESC, e.id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consents: %w", err)
+	}
+	defer rows.Close()
+
+	latest := make(map[domain.ConsentPurpose]*domain.ConsentRecord)
+	for rows.Next() {
+		var event domain.ConsentEvent
+		var pendingExpiresAt *time.Time
+		if err := rows.Scan(
+			&event.ID, &event.UserID, &event.Purpose, &event.Action, &event.WordingVersion, &event.Channel,
+			&event.ActorID, &event.IPHash, &event.RequestID, &event.ConfirmsEventID, &event.CreatedAt,
+			&pendingExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan consent: %w", err)
+		}
+		latest[event.Purpose] = domain.NewConsentRecord(event.Purpose, &event, pendingExpiresAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list consents: %w", err)
+	}
+
+	records := make([]*domain.ConsentRecord, 0, len(domain.ConsentPurposes))
+	for _, purpose := range domain.ConsentPurposes {
+		record, ok := latest[purpose]
+		if !ok {
+			record = domain.NewConsentRecord(purpose, nil, nil)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ListEvents returns the user's whole consent ledger, oldest first
+func (r *ConsentRepository) ListEvents(ctx context.Context, userID uuid.UUID) ([]*domain.ConsentEvent, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listEvents(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.ConsentEvent), nil
+}
+
+func (r *ConsentRepository) listEvents(ctx context.Context, userID uuid.UUID) ([]*domain.ConsentEvent, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+consentEventColumns+`
+		FROM consent_events
+		WHERE user_id = $1
+		ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consent events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.ConsentEvent{}
+	for rows.Next() {
+		var event domain.ConsentEvent
+		if err := rows.Scan(
+			&event.ID, &event.UserID, &event.Purpose, &event.Action, &event.WordingVersion, &event.Channel,
+			&event.ActorID, &event.IPHash, &event.RequestID, &event.ConfirmsEventID, &event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan consent event: %w", err)
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list consent events: %w", err)
+	}
+
+	return events, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -20,6 +21,15 @@ var (
 	ErrDeviceNotFound = errors.New("device not found")
 )
 
+// clearPushToken drops a device's push token; every statement removing a device applies it
+// so a removed device can no longer be notified
+const clearPushToken = `
+			push_provider = NULL,
+			push_token_encrypted = NULL,
+			push_token_hash = NULL,
+			push_token_key_version = NULL,
+			push_token_updated_at = NULL`
+
 // DeviceRepository handles device persistence in PostgreSQL
 type DeviceRepository struct {
 	pool      *pgxpool.Pool
@@ -52,7 +62,8 @@ func (r *DeviceRepository) listByUserID(ctx context.Context, userID uuid.UUID) (
 		SELECT 
 			id, user_id, fingerprint_hash, device_type, os, os_version,
 			app_version, device_name, last_ip_hash, last_active_at,
-			is_trusted, trust_reason, created_at, deleted_at
+			is_trusted, trust_reason, trusted_until, suspicion_score, suspicion_reasons, scored_at,
+			created_at, deleted_at
 		FROM devices
 		WHERE user_id = $1 AND deleted_at IS NULL
 		ORDER BY last_active_at DESC NULLS LAST`
@@ -91,7 +102,8 @@ func (r *DeviceRepository) getByID(ctx context.Context, userID, deviceID uuid.UU
 		SELECT 
 			id, user_id, fingerprint_hash, device_type, os, os_version,
 			app_version, device_name, last_ip_hash, last_active_at,
-			is_trusted, trust_reason, created_at, deleted_at
+			is_trusted, trust_reason, trusted_until, suspicion_score, suspicion_reasons, scored_at,
+			created_at, deleted_at
 		FROM devices
 		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
 
@@ -106,6 +118,78 @@ func (r *DeviceRepository) getByID(ctx context.Context, userID, deviceID uuid.UU
 	return device, nil
 }
 
+// GetByFingerprint retrieves the user's device with the given fingerprint hash
+func (r *DeviceRepository) GetByFingerprint(ctx context.Context, userID uuid.UUID, fingerprintHash string) (*domain.Device, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.getByFingerprint(ctx, userID, fingerprintHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*domain.Device), nil
+}
+
+func (r *DeviceRepository) getByFingerprint(ctx context.Context, userID uuid.UUID, fingerprintHash string) (*domain.Device, error) {
+	query := `
+		SELECT
+			id, user_id, fingerprint_hash, device_type, os, os_version,
+			app_version, device_name, last_ip_hash, last_active_at,
+			is_trusted, trust_reason, trusted_until, suspicion_score, suspicion_reasons, scored_at,
+			created_at, deleted_at
+		FROM devices
+		WHERE user_id = $1 AND fingerprint_hash = $2 AND deleted_at IS NULL`
+
+	device, err := r.scanDevice(r.pool.QueryRow(ctx, query, userID, fingerprintHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// ListByFingerprint retrieves the active devices of every user with this fingerprint hash
+func (r *DeviceRepository) ListByFingerprint(ctx context.Context, fingerprintHash string) ([]*domain.Device, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listByFingerprint(ctx, fingerprintHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.Device), nil
+}
+
+func (r *DeviceRepository) listByFingerprint(ctx context.Context, fingerprintHash string) ([]*domain.Device, error) {
+	query := `
+		SELECT
+			id, user_id, fingerprint_hash, device_type, os, os_version,
+			app_version, device_name, last_ip_hash, last_active_at,
+			is_trusted, trust_reason, trusted_until, suspicion_score, suspicion_reasons, scored_at,
+			created_at, deleted_at
+		FROM devices
+		WHERE fingerprint_hash = $1 AND deleted_at IS NULL
+		ORDER BY created_at`
+
+	rows, err := r.pool.Query(ctx, query, fingerprintHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices by fingerprint: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*domain.Device
+	for rows.Next() {
+		device, err := r.scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
 // SoftDelete marks a device as deleted
 func (r *DeviceRepository) SoftDelete(ctx context.Context, userID, deviceID uuid.UUID) error {
 	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
@@ -117,7 +201,7 @@ func (r *DeviceRepository) SoftDelete(ctx context.Context, userID, deviceID uuid
 func (r *DeviceRepository) softDelete(ctx context.Context, userID, deviceID uuid.UUID) error {
 	query := `
 		UPDATE devices SET
-			deleted_at = NOW()
+			deleted_at = NOW(),` + clearPushToken + `
 		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
 
 	result, err := r.pool.Exec(ctx, query, deviceID, userID)
@@ -132,6 +216,670 @@ func (r *DeviceRepository) softDelete(ctx context.Context, userID, deviceID uuid
 	return nil
 }
 
+// Trust marks the user's device as trusted until trustedUntil
+func (r *DeviceRepository) Trust(ctx context.Context, userID, deviceID uuid.UUID, reason string, trustedUntil time.Time) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.trust(ctx, userID, deviceID, reason, trustedUntil)
+	})
+	return err
+}
+
+func (r *DeviceRepository) trust(ctx context.Context, userID, deviceID uuid.UUID, reason string, trustedUntil time.Time) error {
+	query := `
+		UPDATE devices SET
+			is_trusted = TRUE,
+			trust_reason = $3,
+			trusted_until = $4,
+			trust_changed_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, deviceID, userID, reason, trustedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to trust device: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+// Untrust revokes trust in the user's device
+// It reports whether the device was trusted; a lapsed trust counts as not trusted
+func (r *DeviceRepository) Untrust(ctx context.Context, userID, deviceID uuid.UUID, reason string) (bool, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.untrust(ctx, userID, deviceID, reason)
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+func (r *DeviceRepository) untrust(ctx context.Context, userID, deviceID uuid.UUID, reason string) (bool, error) {
+	var wasTrusted bool
+	err := r.pool.QueryRow(ctx, `
+		UPDATE devices SET
+			is_trusted = FALSE,
+			trust_reason = $3,
+			trusted_until = NULL,
+			trust_changed_at = NOW()
+		FROM (
+			SELECT id, is_trusted AND (trusted_until IS NULL OR trusted_until > NOW()) AS was_trusted
+			FROM devices
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			FOR UPDATE
+		) AS prev
+		WHERE devices.id = prev.id
+		RETURNING prev.was_trusted`,
+		deviceID, userID, reason,
+	).Scan(&wasTrusted)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrDeviceNotFound
+		}
+		return false, fmt.Errorf("failed to untrust device: %w", err)
+	}
+
+	return wasTrusted, nil
+}
+
+// UntrustAll revokes trust in every trusted device of the user and returns their IDs
+func (r *DeviceRepository) UntrustAll(ctx context.Context, userID uuid.UUID, reason string) ([]uuid.UUID, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.untrustAll(ctx, userID, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]uuid.UUID), nil
+}
+
+func (r *DeviceRepository) untrustAll(ctx context.Context, userID uuid.UUID, reason string) ([]uuid.UUID, error) {
+	// Lapsed trust is cleared too, but only devices that were still trusted are reported
+	rows, err := r.pool.Query(ctx, `
+		WITH prev AS (
+			SELECT id, trusted_until IS NULL OR trusted_until > NOW() AS was_trusted
+			FROM devices
+			WHERE user_id = $1 AND is_trusted AND deleted_at IS NULL
+			FOR UPDATE
+		)
+		UPDATE devices SET
+			is_trusted = FALSE,
+			trust_reason = $2,
+			trusted_until = NULL,
+			trust_changed_at = NOW()
+		FROM prev
+		WHERE devices.id = prev.id
+		RETURNING devices.id, prev.was_trusted`,
+		userID, reason,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to untrust devices: %w", err)
+	}
+
+	var ids []uuid.UUID
+	var id uuid.UUID
+	var wasTrusted bool
+	_, err = pgx.ForEachRow(rows, []any{&id, &wasTrusted}, func() error {
+		if wasTrusted {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to untrust devices: %w", err)
+	}
+	return ids, nil
+}
+
+// SoftDeleteAllExcept soft-deletes all of the user's devices other than the one with
+// keepFingerprintHash and returns the IDs removed
+func (r *DeviceRepository) SoftDeleteAllExcept(ctx context.Context, userID uuid.UUID, keepFingerprintHash string) ([]uuid.UUID, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.softDeleteAllExcept(ctx, userID, keepFingerprintHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]uuid.UUID), nil
+}
+
+func (r *DeviceRepository) softDeleteAllExcept(ctx context.Context, userID uuid.UUID, keepFingerprintHash string) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE devices SET
+			deleted_at = NOW(),`+clearPushToken+`
+		WHERE user_id = $1 AND fingerprint_hash != $2 AND deleted_at IS NULL
+		RETURNING id`,
+		userID, keepFingerprintHash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke devices: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke devices: %w", err)
+	}
+	return ids, nil
+}
+
+// DeviceUpsert reports the outcome of registering or touching a device
+type DeviceUpsert struct {
+	Device  *domain.Device
+	Created bool
+	Evicted []uuid.UUID // Devices removed to keep the user within the cap
+}
+
+// Register creates the user's device with this fingerprint, or refreshes its details and
+// activity if it is already registered. A new device beyond maxDevices evicts the user's
+// least recently active devices
+func (r *DeviceRepository) Register(ctx context.Context, device *domain.Device, maxDevices int) (*DeviceUpsert, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.register(ctx, device, maxDevices)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*DeviceUpsert), nil
+}
+
+func (r *DeviceRepository) register(ctx context.Context, device *domain.Device, maxDevices int) (*DeviceUpsert, error) {
+	res := &DeviceUpsert{}
+	// Serializable so concurrent registrations cannot both slip under the cap
+	err := withTx(ctx, r.pool, serializableTx, func(tx pgx.Tx) error {
+		*res = DeviceUpsert{}
+
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO devices (
+				user_id, fingerprint_hash, device_type, os, os_version,
+				app_version, device_name, last_ip_hash, last_active_at
+			) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, NOW())
+			ON CONFLICT (user_id, fingerprint_hash) WHERE deleted_at IS NULL DO UPDATE SET
+				device_type = EXCLUDED.device_type,
+				os = EXCLUDED.os,
+				os_version = EXCLUDED.os_version,
+				app_version = EXCLUDED.app_version,
+				device_name = COALESCE(EXCLUDED.device_name, devices.device_name),
+				last_ip_hash = EXCLUDED.last_ip_hash,
+				last_active_at = NOW()
+			RETURNING id, xmax = 0`,
+			device.UserID,
+			device.FingerprintHash,
+			device.DeviceType,
+			device.OS,
+			device.OSVersion,
+			device.AppVersion,
+			device.DeviceName,
+			device.LastIPHash,
+		).Scan(&id, &res.Created)
+		if err != nil {
+			return fmt.Errorf("failed to register device: %w", err)
+		}
+
+		return r.finishUpsert(ctx, tx, res, device.UserID, id, maxDevices)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Heartbeat records activity for the user's device with this fingerprint, registering it
+// with unknown type and OS if it is new. Activity within minInterval of the last recorded
+// heartbeat is not written again, and nil is returned for the device
+func (r *DeviceRepository) Heartbeat(ctx context.Context, userID uuid.UUID, fingerprintHash, ipHash string, minInterval time.Duration, maxDevices int) (*DeviceUpsert, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.heartbeat(ctx, userID, fingerprintHash, ipHash, minInterval, maxDevices)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*DeviceUpsert), nil
+}
+
+func (r *DeviceRepository) heartbeat(ctx context.Context, userID uuid.UUID, fingerprintHash, ipHash string, minInterval time.Duration, maxDevices int) (*DeviceUpsert, error) {
+	res := &DeviceUpsert{}
+	err := withTx(ctx, r.pool, serializableTx, func(tx pgx.Tx) error {
+		*res = DeviceUpsert{}
+
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO devices (user_id, fingerprint_hash, last_ip_hash, last_active_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (user_id, fingerprint_hash) WHERE deleted_at IS NULL DO UPDATE SET
+				last_ip_hash = EXCLUDED.last_ip_hash,
+				last_active_at = NOW()
+			WHERE devices.last_active_at IS NULL
+				OR devices.last_active_at <= NOW() - make_interval(secs => $4)
+				OR devices.last_ip_hash IS DISTINCT FROM EXCLUDED.last_ip_hash
+			RETURNING id, xmax = 0`,
+			userID, fingerprintHash, ipHash, minInterval.Seconds(),
+		).Scan(&id, &res.Created)
+		if err != nil {
+			// Seen recently from the same IP; nothing to record
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("failed to record device heartbeat: %w", err)
+		}
+
+		return r.finishUpsert(ctx, tx, res, userID, id, maxDevices)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// finishUpsert enforces the device cap after a new device was inserted and loads the device
+func (r *DeviceRepository) finishUpsert(ctx context.Context, tx pgx.Tx, res *DeviceUpsert, userID, deviceID uuid.UUID, maxDevices int) error {
+	if res.Created && maxDevices > 0 {
+		evicted, err := r.evictInactive(ctx, tx, userID, deviceID, maxDevices)
+		if err != nil {
+			return err
+		}
+		res.Evicted = evicted
+	}
+
+	device, err := r.scanDevice(tx.QueryRow(ctx, `
+		SELECT
+			id, user_id, fingerprint_hash, device_type, os, os_version,
+			app_version, device_name, last_ip_hash, last_active_at,
+			is_trusted, trust_reason, trusted_until, suspicion_score, suspicion_reasons, scored_at,
+			created_at, deleted_at
+		FROM devices
+		WHERE id = $1`, deviceID))
+	if err != nil {
+		return fmt.Errorf("failed to read device: %w", err)
+	}
+	res.Device = device
+	return nil
+}
+
+// evictInactive soft-deletes the user's devices beyond maxDevices, least recently active
+// first; keepID, the device just registered, always stays
+func (r *DeviceRepository) evictInactive(ctx context.Context, tx pgx.Tx, userID, keepID uuid.UUID, maxDevices int) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, `
+		UPDATE devices SET
+			deleted_at = NOW(),`+clearPushToken+`
+		WHERE id IN (
+			SELECT id FROM devices
+			WHERE user_id = $1 AND id != $2 AND deleted_at IS NULL
+			ORDER BY last_active_at DESC NULLS LAST, created_at DESC
+			OFFSET $3
+		)
+		RETURNING id`,
+		userID, keepID, maxDevices-1,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evict devices: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to evict devices: %w", err)
+	}
+	return ids, nil
+}
+
+// PruneInactive soft-deletes up to limit devices not active since before and clears their trust
+// Devices never seen active count from their registration. The pruned devices are returned;
+// those that were still trusted carry reason as their trust reason
+func (r *DeviceRepository) PruneInactive(ctx context.Context, before time.Time, reason string, limit int) ([]*domain.Device, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.pruneInactive(ctx, before, reason, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.Device), nil
+}
+
+func (r *DeviceRepository) pruneInactive(ctx context.Context, before time.Time, reason string, limit int) ([]*domain.Device, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE devices SET
+			deleted_at = NOW(),
+			is_trusted = FALSE,
+			trust_reason = CASE WHEN is_trusted AND (trusted_until IS NULL OR trusted_until > NOW())
+				THEN $2 ELSE trust_reason END,
+			trusted_until = NULL,
+			trust_changed_at = CASE WHEN is_trusted AND (trusted_until IS NULL OR trusted_until > NOW())
+				THEN NOW() ELSE trust_changed_at END,`+clearPushToken+`
+		WHERE id IN (
+			SELECT id FROM devices
+			WHERE deleted_at IS NULL AND COALESCE(last_active_at, created_at) < $1
+			ORDER BY COALESCE(last_active_at, created_at)
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING
+			id, user_id, fingerprint_hash, device_type, os, os_version,
+			app_version, device_name, last_ip_hash, last_active_at,
+			is_trusted, trust_reason, trusted_until, suspicion_score, suspicion_reasons, scored_at,
+			created_at, deleted_at`,
+		before, reason, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*domain.Device
+	for rows.Next() {
+		device, err := r.scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to prune devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// PushTokenOwner identifies a device a push token was registered to
+type PushTokenOwner struct {
+	UserID   uuid.UUID
+	DeviceID uuid.UUID
+}
+
+// PushTokenUpdate reports the outcome of setting a device's push token
+type PushTokenUpdate struct {
+	Changed   bool             // False when the device already had this token
+	Rotated   bool             // The device had a different token before
+	Displaced []PushTokenOwner // Other devices the token was taken from
+}
+
+// SetPushToken stores token, encrypted, as the push token of the user's device
+// A token identifies one app install, so it is taken from any other device holding it,
+// e.g. after another user signed in on the same phone
+func (r *DeviceRepository) SetPushToken(ctx context.Context, userID, deviceID uuid.UUID, provider domain.PushProvider, token string) (*PushTokenUpdate, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.setPushToken(ctx, userID, deviceID, provider, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*PushTokenUpdate), nil
+}
+
+func (r *DeviceRepository) setPushToken(ctx context.Context, userID, deviceID uuid.UUID, provider domain.PushProvider, token string) (*PushTokenUpdate, error) {
+	tokenHash := r.encryptor.Hash(token)
+	tokenEnc, err := r.encryptor.EncryptString(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt push token: %w", err)
+	}
+
+	var res *PushTokenUpdate
+	err = withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		res = &PushTokenUpdate{}
+
+		var prevHash, prevProvider sql.NullString
+		err := tx.QueryRow(ctx, `
+			SELECT push_token_hash, push_provider FROM devices
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			FOR UPDATE`,
+			deviceID, userID,
+		).Scan(&prevHash, &prevProvider)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrDeviceNotFound
+			}
+			return fmt.Errorf("failed to get push token: %w", err)
+		}
+
+		// Apps re-register their token on every launch
+		if prevHash.String == tokenHash && prevProvider.String == string(provider) {
+			return nil
+		}
+		res.Changed = true
+		res.Rotated = prevHash.Valid
+
+		rows, err := tx.Query(ctx, `
+			UPDATE devices SET`+clearPushToken+`
+			WHERE push_token_hash = $1 AND id != $2 AND deleted_at IS NULL
+			RETURNING user_id, id`,
+			tokenHash, deviceID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to release push token: %w", err)
+		}
+		res.Displaced, err = pgx.CollectRows(rows, pgx.RowToStructByPos[PushTokenOwner])
+		if err != nil {
+			return fmt.Errorf("failed to release push token: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE devices SET
+				push_provider = $3,
+				push_token_encrypted = $4,
+				push_token_hash = $5,
+				push_token_key_version = $6,
+				push_token_updated_at = NOW()
+			WHERE id = $1 AND user_id = $2`,
+			deviceID, userID, provider, tokenEnc, tokenHash, r.encryptor.CurrentKeyVersion(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set push token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ClearPushToken removes the push token of the user's device
+// It reports whether the device had one
+func (r *DeviceRepository) ClearPushToken(ctx context.Context, userID, deviceID uuid.UUID) (bool, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.clearPushToken(ctx, userID, deviceID)
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+func (r *DeviceRepository) clearPushToken(ctx context.Context, userID, deviceID uuid.UUID) (bool, error) {
+	var hadToken bool
+	err := r.pool.QueryRow(ctx, `
+		UPDATE devices SET`+clearPushToken+`
+		FROM (
+			SELECT id, push_token_hash IS NOT NULL AS had_token
+			FROM devices
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			FOR UPDATE
+		) AS prev
+		WHERE devices.id = prev.id
+		RETURNING prev.had_token`,
+		deviceID, userID,
+	).Scan(&hadToken)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrDeviceNotFound
+		}
+		return false, fmt.Errorf("failed to clear push token: %w", err)
+	}
+
+	return hadToken, nil
+}
+
+// InvalidatePushToken removes token from whichever device holds it and returns that device
+// The token is found by its hash; nothing is returned if no active device holds it
+func (r *DeviceRepository) InvalidatePushToken(ctx context.Context, token string) ([]PushTokenOwner, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.invalidatePushToken(ctx, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]PushTokenOwner), nil
+}
+
+func (r *DeviceRepository) invalidatePushToken(ctx context.Context, token string) ([]PushTokenOwner, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE devices SET`+clearPushToken+`
+		WHERE push_token_hash = $1 AND deleted_at IS NULL
+		RETURNING user_id, id`,
+		r.encryptor.Hash(token),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invalidate push token: %w", err)
+	}
+
+	owners, err := pgx.CollectRows(rows, pgx.RowToStructByPos[PushTokenOwner])
+	if err != nil {
+		return nil, fmt.Errorf("failed to invalidate push token: %w", err)
+	}
+	return owners, nil
+}
+
+// ListPushTokens returns the decrypted push tokens of the user's trusted devices
+// Devices whose trust lapsed are left out
+func (r *DeviceRepository) ListPushTokens(ctx context.Context, userID uuid.UUID) ([]*domain.PushToken, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listPushTokens(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*domain.PushToken), nil
+}
+
+func (r *DeviceRepository) listPushTokens(ctx context.Context, userID uuid.UUID) ([]*domain.PushToken, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, device_type, os, push_provider, push_token_encrypted, push_token_updated_at
+		FROM devices
+		WHERE user_id = $1 AND deleted_at IS NULL AND push_token_hash IS NOT NULL
+			AND is_trusted AND (trusted_until IS NULL OR trusted_until > NOW())
+		ORDER BY last_active_at DESC NULLS LAST`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*domain.PushToken, 0)
+	for rows.Next() {
+		var token domain.PushToken
+		var tokenEnc string
+		if err := rows.Scan(&token.DeviceID, &token.DeviceType, &token.OS, &token.Provider, &tokenEnc, &token.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan push token: %w", err)
+		}
+		token.Token, _, err = r.encryptor.DecryptString(tokenEnc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt push token: %w", err)
+		}
+		tokens = append(tokens, &token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list push tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// DeviceRiskHistory is the activity a device is scored against
+type DeviceRiskHistory struct {
+	UserHasActivity bool       // Any of the user's devices was seen before
+	NetworkSeen     bool       // The user was seen on this network before
+	OtherUsers      int        // Other users with an active device of the same fingerprint
+	LastNetworkHash string     // Network the user was most recently seen on
+	LastSeenAt      *time.Time // When the user was most recently seen
+}
+
+// GetRiskHistory gathers the user's activity history relevant to scoring a device seen on networkHash
+func (r *DeviceRepository) GetRiskHistory(ctx context.Context, userID uuid.UUID, fingerprintHash, networkHash string) (*DeviceRiskHistory, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.getRiskHistory(ctx, userID, fingerprintHash, networkHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*DeviceRiskHistory), nil
+}
+
+func (r *DeviceRepository) getRiskHistory(ctx context.Context, userID uuid.UUID, fingerprintHash, networkHash string) (*DeviceRiskHistory, error) {
+	var history DeviceRiskHistory
+	var lastNetworkHash sql.NullString
+	var lastSeenAt sql.NullTime
+
+	err := r.pool.QueryRow(ctx, `
+		SELECT
+			last.seen_at IS NOT NULL,
+			EXISTS (SELECT 1 FROM device_activity WHERE user_id = $1 AND network_hash = $3),
+			(SELECT COUNT(DISTINCT user_id) FROM devices
+				WHERE fingerprint_hash = $2 AND user_id != $1 AND deleted_at IS NULL),
+			last.network_hash,
+			last.seen_at
+		FROM (SELECT 1) AS one
+		LEFT JOIN LATERAL (
+			SELECT network_hash, seen_at FROM device_activity
+			WHERE user_id = $1
+			ORDER BY seen_at DESC
+			LIMIT 1
+		) AS last ON true`,
+		userID, fingerprintHash, networkHash,
+	).Scan(&history.UserHasActivity, &history.NetworkSeen, &history.OtherUsers, &lastNetworkHash, &lastSeenAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device risk history: %w", err)
+	}
+
+	if lastNetworkHash.Valid {
+		history.LastNetworkHash = lastNetworkHash.String
+	}
+	if lastSeenAt.Valid {
+		history.LastSeenAt = &lastSeenAt.Time
+	}
+
+	return &history, nil
+}
+
+// RecordAssessment logs the device's activity on ipHash and networkHash and stores its score
+func (r *DeviceRepository) RecordAssessment(ctx context.Context, device *domain.Device, ipHash, networkHash string, suspicion *domain.DeviceSuspicion) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.recordAssessment(ctx, device, ipHash, networkHash, suspicion)
+	})
+	return err
+}
+
+func (r *DeviceRepository) recordAssessment(ctx context.Context, device *domain.Device, ipHash, networkHash string, suspicion *domain.DeviceSuspicion) error {
+	return withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO device_activity (device_id, user_id, ip_hash, network_hash)
+			VALUES ($1, $2, $3, $4)`,
+			device.ID, device.UserID, ipHash, networkHash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record device activity: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE devices SET
+				suspicion_score = $2,
+				suspicion_reasons = $3,
+				scored_at = NOW()
+			WHERE id = $1`,
+			device.ID, suspicion.SuspicionScore, suspicion.Reasons,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store device suspicion: %w", err)
+		}
+		return nil
+	})
+}
+
 // UpdateLastActive updates the last active timestamp for a device
 func (r *DeviceRepository) UpdateLastActive(ctx context.Context, deviceID uuid.UUID, ipHash string) error {
 	query := `
@@ -152,7 +900,9 @@ func (r *DeviceRepository) UpdateLastActive(ctx context.Context, deviceID uuid.U
 func (r *DeviceRepository) scanDevice(row pgx.Row) (*domain.Device, error) {
 	var device domain.Device
 	var osVersion, appVersion, deviceName, trustReason sql.NullString
-	var lastActiveAt, deletedAt sql.NullTime
+	var lastActiveAt, trustedUntil, scoredAt, deletedAt sql.NullTime
+	var suspicionScore int
+	var suspicionReasons []string
 
 	err := row.Scan(
 		&device.ID,
@@ -167,6 +917,10 @@ func (r *DeviceRepository) scanDevice(row pgx.Row) (*domain.Device, error) {
 		&lastActiveAt,
 		&device.IsTrusted,
 		&trustReason,
+		&trustedUntil,
+		&suspicionScore,
+		&suspicionReasons,
+		&scoredAt,
 		&device.CreatedAt,
 		&deletedAt,
 	)
@@ -189,6 +943,18 @@ func (r *DeviceRepository) scanDevice(row pgx.Row) (*domain.Device, error) {
 	if lastActiveAt.Valid {
 		device.LastActiveAt = &lastActiveAt.Time
 	}
+	if trustedUntil.Valid {
+		device.TrustedUntil = &trustedUntil.Time
+		// Lapsed trust is reported as untrusted; the row is left for the audit trail
+		if !trustedUntil.Time.After(time.Now()) {
+			device.IsTrusted = false
+			device.TrustReason = domain.TrustReasonExpired
+		}
+	}
+	if scoredAt.Valid {
+		device.ScoredAt = &scoredAt.Time
+		device.Suspicion = domain.NewDeviceSuspicion(suspicionScore, suspicionReasons)
+	}
 	if deletedAt.Valid {
 		device.DeletedAt = &deletedAt.Time
 	}
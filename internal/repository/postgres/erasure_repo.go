@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// Erasure repository errors
+var (
+	ErrNotErasable = errors.New("user is not eligible for erasure")
+)
+
+// ErasureResult reports what an erasure wiped
+type ErasureResult struct {
+	ErasedAt             time.Time
+	AddressesErased      int64
+	AddressHistoryErased int64
+	DevicesErased        int64
+}
+
+// ErasureRepository wipes PII of deleted users once their retention period has passed
+type ErasureRepository struct {
+	pool *pgxpool.Pool
+	cb   *resilience.CircuitBreaker
+}
+
+// NewErasureRepository creates a new erasure repository
+func NewErasureRepository(pool *pgxpool.Pool, cb *resilience.CircuitBreaker) *ErasureRepository {
+	return &ErasureRepository{
+		pool: pool,
+		cb:   cb,
+	}
+}
+
+// ListErasable returns deleted, not yet erased users deleted before the cutoff
+// Users under legal hold are excluded
+func (r *ErasureRepository) ListErasable(ctx context.Context, deletedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.listErasable(ctx, deletedBefore, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]uuid.UUID), nil
+}
+
+func (r *ErasureRepository) listErasable(ctx context.Context, deletedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM users
+		WHERE deleted_at IS NOT NULL
+			AND deleted_at < $1
+			AND erased_at IS NULL
+			AND NOT COALESCE(risk_flags, '[]'::jsonb) ? $2
+		ORDER BY deleted_at
+		LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, deletedBefore, domain.RiskFlagLegalHold, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list erasable users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// Erase wipes all PII held for the user in a single transaction
+// The user row is re-checked under lock, so a legal hold or restore applied since
+// ListErasable wins; in that case ErrNotErasable is returned
+// eraseElsewhere wipes what other stores hold; it runs under the same lock and its failure
+// rolls the erasure back, so the user stays erasable and is retried. It must be idempotent
+func (r *ErasureRepository) Erase(ctx context.Context, userID uuid.UUID, deletedBefore time.Time, eraseElsewhere func(ctx context.Context) error) (*ErasureResult, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.erase(ctx, userID, deletedBefore, eraseElsewhere)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*ErasureResult), nil
+}
+
+func (r *ErasureRepository) erase(ctx context.Context, userID uuid.UUID, deletedBefore time.Time, eraseElsewhere func(ctx context.Context) error) (*ErasureResult, error) {
+	res := &ErasureResult{}
+	err := withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		// SKIP LOCKED lets several instances run the job without blocking on each other
+		var locked uuid.UUID
+		err := tx.QueryRow(ctx, `
+			SELECT id FROM users
+			WHERE id = $1
+				AND deleted_at IS NOT NULL
+				AND deleted_at < $2
+				AND erased_at IS NULL
+				AND NOT COALESCE(risk_flags, '[]'::jsonb) ? $3
+			FOR UPDATE SKIP LOCKED`,
+			userID, deletedBefore, domain.RiskFlagLegalHold,
+		).Scan(&locked)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotErasable
+			}
+			return fmt.Errorf("failed to lock user for erasure: %w", err)
+		}
+
+		if err := eraseElsewhere(ctx); err != nil {
+			return fmt.Errorf("failed to erase data held outside Postgres: %w", err)
+		}
+
+		err = tx.QueryRow(ctx, `
+			UPDATE users SET
+				legal_name_encrypted = NULL,
+				email_encrypted = NULL,
+				email_hash = NULL,
+				phone_encrypted = NULL,
+				phone_hash = NULL,
+				phone_verified_at = NULL,
+				dob_encrypted = NULL,
+				pending_email_encrypted = NULL,
+				pending_email_hash = NULL,
+				email_change_token_hash = NULL,
+				email_change_expires_at = NULL,
+				erased_at = NOW(),
+				updated_at = NOW()
+			WHERE id = $1
+			RETURNING erased_at`,
+			userID,
+		).Scan(&res.ErasedAt)
+		if err != nil {
+			return fmt.Errorf("failed to erase user: %w", err)
+		}
+
+		addrResult, err := tx.Exec(ctx,
+			`UPDATE addresses SET
+				address_encrypted = NULL,
+				pending_change_encrypted = NULL,
+				pending_change_token_hash = NULL,
+				pending_change_expires_at = NULL,
+				updated_at = NOW()
+			WHERE user_id = $1`,
+			userID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to erase addresses: %w", err)
+		}
+		res.AddressesErased = addrResult.RowsAffected()
+
+		historyResult, err := tx.Exec(ctx,
+			"UPDATE address_history SET address_encrypted = NULL, raw_input_encrypted = NULL WHERE user_id = $1",
+			userID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to erase address history: %w", err)
+		}
+		res.AddressHistoryErased = historyResult.RowsAffected()
+
+		// Device names are user-chosen and often contain the owner's name, and push
+		// tokens would still let the erased user be contacted
+		devResult, err := tx.Exec(ctx,
+			"UPDATE devices SET device_name = NULL, last_ip_hash = NULL,"+clearPushToken+" WHERE user_id = $1",
+			userID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to erase devices: %w", err)
+		}
+		res.DevicesErased = devResult.RowsAffected()
+
+		// IP hashes still link the erased user to networks and other accounts
+		if _, err := tx.Exec(ctx, "DELETE FROM device_activity WHERE user_id = $1", userID); err != nil {
+			return fmt.Errorf("failed to erase device activity: %w", err)
+		}
+
+		// Consent events are kept as evidence; only unused double opt-in links go
+		if _, err := tx.Exec(ctx, "DELETE FROM consent_confirmations WHERE user_id = $1", userID); err != nil {
+			return fmt.Errorf("failed to erase consent confirmations: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Transaction retry settings
+const (
+	maxTxAttempts  = 3
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+// serializableTx is used where concurrent writers must not interleave,
+// e.g. flipping the primary address while another request inserts one
+var serializableTx = pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+// withTx runs fn in a transaction and commits it, rolling back on any error
+// Serialization failures and deadlocks are retried with a fresh transaction, so fn
+// must derive everything it writes from its arguments and the tx, not from a prior attempt
+func withTx(ctx context.Context, pool *pgxpool.Pool, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	return retryTx(ctx, func() error {
+		return runTx(ctx, pool, opts, fn)
+	})
+}
+
+// retryTx calls run until it succeeds, fails with an error that is not retryable, or
+// maxTxAttempts is reached; the last error is returned
+func retryTx(ctx context.Context, run func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = run()
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+
+		if attempt < maxTxAttempts {
+			// Jitter keeps colliding writers from retrying in lockstep
+			backoff := time.Duration(attempt)*txRetryBackoff + time.Duration(rand.Int63n(int64(txRetryBackoff)))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+	}
+	return err
+}
+
+func runTx(ctx context.Context, pool *pgxpool.Pool, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isRetryableTxError reports whether err aborted the transaction because of a concurrent one
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryableTxError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"wrapped serialization failure", fmt.Errorf("failed to commit transaction: %w", &pgconn.PgError{Code: "40001"}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"not a postgres error", errors.New("connection refused"), false},
+		{"repository error", ErrAddressChanged, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableTxError(tt.err); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryTx(t *testing.T) {
+	ctx := context.Background()
+	conflict := &pgconn.PgError{Code: "40001"}
+
+	t.Run("retries conflicts until success", func(t *testing.T) {
+		calls := 0
+		err := retryTx(ctx, func() error {
+			calls++
+			if calls < maxTxAttempts {
+				return conflict
+			}
+			return nil
+		})
+		if err != nil || calls != maxTxAttempts {
+			t.Errorf("expected success after %d attempts, got %v after %d", maxTxAttempts, err, calls)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		err := retryTx(ctx, func() error {
+			calls++
+			return conflict
+		})
+		if !errors.Is(err, conflict) || calls != maxTxAttempts {
+			t.Errorf("expected the conflict after %d attempts, got %v after %d", maxTxAttempts, err, calls)
+		}
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		calls := 0
+		err := retryTx(ctx, func() error {
+			calls++
+			return ErrAddressChanged
+		})
+		if err != ErrAddressChanged || calls != 1 {
+			t.Errorf("expected one attempt returning ErrAddressChanged, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		calls := 0
+		err := retryTx(cancelled, func() error {
+			calls++
+			return conflict
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Errorf("expected context.Canceled after one attempt, got %v after %d", err, calls)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/banking/user-service/internal/crypto"
@@ -22,6 +23,10 @@ var (
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserAlreadyExists = errors.New("user with this email already exists")
 	ErrOptimisticLock    = errors.New("optimistic lock conflict: user was modified")
+	ErrInvalidToken      = errors.New("verification token is invalid or expired")
+	ErrPhoneMismatch     = errors.New("phone number does not match verification request")
+	ErrNotDeleted        = errors.New("user is not deleted")
+	ErrRestoreExpired    = errors.New("restore grace period has expired")
 )
 
 // UserRepository handles user persistence in PostgreSQL
@@ -135,6 +140,11 @@ func (r *UserRepository) create(ctx context.Context, user *domain.User) error {
 	).Scan(&user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
+		// Concurrent registrations can pass the EXISTS check above;
+		// the unique index on email_hash is the final arbiter
+		if isUniqueViolation(err) {
+			return ErrUserAlreadyExists
+		}
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
 
@@ -158,7 +168,8 @@ func (r *UserRepository) getByID(ctx context.Context, id uuid.UUID) (*domain.Use
 			id, legal_name_encrypted, email_encrypted, email_hash,
 			phone_encrypted, phone_hash, dob_encrypted,
 			country, status, kyc_status, kyc_reference_id, risk_flags,
-			encryption_key_version, created_at, updated_at, deleted_at
+			encryption_key_version, created_at, updated_at, deleted_at,
+			phone_verified_at, status_reason, status_changed_at
 		FROM users
 		WHERE id = $1`
 
@@ -192,7 +203,8 @@ func (r *UserRepository) getByEmail(ctx context.Context, email string) (*domain.
 			id, legal_name_encrypted, email_encrypted, email_hash,
 			phone_encrypted, phone_hash, dob_encrypted,
 			country, status, kyc_status, kyc_reference_id, risk_flags,
-			encryption_key_version, created_at, updated_at, deleted_at
+			encryption_key_version, created_at, updated_at, deleted_at,
+			phone_verified_at, status_reason, status_changed_at
 		FROM users
 		WHERE email_hash = $1 AND deleted_at IS NULL`
 
@@ -267,11 +279,13 @@ func (r *UserRepository) update(ctx context.Context, user *domain.User, expected
 			kyc_reference_id = $10,
 			risk_flags = $11,
 			encryption_key_version = $12,
+			phone_verified_at = CASE WHEN phone_hash IS NOT DISTINCT FROM $5 THEN phone_verified_at END,
 			updated_at = NOW()
 		WHERE id = $13 AND updated_at = $14
-		RETURNING updated_at`
+		RETURNING updated_at, phone_verified_at`
 
 	var newUpdatedAt time.Time
+	var phoneVerifiedAt sql.NullTime
 	err = r.pool.QueryRow(ctx, query,
 		legalNameEnc,
 		emailEnc,
@@ -287,7 +301,7 @@ func (r *UserRepository) update(ctx context.Context, user *domain.User, expected
 		r.encryptor.CurrentKeyVersion(),
 		user.ID,
 		expectedUpdatedAt,
-	).Scan(&newUpdatedAt)
+	).Scan(&newUpdatedAt, &phoneVerifiedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -297,28 +311,344 @@ func (r *UserRepository) update(ctx context.Context, user *domain.User, expected
 	}
 
 	user.UpdatedAt = newUpdatedAt
+	// A changed phone number loses its verification
+	user.PhoneVerifiedAt = nil
+	if phoneVerifiedAt.Valid {
+		user.PhoneVerifiedAt = &phoneVerifiedAt.Time
+	}
 	return nil
 }
 
 // SoftDelete marks a user as deleted
-func (r *UserRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+// Addresses deleted with the account are versioned in history as SYSTEM changes by changedBy
+func (r *UserRepository) SoftDelete(ctx context.Context, id uuid.UUID, changedBy string) error {
 	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
-		return nil, r.softDelete(ctx, id)
+		return nil, r.softDelete(ctx, id, changedBy)
 	})
 	return err
 }
 
-func (r *UserRepository) softDelete(ctx context.Context, id uuid.UUID) error {
+func (r *UserRepository) softDelete(ctx context.Context, id uuid.UUID, changedBy string) error {
+	return withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		// NOW() is fixed for the transaction, so the user and its children share one deleted_at.
+		// Restore relies on this to bring back exactly what was deleted with the account.
+		query := `
+			UPDATE users SET
+				status = 'DELETED',
+				status_reason = 'SELF_SERVICE_DELETE',
+				status_changed_at = NOW(),
+				deleted_at = NOW(),
+				updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL`
+
+		result, err := tx.Exec(ctx, query, id)
+		if err != nil {
+			return fmt.Errorf("failed to soft delete user: %w", err)
+		}
+
+		if result.RowsAffected() == 0 {
+			return ErrUserNotFound
+		}
+
+		if _, err := tx.Exec(ctx, `
+			WITH deleted AS (
+				UPDATE addresses SET
+					deleted_at = NOW(),
+					version = version + 1,
+					updated_at = NOW()
+				WHERE user_id = $1 AND deleted_at IS NULL
+				RETURNING id, user_id, address_encrypted, version, encryption_key_version
+			)
+			`+insertAddressHistoryFrom("deleted", domain.AddressChangeDelete),
+			id, changedBy, domain.AddressChangeSourceSystem,
+		); err != nil {
+			return fmt.Errorf("failed to soft delete addresses: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx,
+			"UPDATE devices SET deleted_at = NOW(),"+clearPushToken+" WHERE user_id = $1 AND deleted_at IS NULL",
+			id,
+		); err != nil {
+			return fmt.Errorf("failed to soft delete devices: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RestoreResult reports what a restore brought back
+type RestoreResult struct {
+	RestoredAt        time.Time
+	AddressesRestored int64
+	DevicesRestored   int64
+}
+
+// Restore undoes a soft delete, including addresses and devices deleted with the account
+// Accounts deleted before notBefore are outside the grace period and cannot be restored.
+// Restored addresses are versioned in history as changeSource changes by changedBy
+func (r *UserRepository) Restore(ctx context.Context, id uuid.UUID, notBefore time.Time, reason domain.StatusReason, changedBy, changeSource string) (*RestoreResult, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.restore(ctx, id, notBefore, reason, changedBy, changeSource)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RestoreResult), nil
+}
+
+func (r *UserRepository) restore(ctx context.Context, id uuid.UUID, notBefore time.Time, reason domain.StatusReason, changedBy, changeSource string) (*RestoreResult, error) {
+	res := &RestoreResult{}
+	err := withTx(ctx, r.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		var deletedAt, erasedAt sql.NullTime
+		var emailHash string
+		err := tx.QueryRow(ctx,
+			"SELECT deleted_at, erased_at, COALESCE(email_hash, '') FROM users WHERE id = $1 FOR UPDATE",
+			id,
+		).Scan(&deletedAt, &erasedAt, &emailHash)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("failed to load deleted user: %w", err)
+		}
+		if !deletedAt.Valid {
+			return ErrNotDeleted
+		}
+		// Erased accounts have nothing left to restore, whatever the grace period says
+		if deletedAt.Time.Before(notBefore) || erasedAt.Valid {
+			return ErrRestoreExpired
+		}
+
+		// Another live account may have registered this email since the deletion
+		var taken bool
+		err = tx.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM users WHERE email_hash = $1 AND deleted_at IS NULL AND id <> $2)",
+			emailHash, id,
+		).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("failed to check email availability: %w", err)
+		}
+		if taken {
+			return ErrUserAlreadyExists
+		}
+
+		err = tx.QueryRow(ctx, `
+			UPDATE users SET
+				status = 'ACTIVE',
+				status_reason = $2,
+				status_changed_at = NOW(),
+				deleted_at = NULL,
+				updated_at = NOW()
+			WHERE id = $1
+			RETURNING status_changed_at`,
+			id, reason,
+		).Scan(&res.RestoredAt)
+		if err != nil {
+			// The partial unique index catches a registration racing this restore
+			if isUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to restore user: %w", err)
+		}
+
+		addrResult, err := tx.Exec(ctx, `
+			WITH restored AS (
+				UPDATE addresses SET
+					deleted_at = NULL,
+					version = version + 1,
+					updated_at = NOW()
+				WHERE user_id = $1 AND deleted_at = $4
+				RETURNING id, user_id, address_encrypted, version, encryption_key_version
+			)
+			`+insertAddressHistoryFrom("restored", domain.AddressChangeUpdate),
+			id, changedBy, changeSource, deletedAt.Time,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to restore addresses: %w", err)
+		}
+		res.AddressesRestored = addrResult.RowsAffected()
+
+		devResult, err := tx.Exec(ctx,
+			"UPDATE devices SET deleted_at = NULL WHERE user_id = $1 AND deleted_at = $2",
+			id, deletedAt.Time,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to restore devices: %w", err)
+		}
+		res.DevicesRestored = devResult.RowsAffected()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// UpdateStatus moves a user from one status to another
+// The from status guards against concurrent transitions; validation happens in the domain layer
+func (r *UserRepository) UpdateStatus(ctx context.Context, userID uuid.UUID, from, to domain.UserStatus, reason domain.StatusReason) (time.Time, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.updateStatus(ctx, userID, from, to, reason)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return result.(time.Time), nil
+}
+
+func (r *UserRepository) updateStatus(ctx context.Context, userID uuid.UUID, from, to domain.UserStatus, reason domain.StatusReason) (time.Time, error) {
+	query := `
+		UPDATE users SET
+			status = $3,
+			status_reason = $4,
+			status_changed_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND status = $2 AND deleted_at IS NULL
+		RETURNING status_changed_at`
+
+	var changedAt time.Time
+	err := r.pool.QueryRow(ctx, query, userID, from, to, reason).Scan(&changedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, ErrOptimisticLock
+		}
+		return time.Time{}, fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	return changedAt, nil
+}
+
+// AddRiskFlag adds flag to the user's risk flags and reports whether it was not already set
+// updated_at is bumped so a concurrent profile update cannot write back a stale flag list
+func (r *UserRepository) AddRiskFlag(ctx context.Context, userID uuid.UUID, flag string) (bool, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.addRiskFlag(ctx, userID, flag)
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+func (r *UserRepository) addRiskFlag(ctx context.Context, userID uuid.UUID, flag string) (bool, error) {
 	query := `
 		UPDATE users SET
-			status = 'DELETED',
-			deleted_at = NOW(),
+			risk_flags = COALESCE(risk_flags, '[]'::jsonb) || jsonb_build_array($2::text),
 			updated_at = NOW()
-		WHERE id = $1 AND deleted_at IS NULL`
+		WHERE id = $1 AND deleted_at IS NULL
+			AND NOT COALESCE(risk_flags, '[]'::jsonb) ? $2`
 
-	result, err := r.pool.Exec(ctx, query, id)
+	result, err := r.pool.Exec(ctx, query, userID, flag)
 	if err != nil {
-		return fmt.Errorf("failed to soft delete user: %w", err)
+		return false, fmt.Errorf("failed to add risk flag: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// MarkPhoneVerified records that the user's current phone number passed verification
+// phoneHash is the hash captured when the code was sent; a mismatch means the number changed since
+func (r *UserRepository) MarkPhoneVerified(ctx context.Context, userID uuid.UUID, phoneHash string) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.markPhoneVerified(ctx, userID, phoneHash)
+	})
+	return err
+}
+
+func (r *UserRepository) markPhoneVerified(ctx context.Context, userID uuid.UUID, phoneHash string) error {
+	query := `
+		UPDATE users SET
+			phone_verified_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND phone_hash = $2 AND deleted_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, userID, phoneHash)
+	if err != nil {
+		return fmt.Errorf("failed to mark phone verified: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrPhoneMismatch
+	}
+
+	return nil
+}
+
+// IsPhoneVerified reports whether the user's current phone number is verified
+func (r *UserRepository) IsPhoneVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		var verified bool
+		err := r.pool.QueryRow(ctx,
+			"SELECT phone_verified_at IS NOT NULL FROM users WHERE id = $1 AND deleted_at IS NULL",
+			userID,
+		).Scan(&verified)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, ErrUserNotFound
+			}
+			return false, err
+		}
+		return verified, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// EmailExists reports whether a non-deleted user already uses the email
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	result, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		var exists bool
+		err := r.pool.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM users WHERE email_hash = $1 AND deleted_at IS NULL)",
+			r.encryptor.Hash(email),
+		).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing email: %w", err)
+		}
+		return exists, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// SetPendingEmail stores a new email awaiting verification along with the hashed one-time token
+// Any previous pending change is replaced
+func (r *UserRepository) SetPendingEmail(ctx context.Context, userID uuid.UUID, email, token string, expiresAt time.Time) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.setPendingEmail(ctx, userID, email, token, expiresAt)
+	})
+	return err
+}
+
+func (r *UserRepository) setPendingEmail(ctx context.Context, userID uuid.UUID, email, token string, expiresAt time.Time) error {
+	emailEnc, err := r.encryptor.EncryptString(email)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt pending email: %w", err)
+	}
+
+	query := `
+		UPDATE users SET
+			pending_email_encrypted = $1,
+			pending_email_hash = $2,
+			email_change_token_hash = $3,
+			email_change_expires_at = $4
+		WHERE id = $5 AND deleted_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query,
+		emailEnc,
+		r.encryptor.Hash(email),
+		r.encryptor.Hash(token),
+		expiresAt,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set pending email: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
@@ -328,16 +658,64 @@ func (r *UserRepository) softDelete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// ConfirmEmailChange atomically swaps the pending email into email_encrypted/email_hash
+// The token must match and be unexpired; the pending columns are cleared in the same statement
+func (r *UserRepository) ConfirmEmailChange(ctx context.Context, userID uuid.UUID, token string) error {
+	_, err := r.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, r.confirmEmailChange(ctx, userID, token)
+	})
+	return err
+}
+
+func (r *UserRepository) confirmEmailChange(ctx context.Context, userID uuid.UUID, token string) error {
+	query := `
+		UPDATE users SET
+			email_encrypted = pending_email_encrypted,
+			email_hash = pending_email_hash,
+			pending_email_encrypted = NULL,
+			pending_email_hash = NULL,
+			email_change_token_hash = NULL,
+			email_change_expires_at = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+			AND pending_email_hash IS NOT NULL
+			AND email_change_token_hash = $2
+			AND email_change_expires_at > NOW()`
+
+	result, err := r.pool.Exec(ctx, query,
+		userID,
+		r.encryptor.Hash(token),
+	)
+	if err != nil {
+		// Another account claimed the address between request and confirmation
+		if isUniqueViolation(err) {
+			return ErrUserAlreadyExists
+		}
+		return fmt.Errorf("failed to confirm email change: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
 // scanUser scans a row into a User struct and decrypts PII
 func (r *UserRepository) scanUser(ctx context.Context, row pgx.Row) (*domain.User, error) {
 	var user domain.User
-	var legalNameEnc, emailEnc string
+	// PII columns are NULL once the erasure job has wiped the account
+	var legalNameEnc, emailEnc sql.NullString
 	var phoneEnc, dobEnc sql.NullString
-	var emailHash string
+	var emailHash sql.NullString
 	var phoneHash sql.NullString
 	var kycRefID *uuid.UUID
 	var riskFlagsJSON []byte
 	var deletedAt sql.NullTime
+	var phoneVerifiedAt sql.NullTime
+	var statusReason sql.NullString
+	var statusChangedAt sql.NullTime
 
 	err := row.Scan(
 		&user.ID,
@@ -356,20 +734,32 @@ func (r *UserRepository) scanUser(ctx context.Context, row pgx.Row) (*domain.Use
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&deletedAt,
+		&phoneVerifiedAt,
+		&statusReason,
+		&statusChangedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	// Decrypt PII fields
-	user.LegalName, _, err = r.encryptor.DecryptString(legalNameEnc)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt legal name: %w", err)
+	if legalNameEnc.Valid {
+		user.LegalName, _, err = r.encryptor.DecryptString(legalNameEnc.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt legal name: %w", err)
+		}
 	}
 
-	user.Email, _, err = r.encryptor.DecryptString(emailEnc)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt email: %w", err)
+	if emailEnc.Valid {
+		user.Email, _, err = r.encryptor.DecryptString(emailEnc.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt email: %w", err)
+		}
+	}
+
+	user.EmailHash = emailHash.String
+	if phoneHash.Valid {
+		user.PhoneHash = &phoneHash.String
 	}
 
 	if phoneEnc.Valid {
@@ -401,10 +791,40 @@ func (r *UserRepository) scanUser(ctx context.Context, row pgx.Row) (*domain.Use
 		user.DeletedAt = &deletedAt.Time
 	}
 
+	if phoneVerifiedAt.Valid {
+		user.PhoneVerifiedAt = &phoneVerifiedAt.Time
+	}
+
+	if statusReason.Valid {
+		user.StatusReason = domain.StatusReason(statusReason.String)
+	}
+	if statusChangedAt.Valid {
+		user.StatusChangedAt = &statusChangedAt.Time
+	}
+
 	return &user, nil
 }
 
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 // Ping checks database connectivity
 func (r *UserRepository) Ping(ctx context.Context) error {
 	return r.pool.Ping(ctx)
 }
+
+// insertAddressHistoryFrom records every address in the named CTE, which must return
+// id, user_id, address_encrypted, version and encryption_key_version, as a history entry
+// by $2 with change source $3. Snapshots keep the key version they were encrypted with
+func insertAddressHistoryFrom(cte, changeType string) string {
+	return `
+		INSERT INTO address_history (
+			address_id, user_id, address_encrypted, version,
+			changed_by, change_source, change_type, encryption_key_version
+		)
+		SELECT id, user_id, address_encrypted, version, $2, $3, '` + changeType + `', encryption_key_version
+		FROM ` + cte
+}
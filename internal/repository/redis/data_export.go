@@ -0,0 +1,201 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/banking/user-service/internal/crypto"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// Data export errors
+var (
+	ErrExportInProgress = errors.New("data export already in progress")
+)
+
+// Data export keys
+const (
+	dataExportJobPrefix    = "user:export:job:"
+	dataExportBundlePrefix = "user:export:bundle:"
+	dataExportActivePrefix = "user:export:active:"
+	dataExportUserPrefix   = "user:export:user:" // Set of the user's export IDs, for erasure
+)
+
+// DataExportStore keeps data export jobs and their bundles in Redis
+// Bundles hold decrypted PII, so they are encrypted at rest and expire automatically
+type DataExportStore struct {
+	client    *redis.Client
+	encryptor *crypto.FieldEncryptor
+	cb        *resilience.CircuitBreaker
+}
+
+// NewDataExportStore creates a new data export store
+func NewDataExportStore(client *redis.Client, encryptor *crypto.FieldEncryptor, cb *resilience.CircuitBreaker) *DataExportStore {
+	return &DataExportStore{
+		client:    client,
+		encryptor: encryptor,
+		cb:        cb,
+	}
+}
+
+// CreateJob stores a new job and marks the user as having an export in progress
+// Returns ErrExportInProgress if another export for the user has not finished
+func (s *DataExportStore) CreateJob(ctx context.Context, job *domain.DataExportJob, ttl, lockTTL time.Duration) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, s.createJob(ctx, job, ttl, lockTTL)
+	})
+	return err
+}
+
+func (s *DataExportStore) createJob(ctx context.Context, job *domain.DataExportJob, ttl, lockTTL time.Duration) error {
+	ok, err := s.client.SetNX(ctx, dataExportActivePrefix+job.UserID.String(), job.ID.String(), lockTTL).Result()
+	if err != nil {
+		return fmt.Errorf("failed to set export lock: %w", err)
+	}
+	if !ok {
+		return ErrExportInProgress
+	}
+
+	// The bundle is saved up to lockTTL after the job, so the index must outlive it by as much
+	userKey := dataExportUserPrefix + job.UserID.String()
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, userKey, job.ID.String())
+		pipe.Expire(ctx, userKey, ttl+lockTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index export job: %w", err)
+	}
+
+	return s.saveJob(ctx, job, ttl)
+}
+
+// SaveJob overwrites the stored job state
+func (s *DataExportStore) SaveJob(ctx context.Context, job *domain.DataExportJob, ttl time.Duration) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, s.saveJob(ctx, job, ttl)
+	})
+	return err
+}
+
+func (s *DataExportStore) saveJob(ctx context.Context, job *domain.DataExportJob, ttl time.Duration) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal export job: %w", err)
+	}
+
+	if err := s.client.Set(ctx, dataExportJobPrefix+job.ID.String(), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store export job: %w", err)
+	}
+
+	return nil
+}
+
+// GetJob retrieves a job by ID
+// Returns ErrCacheMiss if the job does not exist or has expired
+func (s *DataExportStore) GetJob(ctx context.Context, exportID uuid.UUID) (*domain.DataExportJob, error) {
+	result, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return s.getJob(ctx, exportID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*domain.DataExportJob), nil
+}
+
+func (s *DataExportStore) getJob(ctx context.Context, exportID uuid.UUID) (*domain.DataExportJob, error) {
+	data, err := s.client.Get(ctx, dataExportJobPrefix+exportID.String()).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrCacheMiss
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+
+	var job domain.DataExportJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal export job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// SaveBundle encrypts and stores a finished export bundle
+func (s *DataExportStore) SaveBundle(ctx context.Context, exportID uuid.UUID, bundle []byte, ttl time.Duration) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		encrypted, err := s.encryptor.Encrypt(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt export bundle: %w", err)
+		}
+		if err := s.client.Set(ctx, dataExportBundlePrefix+exportID.String(), encrypted, ttl).Err(); err != nil {
+			return nil, fmt.Errorf("failed to store export bundle: %w", err)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// GetBundle retrieves and decrypts an export bundle
+// Returns ErrCacheMiss if the bundle has expired
+func (s *DataExportStore) GetBundle(ctx context.Context, exportID uuid.UUID) ([]byte, error) {
+	result, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		encrypted, err := s.client.Get(ctx, dataExportBundlePrefix+exportID.String()).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil, ErrCacheMiss
+			}
+			return nil, fmt.Errorf("failed to get export bundle: %w", err)
+		}
+		bundle, _, err := s.encryptor.Decrypt(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt export bundle: %w", err)
+		}
+		return bundle, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]byte), nil
+}
+
+// ReleaseUser clears the in-progress marker so the user can request another export
+func (s *DataExportStore) ReleaseUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, s.client.Del(ctx, dataExportActivePrefix+userID.String()).Err()
+	})
+	return err
+}
+
+// DeleteUserExports removes every job and bundle of the user's exports
+func (s *DataExportStore) DeleteUserExports(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, s.deleteUserExports(ctx, userID)
+	})
+	return err
+}
+
+func (s *DataExportStore) deleteUserExports(ctx context.Context, userID uuid.UUID) error {
+	userKey := dataExportUserPrefix + userID.String()
+	ids, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list user exports: %w", err)
+	}
+
+	keys := make([]string, 0, 2*len(ids)+2)
+	for _, id := range ids {
+		keys = append(keys, dataExportJobPrefix+id, dataExportBundlePrefix+id)
+	}
+	keys = append(keys, userKey, dataExportActivePrefix+userID.String())
+
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete user exports: %w", err)
+	}
+	return nil
+}
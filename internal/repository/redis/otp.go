@@ -0,0 +1,219 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/banking/user-service/internal/resilience"
+)
+
+// OTP errors
+var (
+	ErrOTPCooldown = errors.New("verification code sent too recently")
+)
+
+// otpKeys names the Redis keys for one kind of code
+type otpKeys struct {
+	code     string
+	attempts string
+	cooldown string
+}
+
+// OTP keys
+var (
+	phoneOTPKeys = otpKeys{
+		code:     "user:phone_otp:",
+		attempts: "user:phone_otp:attempts:",
+		cooldown: "user:phone_otp:cooldown:",
+	}
+	stepUpOTPKeys = otpKeys{
+		code:     "user:step_up_otp:",
+		attempts: "user:step_up_otp:attempts:",
+		cooldown: "user:step_up_otp:cooldown:",
+	}
+)
+
+// PhoneOTP is a pending phone verification code
+// Only the HMAC of the code is stored, never the code itself
+type PhoneOTP struct {
+	CodeHash  string    `json:"code_hash"`
+	PhoneHash string    `json:"phone_hash"` // Phone the code was sent to
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OTPStore keeps one-time verification codes in Redis
+// Unlike UserCache, errors are not treated as misses: verification fails closed
+type OTPStore struct {
+	client *redis.Client
+	cb     *resilience.CircuitBreaker
+}
+
+// NewOTPStore creates a new OTP store
+func NewOTPStore(client *redis.Client, cb *resilience.CircuitBreaker) *OTPStore {
+	return &OTPStore{
+		client: client,
+		cb:     cb,
+	}
+}
+
+// StepUpOTP is a pending step-up code for trusting a device
+// The code only trusts the device it was requested from
+type StepUpOTP struct {
+	CodeHash        string    `json:"code_hash"`
+	FingerprintHash string    `json:"fingerprint_hash"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// SavePhoneOTP stores a code for the user, replacing any earlier one
+// Returns ErrOTPCooldown if a code was already sent within the cooldown window
+func (s *OTPStore) SavePhoneOTP(ctx context.Context, userID uuid.UUID, otp *PhoneOTP, cooldown time.Duration) error {
+	return s.save(ctx, phoneOTPKeys, userID, otp, otp.ExpiresAt, cooldown)
+}
+
+// GetPhoneOTP retrieves the pending code for the user
+// Returns ErrCacheMiss if no code is pending or it has expired
+func (s *OTPStore) GetPhoneOTP(ctx context.Context, userID uuid.UUID) (*PhoneOTP, error) {
+	var otp PhoneOTP
+	if err := s.get(ctx, phoneOTPKeys, userID, &otp); err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// PhoneOTPAttempts returns the number of failed attempts in the current lockout window
+func (s *OTPStore) PhoneOTPAttempts(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.attempts(ctx, phoneOTPKeys, userID)
+}
+
+// IncrementPhoneOTPAttempts records a failed attempt and returns the new count
+// The counter survives resends so that requesting a new code does not reset the limit
+func (s *OTPStore) IncrementPhoneOTPAttempts(ctx context.Context, userID uuid.UUID, window time.Duration) (int64, error) {
+	return s.incrementAttempts(ctx, phoneOTPKeys, userID, window)
+}
+
+// DeletePhoneOTP removes the pending code and its attempt counter
+func (s *OTPStore) DeletePhoneOTP(ctx context.Context, userID uuid.UUID) error {
+	return s.delete(ctx, phoneOTPKeys, userID)
+}
+
+// SaveStepUpOTP stores a step-up code for the user, replacing any earlier one
+// Returns ErrOTPCooldown if a code was already sent within the cooldown window
+func (s *OTPStore) SaveStepUpOTP(ctx context.Context, userID uuid.UUID, otp *StepUpOTP, cooldown time.Duration) error {
+	return s.save(ctx, stepUpOTPKeys, userID, otp, otp.ExpiresAt, cooldown)
+}
+
+// GetStepUpOTP retrieves the pending step-up code for the user
+// Returns ErrCacheMiss if no code is pending or it has expired
+func (s *OTPStore) GetStepUpOTP(ctx context.Context, userID uuid.UUID) (*StepUpOTP, error) {
+	var otp StepUpOTP
+	if err := s.get(ctx, stepUpOTPKeys, userID, &otp); err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// StepUpOTPAttempts returns the number of failed step-up attempts in the current lockout window
+func (s *OTPStore) StepUpOTPAttempts(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.attempts(ctx, stepUpOTPKeys, userID)
+}
+
+// IncrementStepUpOTPAttempts records a failed step-up attempt and returns the new count
+func (s *OTPStore) IncrementStepUpOTPAttempts(ctx context.Context, userID uuid.UUID, window time.Duration) (int64, error) {
+	return s.incrementAttempts(ctx, stepUpOTPKeys, userID, window)
+}
+
+// DeleteStepUpOTP removes the pending step-up code and its attempt counter
+func (s *OTPStore) DeleteStepUpOTP(ctx context.Context, userID uuid.UUID) error {
+	return s.delete(ctx, stepUpOTPKeys, userID)
+}
+
+func (s *OTPStore) save(ctx context.Context, keys otpKeys, userID uuid.UUID, otp any, expiresAt time.Time, cooldown time.Duration) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		ok, err := s.client.SetNX(ctx, keys.cooldown+userID.String(), 1, cooldown).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to set otp cooldown: %w", err)
+		}
+		if !ok {
+			return nil, ErrOTPCooldown
+		}
+
+		data, err := json.Marshal(otp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal otp: %w", err)
+		}
+
+		ttl := time.Until(expiresAt)
+		if err := s.client.Set(ctx, keys.code+userID.String(), data, ttl).Err(); err != nil {
+			return nil, fmt.Errorf("failed to store otp: %w", err)
+		}
+
+		return nil, nil
+	})
+	return err
+}
+
+func (s *OTPStore) get(ctx context.Context, keys otpKeys, userID uuid.UUID, otp any) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		data, err := s.client.Get(ctx, keys.code+userID.String()).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil, ErrCacheMiss
+			}
+			return nil, fmt.Errorf("failed to get otp: %w", err)
+		}
+
+		if err := json.Unmarshal(data, otp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal otp: %w", err)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (s *OTPStore) attempts(ctx context.Context, keys otpKeys, userID uuid.UUID) (int64, error) {
+	result, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		n, err := s.client.Get(ctx, keys.attempts+userID.String()).Int64()
+		if errors.Is(err, redis.Nil) {
+			return int64(0), nil
+		}
+		return n, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+func (s *OTPStore) incrementAttempts(ctx context.Context, keys otpKeys, userID uuid.UUID, window time.Duration) (int64, error) {
+	result, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		key := keys.attempts + userID.String()
+
+		pipe := s.client.TxPipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, window)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to increment otp attempts: %w", err)
+		}
+		return incr.Val(), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+func (s *OTPStore) delete(ctx context.Context, keys otpKeys, userID uuid.UUID) error {
+	_, err := s.cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, s.client.Del(ctx,
+			keys.code+userID.String(),
+			keys.attempts+userID.String(),
+		).Err()
+	})
+	return err
+}
@@ -123,23 +123,28 @@ func (c *CircuitBreaker) Counts() gobreaker.Counts {
 
 // CircuitBreakers holds all circuit breakers for the service
 type CircuitBreakers struct {
-	Postgres *CircuitBreaker
-	Redis    *CircuitBreaker
-	MongoDB  *CircuitBreaker
-	Kafka    *CircuitBreaker
+	Postgres          *CircuitBreaker
+	Redis             *CircuitBreaker
+	MongoDB           *CircuitBreaker
+	Kafka             *CircuitBreaker
+	AddressValidation *CircuitBreaker
+	AuditStore        *CircuitBreaker
 }
 
 // NewCircuitBreakers creates circuit breakers for all dependencies
 func NewCircuitBreakers() *CircuitBreakers {
 	return &CircuitBreakers{
-		Postgres: NewCircuitBreaker(DefaultSettings("postgres")),
-		Redis:    NewCircuitBreaker(DefaultSettings("redis")),
-		MongoDB:  NewCircuitBreaker(DefaultSettings("mongodb")),
-		Kafka:    NewCircuitBreaker(DefaultSettings("kafka")),
+		Postgres:          NewCircuitBreaker(DefaultSettings("postgres")),
+		Redis:             NewCircuitBreaker(DefaultSettings("redis")),
+		MongoDB:           NewCircuitBreaker(DefaultSettings("mongodb")),
+		Kafka:             NewCircuitBreaker(DefaultSettings("kafka")),
+		AddressValidation: NewCircuitBreaker(DefaultSettings("address_validation")),
+		AuditStore:        NewCircuitBreaker(DefaultSettings("audit_store")),
 	}
 }
 
 // AllHealthy returns true if no circuit breakers are open
+// The address validation provider and audit store are optional and do not count against health
 func (cb *CircuitBreakers) AllHealthy() bool {
 	return !cb.Postgres.IsOpen() &&
 		!cb.Redis.IsOpen() &&
@@ -150,9 +155,11 @@ func (cb *CircuitBreakers) AllHealthy() bool {
 // Status returns the status of all circuit breakers
 func (cb *CircuitBreakers) Status() map[string]string {
 	return map[string]string{
-		"postgres": cb.Postgres.State(),
-		"redis":    cb.Redis.State(),
-		"mongodb":  cb.MongoDB.State(),
-		"kafka":    cb.Kafka.State(),
+		"postgres":           cb.Postgres.State(),
+		"redis":              cb.Redis.State(),
+		"mongodb":            cb.MongoDB.State(),
+		"kafka":              cb.Kafka.State(),
+		"address_validation": cb.AddressValidation.State(),
+		"audit_store":        cb.AuditStore.State(),
 	}
 }
@@ -0,0 +1,73 @@
+package service
+
+import "github.com/banking/user-service/internal/domain"
+
+// AddressChangeSignals describes an address edit for the change policy
+type AddressChangeSignals struct {
+	DeviceKnown    bool // The request carried the fingerprint of one of the user's devices
+	DeviceTrusted  bool
+	CountryChanged bool
+	RecentChanges  int // Address creates and edits by the user within the velocity window
+}
+
+// AddressChangeRule returns the reason to hold an edit, or "" to let it through
+type AddressChangeRule func(sig *AddressChangeSignals) string
+
+// AddressChangePolicy decides which address edits must be confirmed out of band
+// Account takeovers typically start by redirecting mail before ordering a card,
+// so edits that look like that are held until the user confirms them
+type AddressChangePolicy struct {
+	rules []AddressChangeRule
+}
+
+// NewAddressChangePolicy creates a policy evaluating the given rules in order
+func NewAddressChangePolicy(rules ...AddressChangeRule) *AddressChangePolicy {
+	return &AddressChangePolicy{rules: rules}
+}
+
+// DefaultAddressChangePolicy holds edits from untrusted devices, edits that move the
+// address to another country, and edits beyond maxRecentChanges within the window
+func DefaultAddressChangePolicy(maxRecentChanges int) *AddressChangePolicy {
+	return NewAddressChangePolicy(
+		UntrustedDeviceRule,
+		CountryChangeRule,
+		VelocityRule(maxRecentChanges),
+	)
+}
+
+// Evaluate returns every reason to hold the edit; none means it can be applied directly
+func (p *AddressChangePolicy) Evaluate(sig *AddressChangeSignals) []string {
+	var reasons []string
+	for _, rule := range p.rules {
+		if reason := rule(sig); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// UntrustedDeviceRule holds edits from unknown devices or devices the user has not trusted
+func UntrustedDeviceRule(sig *AddressChangeSignals) string {
+	if !sig.DeviceKnown || !sig.DeviceTrusted {
+		return domain.AddressHoldUntrustedDevice
+	}
+	return ""
+}
+
+// CountryChangeRule holds edits that move an address to another country
+func CountryChangeRule(sig *AddressChangeSignals) string {
+	if sig.CountryChanged {
+		return domain.AddressHoldCountryChange
+	}
+	return ""
+}
+
+// VelocityRule holds an edit once the user already made max changes within the window
+func VelocityRule(max int) AddressChangeRule {
+	return func(sig *AddressChangeSignals) string {
+		if sig.RecentChanges >= max {
+			return domain.AddressHoldChangeVelocity
+		}
+		return ""
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+)
+
+// addressExpiryActor identifies the expiry job in history and audit events
+const addressExpiryActor = "address_expiry_job"
+
+// AddressExpiryConfig holds temporary address expiry settings
+type AddressExpiryConfig struct {
+	BatchSize int
+}
+
+// DefaultAddressExpiryConfig returns the default temporary address expiry settings
+func DefaultAddressExpiryConfig() AddressExpiryConfig {
+	return AddressExpiryConfig{
+		BatchSize: 100,
+	}
+}
+
+// AddressExpiryService retires temporary addresses once their validity window closes
+type AddressExpiryService struct {
+	addressRepo   *postgres.AddressRepository
+	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           AddressExpiryConfig
+}
+
+// NewAddressExpiryService creates a new temporary address expiry service
+func NewAddressExpiryService(
+	addressRepo *postgres.AddressRepository,
+	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg AddressExpiryConfig,
+) *AddressExpiryService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultAddressExpiryConfig().BatchSize
+	}
+	return &AddressExpiryService{
+		addressRepo:   addressRepo,
+		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		log:           log.Named("address_expiry_service"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+	}
+}
+
+// ExpireTemporary expires one batch of temporary addresses and returns how many were expired
+// A failure on one address is logged and does not stop the rest of the batch
+func (s *AddressExpiryService) ExpireTemporary(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	addresses, err := s.addressRepo.ListExpiredTemporary(ctx, now, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, addr := range addresses {
+		if ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+
+		result, err := s.addressRepo.ExpireTemporary(ctx, addr, now, addressExpiryActor)
+		if err != nil {
+			// Extended, deleted or expired by another instance since listing
+			if errors.Is(err, postgres.ErrAddressChanged) {
+				continue
+			}
+			s.log.Error("failed to expire temporary address", logger.UserID(addr.UserID.String()), logger.ErrorField(err))
+			continue
+		}
+		expired++
+
+		s.emitAuditEvent(ctx, addr.UserID, audit.ActionDelete, addr.ID, []string{"deleted_at"})
+		s.publishEvent(ctx, events.EventAddressExpired, addr.UserID, events.AddressExpiredEvent{
+			AddressID:  addr.ID,
+			ValidUntil: addr.ValidUntil,
+			ExpiredAt:  result.ExpiredAt,
+		})
+
+		if result.RestoredAddressID == nil {
+			continue
+		}
+
+		s.emitAuditEvent(ctx, addr.UserID, audit.ActionUpdate, *result.RestoredAddressID, []string{"is_primary"})
+		s.publishEvent(ctx, events.EventPrimaryAddressChanged, addr.UserID, events.PrimaryAddressChangedEvent{
+			AddressID:         *result.RestoredAddressID,
+			AddressType:       result.RestoredAddressType,
+			PreviousAddressID: addr.ID,
+			ActorType:         audit.ActorSystem,
+			ChangedAt:         result.ExpiredAt,
+		})
+	}
+
+	return expired, nil
+}
+
+func (s *AddressExpiryService) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+func (s *AddressExpiryService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, addressID uuid.UUID, fields []string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(addressExpiryActor, audit.ActorSystem).
+		Action(action).
+		Resource(audit.ResourceAddress, addressID.String()).
+		FieldsChanged(fields).
+		Service(addressExpiryActor).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
@@ -3,9 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/banking/user-service/internal/addressformat"
+	"github.com/banking/user-service/internal/crypto"
 	"github.com/banking/user-service/internal/domain"
 	"github.com/banking/user-service/internal/domain/audit"
 	"github.com/banking/user-service/internal/events"
@@ -15,29 +19,71 @@ import (
 
 // Address service errors
 var (
-	ErrAddressNotFound = errors.New("address not found")
+	ErrAddressNotFound       = errors.New("address not found")
+	ErrInvalidValidityWindow = errors.New("invalid address validity window")
+	ErrTemporaryNotStarted   = errors.New("temporary address cannot be primary before its window starts")
 )
 
+// AddressServiceConfig holds address change hold settings
+type AddressServiceConfig struct {
+	ChangeConfirmationTTL time.Duration // How long a held edit can be confirmed
+	ChangeVelocityWindow  time.Duration // Window in which recent edits are counted
+	MaxChangesPerWindow   int           // Edits allowed in the window before the next one is held
+}
+
+// DefaultAddressServiceConfig returns the default address service settings
+func DefaultAddressServiceConfig() AddressServiceConfig {
+	return AddressServiceConfig{
+		ChangeConfirmationTTL: 24 * time.Hour,
+		ChangeVelocityWindow:  24 * time.Hour,
+		MaxChangesPerWindow:   1,
+	}
+}
+
 // AddressService handles address-related business logic
 type AddressService struct {
 	addressRepo   *postgres.AddressRepository
+	deviceRepo    *postgres.DeviceRepository
+	userRepo      *postgres.UserRepository
+	policy        *AddressChangePolicy
 	auditProducer *events.AuditProducer
+	notifier      *Notifier
 	log           *logger.Logger
 	hmacSecret    []byte
+	cfg           AddressServiceConfig
 }
 
 // NewAddressService creates a new address service
 func NewAddressService(
 	addressRepo *postgres.AddressRepository,
+	deviceRepo *postgres.DeviceRepository,
+	userRepo *postgres.UserRepository,
 	auditProducer *events.AuditProducer,
+	notifier *Notifier,
 	log *logger.Logger,
 	hmacSecret []byte,
+	cfg AddressServiceConfig,
 ) *AddressService {
+	defaults := DefaultAddressServiceConfig()
+	if cfg.ChangeConfirmationTTL <= 0 {
+		cfg.ChangeConfirmationTTL = defaults.ChangeConfirmationTTL
+	}
+	if cfg.ChangeVelocityWindow <= 0 {
+		cfg.ChangeVelocityWindow = defaults.ChangeVelocityWindow
+	}
+	if cfg.MaxChangesPerWindow <= 0 {
+		cfg.MaxChangesPerWindow = defaults.MaxChangesPerWindow
+	}
 	return &AddressService{
 		addressRepo:   addressRepo,
+		deviceRepo:    deviceRepo,
+		userRepo:      userRepo,
+		policy:        DefaultAddressChangePolicy(cfg.MaxChangesPerWindow),
 		auditProducer: auditProducer,
+		notifier:      notifier,
 		log:           log.Named("address_service"),
 		hmacSecret:    hmacSecret,
+		cfg:           cfg,
 	}
 }
 
@@ -47,24 +93,64 @@ func (s *AddressService) ListAddresses(ctx context.Context, userID uuid.UUID) ([
 	if err != nil {
 		return nil, err
 	}
+	for _, addr := range addresses {
+		withMailingLabel(addr)
+	}
 	return addresses, nil
 }
 
 // CreateAddress creates a new address for a user
-func (s *AddressService) CreateAddress(ctx context.Context, userID uuid.UUID, req *domain.CreateAddressRequest, clientIP, requestID string) (*domain.Address, error) {
-	addr := &domain.Address{
-		UserID:      userID,
-		AddressType: req.AddressType,
+// A new primary address redirects mail like an edit does, so it is judged by the change policy;
+// if held, the address is created without primary status until the user confirms the switch
+func (s *AddressService) CreateAddress(ctx context.Context, userID uuid.UUID, req *domain.CreateAddressRequest, deviceFingerprint, clientIP, requestID string) (*domain.Address, error) {
+	raw := &domain.AddressData{
 		StreetLine1: req.StreetLine1,
 		StreetLine2: req.StreetLine2,
 		City:        req.City,
 		State:       req.State,
 		PostalCode:  req.PostalCode,
 		Country:     req.Country,
+	}
+
+	addr := &domain.Address{
+		UserID:      userID,
+		AddressType: req.AddressType,
 		IsPrimary:   req.IsPrimary,
+		ValidFrom:   req.ValidFrom,
+		ValidUntil:  req.ValidUntil,
+		RawInput:    raw,
 	}
+	addr.FromAddressData(addressformat.Normalize(raw))
 
-	if err := s.addressRepo.Create(ctx, addr); err != nil {
+	if err := checkValidityWindow(addr, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkPrimaryStarted(addr, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var reasons []string
+	var primary *domain.AddressData
+	if addr.IsPrimary {
+		var err error
+		primary, err = s.primaryAddressData(ctx, userID, uuid.Nil)
+		if err != nil {
+			return nil, err
+		}
+		// A first primary address takes mail from nowhere, so there is nothing to protect
+		if primary != nil {
+			sig, err := s.changeSignals(ctx, userID, primary, addr.ToAddressData(), deviceFingerprint)
+			if err != nil {
+				return nil, err
+			}
+			reasons = s.policy.Evaluate(sig)
+		}
+	}
+	if len(reasons) > 0 {
+		addr.IsPrimary = false
+	}
+
+	if err := s.addressRepo.Create(ctx, addr, userID.String(), domain.AddressChangeSourceUser); err != nil {
 		return nil, err
 	}
 
@@ -72,7 +158,12 @@ func (s *AddressService) CreateAddress(ctx context.Context, userID uuid.UUID, re
 	s.emitAuditEvent(ctx, userID, audit.ActionCreate, audit.ResourceAddress, addr.ID.String(),
 		[]string{"address_type", "street_line_1", "city", "country"}, clientIP, requestID)
 
-	return addr, nil
+	if len(reasons) > 0 {
+		addr.IsPrimary = true
+		return s.holdChange(ctx, addr, primary, []string{"is_primary"}, reasons, clientIP, requestID)
+	}
+
+	return withMailingLabel(addr), nil
 }
 
 // GetAddress retrieves a specific address by ID
@@ -84,11 +175,13 @@ func (s *AddressService) GetAddress(ctx context.Context, userID, addressID uuid.
 		}
 		return nil, err
 	}
-	return addr, nil
+	return withMailingLabel(addr), nil
 }
 
 // UpdateAddress updates an existing address
-func (s *AddressService) UpdateAddress(ctx context.Context, userID, addressID uuid.UUID, req *domain.UpdateAddressRequest, clientIP, requestID string) (*domain.Address, error) {
+// Edits the change policy considers risky are not applied; the address is returned unchanged
+// with ChangeStatus PENDING_CONFIRMATION until the user confirms with the token sent to them
+func (s *AddressService) UpdateAddress(ctx context.Context, userID, addressID uuid.UUID, req *domain.UpdateAddressRequest, deviceFingerprint, clientIP, requestID string) (*domain.Address, error) {
 	addr, err := s.addressRepo.GetByID(ctx, userID, addressID)
 	if err != nil {
 		if errors.Is(err, postgres.ErrAddressNotFound) {
@@ -96,6 +189,7 @@ func (s *AddressService) UpdateAddress(ctx context.Context, userID, addressID uu
 		}
 		return nil, err
 	}
+	wasPrimary := addr.IsPrimary
 
 	changedFields := []string{}
 
@@ -104,52 +198,226 @@ func (s *AddressService) UpdateAddress(ctx context.Context, userID, addressID uu
 		addr.AddressType = *req.AddressType
 		changedFields = append(changedFields, "address_type")
 	}
-	if req.StreetLine1 != nil && *req.StreetLine1 != addr.StreetLine1 {
-		addr.StreetLine1 = *req.StreetLine1
-		changedFields = append(changedFields, "street_line_1")
+	if req.IsPrimary != nil && *req.IsPrimary != addr.IsPrimary {
+		addr.IsPrimary = *req.IsPrimary
+		changedFields = append(changedFields, "is_primary")
 	}
-	if req.StreetLine2 != nil && *req.StreetLine2 != addr.StreetLine2 {
-		addr.StreetLine2 = *req.StreetLine2
-		changedFields = append(changedFields, "street_line_2")
+	if req.ValidFrom != nil && !timeEqual(req.ValidFrom, addr.ValidFrom) {
+		addr.ValidFrom = req.ValidFrom
+		changedFields = append(changedFields, "valid_from")
 	}
-	if req.City != nil && *req.City != addr.City {
-		addr.City = *req.City
-		changedFields = append(changedFields, "city")
+	if req.ValidUntil != nil && !timeEqual(req.ValidUntil, addr.ValidUntil) {
+		// Only a newly set end must lie ahead; an unchanged one is left to the expiry job
+		if !req.ValidUntil.After(time.Now()) {
+			return nil, ErrInvalidValidityWindow
+		}
+		addr.ValidUntil = req.ValidUntil
+		changedFields = append(changedFields, "valid_until")
 	}
-	if req.State != nil && *req.State != addr.State {
-		addr.State = *req.State
-		changedFields = append(changedFields, "state")
+	// An address that stops being temporary drops its window
+	if !addr.IsTemporary() && (addr.ValidFrom != nil || addr.ValidUntil != nil) && req.ValidFrom == nil && req.ValidUntil == nil {
+		addr.ValidFrom, addr.ValidUntil = nil, nil
+		changedFields = append(changedFields, "valid_from", "valid_until")
 	}
-	if req.PostalCode != nil && *req.PostalCode != addr.PostalCode {
-		addr.PostalCode = *req.PostalCode
-		changedFields = append(changedFields, "postal_code")
+	if err := checkValidityWindow(addr, time.Time{}); err != nil {
+		return nil, err
 	}
-	if req.Country != nil && *req.Country != addr.Country {
-		addr.Country = *req.Country
-		changedFields = append(changedFields, "country")
+	// Checked only when the edit touches it, so an address that became primary before the
+	// rule existed can still be edited
+	if containsString(changedFields, "is_primary") || containsString(changedFields, "valid_from") || containsString(changedFields, "address_type") {
+		if err := checkPrimaryStarted(addr, time.Now()); err != nil {
+			return nil, err
+		}
 	}
-	if req.IsPrimary != nil && *req.IsPrimary != addr.IsPrimary {
-		addr.IsPrimary = *req.IsPrimary
-		changedFields = append(changedFields, "is_primary")
+
+	// Components are compared after normalization, so retyping the stored value
+	// in different case or spacing is not a change
+	raw := addr.ToAddressData()
+	applyAddressUpdate(raw, req)
+	normalized := addressformat.Normalize(raw)
+	current := addr.ToAddressData()
+	for _, c := range []struct {
+		field      string
+		old, value string
+	}{
+		{"street_line_1", current.StreetLine1, normalized.StreetLine1},
+		{"street_line_2", current.StreetLine2, normalized.StreetLine2},
+		{"city", current.City, normalized.City},
+		{"state", current.State, normalized.State},
+		{"postal_code", current.PostalCode, normalized.PostalCode},
+		{"country", current.Country, normalized.Country},
+	} {
+		if c.old != c.value {
+			changedFields = append(changedFields, c.field)
+		}
 	}
+	addr.FromAddressData(normalized)
+	addr.RawInput = raw
 
 	if len(changedFields) == 0 {
-		return addr, nil // No changes
+		return withMailingLabel(addr), nil // No changes
+	}
+
+	// A new address needs a fresh verdict; type and primary flag changes keep the old one
+	if addressContentChanged(changedFields) {
+		addr.ValidationStatus = domain.ValidationStatusPending
+		addr.ValidationSource = ""
+		addr.ValidatedAt = nil
+	}
+
+	// Type and primary flag changes redirect mail as much as new content does
+	if addressContentChanged(changedFields) || containsString(changedFields, "is_primary") || containsString(changedFields, "address_type") {
+		// Mail currently goes to the primary address, so a newly primary address is compared
+		// with it and the alert letter is sent there
+		previous := current
+		if addr.IsPrimary && !wasPrimary {
+			primary, err := s.primaryAddressData(ctx, userID, addr.ID)
+			if err != nil {
+				return nil, err
+			}
+			if primary != nil {
+				previous = primary
+			}
+		}
+
+		sig, err := s.changeSignals(ctx, userID, previous, normalized, deviceFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if reasons := s.policy.Evaluate(sig); len(reasons) > 0 {
+			return s.holdChange(ctx, addr, previous, changedFields, reasons, clientIP, requestID)
+		}
 	}
 
-	if err := s.addressRepo.Update(ctx, addr); err != nil {
+	if err := s.addressRepo.Update(ctx, addr, userID.String(), domain.AddressChangeSourceUser); err != nil {
 		return nil, err
 	}
 
 	// Emit audit event
 	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourceAddress, addr.ID.String(), changedFields, clientIP, requestID)
 
-	return addr, nil
+	return withMailingLabel(addr), nil
+}
+
+// ConfirmAddressChange applies a held edit if the token matches and has not expired
+func (s *AddressService) ConfirmAddressChange(ctx context.Context, userID, addressID uuid.UUID, req *domain.ConfirmAddressChangeRequest, clientIP, requestID string) (*domain.Address, error) {
+	addr, change, err := s.addressRepo.ConfirmPendingChange(ctx, userID, addressID, req.Token, userID.String(), domain.AddressChangeSourceUser)
+	if err != nil {
+		if errors.Is(err, postgres.ErrInvalidToken) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourceAddress, addr.ID.String(), change.ChangedFields, clientIP, requestID)
+
+	return withMailingLabel(addr), nil
+}
+
+// changeSignals gathers what the change policy needs to judge an edit of current into proposed
+// A missing fingerprint, or one matching none of the user's devices, counts as an unknown device
+func (s *AddressService) changeSignals(ctx context.Context, userID uuid.UUID, current, proposed *domain.AddressData, deviceFingerprint string) (*AddressChangeSignals, error) {
+	sig := &AddressChangeSignals{
+		CountryChanged: current.Country != proposed.Country,
+	}
+
+	if deviceFingerprint != "" {
+		device, err := s.deviceRepo.GetByFingerprint(ctx, userID, s.deviceRepo.HashFingerprint(deviceFingerprint))
+		switch {
+		case err == nil:
+			sig.DeviceKnown = true
+			sig.DeviceTrusted = device.IsTrusted
+		case !errors.Is(err, postgres.ErrDeviceNotFound):
+			return nil, err
+		}
+	}
+
+	recent, err := s.addressRepo.CountRecentChanges(ctx, userID, time.Now().Add(-s.cfg.ChangeVelocityWindow))
+	if err != nil {
+		return nil, err
+	}
+	sig.RecentChanges = recent
+
+	return sig, nil
+}
+
+// primaryAddressData returns the content of the user's primary address other than exceptID,
+// or nil if there is none
+func (s *AddressService) primaryAddressData(ctx context.Context, userID, exceptID uuid.UUID) (*domain.AddressData, error) {
+	addresses, err := s.addressRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addresses {
+		if addr.IsPrimary && addr.ID != exceptID {
+			return addr.ToAddressData(), nil
+		}
+	}
+	return nil, nil
+}
+
+// holdChange parks the edited addr for confirmation and returns the address as stored
+// The token goes to the user's email; a letter to the current address warns of the change,
+// since that is where a victim still receives mail
+func (s *AddressService) holdChange(ctx context.Context, addr *domain.Address, current *domain.AddressData, changedFields, reasons []string, clientIP, requestID string) (*domain.Address, error) {
+	user, err := s.userRepo.GetByID(ctx, addr.UserID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	token, err := crypto.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(s.cfg.ChangeConfirmationTTL)
+
+	// Replaces any earlier hold on the address, invalidating its token
+	change := domain.NewPendingAddressChange(addr, changedFields)
+	requestedAt, err := s.addressRepo.SetPendingChange(ctx, addr, change, reasons, token, expiresAt)
+	if err != nil {
+		if errors.Is(err, postgres.ErrAddressChanged) {
+			return nil, ErrOptimisticLock
+		}
+		return nil, err
+	}
+
+	s.notifier.Send(ctx, events.EventAddressChangeRequested, addr.UserID, events.ContactNotification{
+		Channel:   string(domain.ChannelEmail),
+		Recipient: user.Email,
+		Template:  events.TemplateAddressChangeConfirmation,
+		Params: map[string]string{
+			"address_id": addr.ID.String(),
+			"token":      token,
+			"expires_at": expiresAt.Format(time.RFC3339),
+		},
+	})
+	s.notifier.Send(ctx, events.EventAddressChangeAlert, addr.UserID, events.ContactNotification{
+		Channel:   events.ChannelPostal,
+		Recipient: strings.Join(addressformat.Label(current), "\n"),
+		Template:  events.TemplateAddressChangeAlert,
+		Params: map[string]string{
+			"requested_at": requestedAt.Format(time.RFC3339),
+		},
+	})
+
+	s.emitAuditEvent(ctx, addr.UserID, audit.ActionUpdate, audit.ResourceAddress, addr.ID.String(), []string{"pending_change"}, clientIP, requestID)
+
+	held, err := s.addressRepo.GetByID(ctx, addr.UserID, addr.ID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrAddressNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, err
+	}
+	return withMailingLabel(held), nil
 }
 
 // DeleteAddress soft-deletes an address
 func (s *AddressService) DeleteAddress(ctx context.Context, userID, addressID uuid.UUID, clientIP, requestID string) error {
-	err := s.addressRepo.SoftDelete(ctx, userID, addressID)
+	err := s.addressRepo.SoftDelete(ctx, userID, addressID, userID.String(), domain.AddressChangeSourceUser)
 	if err != nil {
 		if errors.Is(err, postgres.ErrAddressNotFound) {
 			return ErrAddressNotFound
@@ -163,10 +431,117 @@ func (s *AddressService) DeleteAddress(ctx context.Context, userID, addressID uu
 	return nil
 }
 
+// GetAddressHistory returns every recorded version of one of the user's own addresses
+func (s *AddressService) GetAddressHistory(ctx context.Context, userID, addressID uuid.UUID) ([]*domain.AddressHistory, error) {
+	history, err := s.addressRepo.ListHistoryByAddressID(ctx, userID, addressID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrAddressNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetAddressHistoryAsAdmin returns an address's versions to an investigator
+// Unlike the owner's own reads, this discloses decrypted PII to staff and is audited
+func (s *AddressService) GetAddressHistoryAsAdmin(ctx context.Context, userID, addressID uuid.UUID, adminID, clientIP, requestID string) ([]*domain.AddressHistory, error) {
+	history, err := s.GetAddressHistory(ctx, userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEventAs(ctx, userID, adminID, audit.ActorAdmin, audit.ActionAccess, audit.ResourceAddress, addressID.String(), []string{"address_history"}, clientIP, requestID)
+
+	return history, nil
+}
+
+// checkValidityWindow enforces that exactly temporary addresses have a window ending after
+// it starts; a non-zero now additionally requires the window to end in the future
+func checkValidityWindow(addr *domain.Address, now time.Time) error {
+	if !addr.IsTemporary() {
+		if addr.ValidFrom != nil || addr.ValidUntil != nil {
+			return ErrInvalidValidityWindow
+		}
+		return nil
+	}
+
+	if addr.ValidUntil == nil {
+		return ErrInvalidValidityWindow
+	}
+	if addr.ValidFrom != nil && !addr.ValidUntil.After(*addr.ValidFrom) {
+		return ErrInvalidValidityWindow
+	}
+	if !now.IsZero() && !addr.ValidUntil.After(now) {
+		return ErrInvalidValidityWindow
+	}
+	return nil
+}
+
+// checkPrimaryStarted rejects a temporary address made primary before its window starts
+// Otherwise it would replace the primary address immediately, while the expiry job only
+// hands primary status back once valid_until passes
+func checkPrimaryStarted(addr *domain.Address, now time.Time) error {
+	if addr.IsPrimary && addr.IsTemporary() && addr.ValidFrom != nil && addr.ValidFrom.After(now) {
+		return ErrTemporaryNotStarted
+	}
+	return nil
+}
+
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// applyAddressUpdate overlays the components present in req onto data
+func applyAddressUpdate(data *domain.AddressData, req *domain.UpdateAddressRequest) {
+	if req.StreetLine1 != nil {
+		data.StreetLine1 = *req.StreetLine1
+	}
+	if req.StreetLine2 != nil {
+		data.StreetLine2 = *req.StreetLine2
+	}
+	if req.City != nil {
+		data.City = *req.City
+	}
+	if req.State != nil {
+		data.State = *req.State
+	}
+	if req.PostalCode != nil {
+		data.PostalCode = *req.PostalCode
+	}
+	if req.Country != nil {
+		data.Country = *req.Country
+	}
+}
+
+// withMailingLabel renders the address's mailing label for API responses
+func withMailingLabel(addr *domain.Address) *domain.Address {
+	addr.MailingLabel = addressformat.Label(addr.ToAddressData())
+	return addr
+}
+
+// addressContentChanged reports whether any of the validated address components changed
+func addressContentChanged(changedFields []string) bool {
+	for _, f := range changedFields {
+		switch f {
+		case "street_line_1", "street_line_2", "city", "state", "postal_code", "country":
+			return true
+		}
+	}
+	return false
+}
+
 func (s *AddressService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
+	s.emitAuditEventAs(ctx, userID, userID.String(), audit.ActorUser, action, resource, resourceID, fields, clientIP, requestID)
+}
+
+func (s *AddressService) emitAuditEventAs(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
 	event, err := audit.NewAuditEvent(s.hmacSecret).
 		UserID(userID.String()).
-		Actor(userID.String(), audit.ActorUser).
+		Actor(actorID, actorType).
 		Action(action).
 		Resource(resource, resourceID).
 		FieldsChanged(fields).
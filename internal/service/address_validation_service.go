@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/banking/user-service/internal/addressvalidation"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+)
+
+// AddressValidationConfig holds address validation settings
+type AddressValidationConfig struct {
+	BatchSize int // Addresses validated per run
+}
+
+// DefaultAddressValidationConfig returns the default address validation settings
+func DefaultAddressValidationConfig() AddressValidationConfig {
+	return AddressValidationConfig{
+		BatchSize: 50,
+	}
+}
+
+// AddressValidationService moves pending addresses to a validation verdict
+// It runs outside the request path so a slow or unavailable provider never blocks address edits
+type AddressValidationService struct {
+	addressRepo *postgres.AddressRepository
+	validator   addressvalidation.AddressValidator
+	log         *logger.Logger
+	cfg         AddressValidationConfig
+}
+
+// NewAddressValidationService creates a new address validation service
+func NewAddressValidationService(
+	addressRepo *postgres.AddressRepository,
+	validator addressvalidation.AddressValidator,
+	log *logger.Logger,
+	cfg AddressValidationConfig,
+) *AddressValidationService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultAddressValidationConfig().BatchSize
+	}
+	return &AddressValidationService{
+		addressRepo: addressRepo,
+		validator:   validator,
+		log:         log.Named("address_validation_service"),
+		cfg:         cfg,
+	}
+}
+
+// ValidatePending validates one batch of pending addresses and returns how many were settled
+// Addresses the validator could not judge stay PENDING and are retried on the next run
+func (s *AddressValidationService) ValidatePending(ctx context.Context) (int, error) {
+	addresses, err := s.addressRepo.ListPendingValidation(ctx, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, addr := range addresses {
+		if ctx.Err() != nil {
+			return settled, ctx.Err()
+		}
+
+		result, err := s.validator.Validate(ctx, addr.ToAddressData())
+		if err != nil {
+			s.log.Warn("address validation failed", logger.UserID(addr.UserID.String()), logger.ErrorField(err))
+			continue
+		}
+
+		err = s.addressRepo.SetValidation(ctx, addr.ID, addr.Version, result.Status, result.Source)
+		if err != nil {
+			// Edited or deleted while being validated; the new version is picked up next run
+			if errors.Is(err, postgres.ErrAddressChanged) {
+				continue
+			}
+			s.log.Error("failed to record address validation", logger.UserID(addr.UserID.String()), logger.ErrorField(err))
+			continue
+		}
+		settled++
+	}
+
+	return settled, nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/crypto"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+)
+
+// Consent service errors
+var (
+	ErrConsentManaged = errors.New("notification type is controlled by consent")
+)
+
+// ConsentConfig holds consent settings
+type ConsentConfig struct {
+	ConfirmationTTL time.Duration // How long a double opt-in link can be used
+}
+
+// DefaultConsentConfig returns the default consent settings
+func DefaultConsentConfig() ConsentConfig {
+	return ConsentConfig{
+		ConfirmationTTL: 72 * time.Hour,
+	}
+}
+
+// ConsentService records consent as an append-only ledger and derives the consent in effect
+type ConsentService struct {
+	consentRepo   *postgres.ConsentRepository
+	userRepo      *postgres.UserRepository
+	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	notifier      *Notifier
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           ConsentConfig
+}
+
+// NewConsentService creates a new consent service
+func NewConsentService(
+	consentRepo *postgres.ConsentRepository,
+	userRepo *postgres.UserRepository,
+	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	notifier *Notifier,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg ConsentConfig,
+) *ConsentService {
+	defaults := DefaultConsentConfig()
+	if cfg.ConfirmationTTL <= 0 {
+		cfg.ConfirmationTTL = defaults.ConfirmationTTL
+	}
+	return &ConsentService{
+		consentRepo:   consentRepo,
+		userRepo:      userRepo,
+		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		notifier:      notifier,
+		log:           log.Named("consent_service"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+	}
+}
+
+// GetConsents returns the consent in effect for every purpose
+func (s *ConsentService) GetConsents(ctx context.Context, userID uuid.UUID) ([]*domain.ConsentRecord, error) {
+	return s.consentRepo.ListCurrent(ctx, userID)
+}
+
+// GrantedConsents returns the purposes the user has consent in effect for
+func (s *ConsentService) GrantedConsents(ctx context.Context, userID uuid.UUID) (map[domain.ConsentPurpose]bool, error) {
+	records, err := s.consentRepo.ListCurrent(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make(map[domain.ConsentPurpose]bool, len(records))
+	for _, record := range records {
+		granted[record.Purpose] = record.Status == domain.ConsentStatusGranted
+	}
+	return granted, nil
+}
+
+// GrantConsent gives consent for a purpose, or for email marketing starts a double opt-in
+// by emailing a confirmation link; consent is then PENDING until ConfirmConsent
+// Granting consent that is already in effect records nothing
+func (s *ConsentService) GrantConsent(ctx context.Context, userID uuid.UUID, req *domain.GrantConsentRequest, clientIP, requestID string) (*domain.ConsentRecord, error) {
+	current, err := s.current(ctx, userID, req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == domain.ConsentStatusGranted {
+		return current, nil
+	}
+
+	event := s.newEvent(userID, req.Purpose, domain.ConsentActionGranted, req.WordingVersion, req.Channel, clientIP, requestID)
+
+	if domain.RequiresDoubleOptIn(req.Purpose) {
+		event.Action = domain.ConsentActionRequested
+		return s.requestConfirmation(ctx, event, clientIP, requestID)
+	}
+
+	if err := s.consentRepo.Record(ctx, event); err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEvent(ctx, userID, audit.ActionCreate, req.Purpose, clientIP, requestID)
+	s.publishChange(ctx, event, domain.ConsentStatusGranted)
+
+	return domain.NewConsentRecord(req.Purpose, event, nil), nil
+}
+
+// ConfirmConsent completes a double opt-in with the token from the confirmation email
+func (s *ConsentService) ConfirmConsent(ctx context.Context, userID uuid.UUID, req *domain.ConfirmConsentRequest, clientIP, requestID string) (*domain.ConsentRecord, error) {
+	event := s.newEvent(userID, "", domain.ConsentActionGranted, "", domain.ConsentChannelEmail, clientIP, requestID)
+	if err := s.consentRepo.Confirm(ctx, userID, req.Token, event); err != nil {
+		if errors.Is(err, postgres.ErrInvalidToken) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	s.emitAuditEvent(ctx, userID, audit.ActionCreate, event.Purpose, clientIP, requestID)
+	s.publishChange(ctx, event, domain.ConsentStatusGranted)
+
+	return domain.NewConsentRecord(event.Purpose, event, nil), nil
+}
+
+// WithdrawConsent withdraws consent for a purpose and cancels a pending double opt-in
+// Withdrawing consent that is not in effect records nothing
+func (s *ConsentService) WithdrawConsent(ctx context.Context, userID uuid.UUID, req *domain.WithdrawConsentRequest, clientIP, requestID string) (*domain.ConsentRecord, error) {
+	current, err := s.current(ctx, userID, req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == domain.ConsentStatusNotGranted {
+		return current, nil
+	}
+
+	// The withdrawal refers to the wording that was agreed to
+	event := s.newEvent(userID, req.Purpose, domain.ConsentActionWithdrawn, current.WordingVersion, req.Channel, clientIP, requestID)
+	if err := s.consentRepo.Record(ctx, event); err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEvent(ctx, userID, audit.ActionDelete, req.Purpose, clientIP, requestID)
+	if current.Status == domain.ConsentStatusGranted {
+		s.publishChange(ctx, event, domain.ConsentStatusNotGranted)
+	}
+
+	return domain.NewConsentRecord(req.Purpose, event, nil), nil
+}
+
+// GetConsentHistory returns the user's whole consent ledger, oldest first
+func (s *ConsentService) GetConsentHistory(ctx context.Context, userID uuid.UUID) ([]*domain.ConsentEvent, error) {
+	return s.consentRepo.ListEvents(ctx, userID)
+}
+
+// GetConsentHistoryAsAdmin returns a user's consent ledger as evidence for compliance staff
+func (s *ConsentService) GetConsentHistoryAsAdmin(ctx context.Context, userID uuid.UUID, adminID, clientIP, requestID string) ([]*domain.ConsentEvent, error) {
+	history, err := s.GetConsentHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEventAs(ctx, userID, adminID, audit.ActorAdmin, audit.ActionAccess, userID.String(), []string{"consent_history"}, clientIP, requestID)
+
+	return history, nil
+}
+
+// requestConfirmation records the double opt-in request and emails the link that confirms it
+func (s *ConsentService) requestConfirmation(ctx context.Context, event *domain.ConsentEvent, clientIP, requestID string) (*domain.ConsentRecord, error) {
+	user, err := s.userRepo.GetByID(ctx, event.UserID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	token, err := crypto.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(s.cfg.ConfirmationTTL)
+
+	// Replaces any earlier link for the purpose
+	if err := s.consentRepo.RequestConfirmation(ctx, event, token, expiresAt); err != nil {
+		return nil, err
+	}
+
+	s.notifier.Send(ctx, events.EventConsentConfirmationRequested, event.UserID, events.ContactNotification{
+		Channel:   string(domain.ChannelEmail),
+		Recipient: user.Email,
+		Template:  events.TemplateConsentConfirmation,
+		Params: map[string]string{
+			"purpose":    string(event.Purpose),
+			"token":      token,
+			"expires_at": expiresAt.Format(time.RFC3339),
+		},
+	})
+
+	s.emitAuditEventAs(ctx, event.UserID, event.UserID.String(), audit.ActorUser, audit.ActionCreate, string(event.Purpose), []string{"consent_request"}, clientIP, requestID)
+
+	return domain.NewConsentRecord(event.Purpose, event, &expiresAt), nil
+}
+
+func (s *ConsentService) current(ctx context.Context, userID uuid.UUID, purpose domain.ConsentPurpose) (*domain.ConsentRecord, error) {
+	records, err := s.consentRepo.ListCurrent(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Purpose == purpose {
+			return record, nil
+		}
+	}
+	return domain.NewConsentRecord(purpose, nil, nil), nil
+}
+
+func (s *ConsentService) newEvent(userID uuid.UUID, purpose domain.ConsentPurpose, action domain.ConsentAction, wordingVersion string, channel domain.ConsentChannel, clientIP, requestID string) *domain.ConsentEvent {
+	return &domain.ConsentEvent{
+		UserID:         userID,
+		Purpose:        purpose,
+		Action:         action,
+		WordingVersion: wordingVersion,
+		Channel:        channel,
+		ActorID:        userID.String(),
+		IPHash:         audit.HashIP(clientIP, s.hmacSecret),
+		RequestID:      requestID,
+	}
+}
+
+// publishChange tells downstream systems that consent for a purpose started or stopped
+func (s *ConsentService) publishChange(ctx context.Context, event *domain.ConsentEvent, status domain.ConsentStatus) {
+	s.publishEvent(ctx, events.EventConsentChanged, event.UserID, events.ConsentChangedEvent{
+		Purpose:        event.Purpose,
+		Status:         status,
+		WordingVersion: event.WordingVersion,
+		ChangedAt:      event.CreatedAt,
+	})
+}
+
+func (s *ConsentService) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+func (s *ConsentService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, purpose domain.ConsentPurpose, clientIP, requestID string) {
+	s.emitAuditEventAs(ctx, userID, userID.String(), audit.ActorUser, action, string(purpose), []string{"consent"}, clientIP, requestID)
+}
+
+// emitAuditEventAs emits an audit event for an actor other than the affected user
+func (s *ConsentService) emitAuditEventAs(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, action audit.Action, resourceID string, fields []string, clientIP, requestID string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(actorID, actorType).
+		Action(action).
+		Resource(audit.ResourceConsent, resourceID).
+		FieldsChanged(fields).
+		IPHash(audit.HashIP(clientIP, s.hmacSecret)).
+		RequestID(requestID).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+	"github.com/banking/user-service/internal/repository/redis"
+)
+
+// Data export errors
+var (
+	ErrExportInProgress = errors.New("data export already in progress")
+	ErrExportNotFound   = errors.New("data export not found")
+	ErrExportNotReady   = errors.New("data export not ready")
+)
+
+// AuditLogReader returns the audit trail recorded for a user
+// Audit events are shipped to Kafka, so the reader is backed by the audit store
+type AuditLogReader interface {
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*audit.AuditEvent, error)
+}
+
+// dataExportFinishTimeout bounds recording an export's outcome and releasing its lock,
+// which must happen even when the export itself ran out of time
+const dataExportFinishTimeout = 5 * time.Second
+
+// DataExportConfig holds data export settings
+type DataExportConfig struct {
+	BundleTTL time.Duration // How long a finished bundle can be downloaded
+	Timeout   time.Duration // Upper bound for assembling a bundle
+}
+
+// DefaultDataExportConfig returns the default data export settings
+func DefaultDataExportConfig() DataExportConfig {
+	return DataExportConfig{
+		BundleTTL: 24 * time.Hour,
+		Timeout:   5 * time.Minute,
+	}
+}
+
+// DataExportService assembles data subject access exports in the background
+type DataExportService struct {
+	userRepo      *postgres.UserRepository
+	addressRepo   *postgres.AddressRepository
+	deviceRepo    *postgres.DeviceRepository
+	consentRepo   *postgres.ConsentRepository
+	prefRepo      PreferenceRepository
+	auditReader   AuditLogReader
+	exportStore   *redis.DataExportStore
+	auditProducer *events.AuditProducer
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           DataExportConfig
+
+	// Exports run in the background; Shutdown waits for them and cancels stragglers
+	runs       sync.WaitGroup
+	runCtx     context.Context
+	cancelRuns context.CancelFunc
+}
+
+// NewDataExportService creates a new data export service
+// prefRepo and auditReader may be nil; their sections are then reported as incomplete
+func NewDataExportService(
+	userRepo *postgres.UserRepository,
+	addressRepo *postgres.AddressRepository,
+	deviceRepo *postgres.DeviceRepository,
+	consentRepo *postgres.ConsentRepository,
+	prefRepo PreferenceRepository,
+	auditReader AuditLogReader,
+	exportStore *redis.DataExportStore,
+	auditProducer *events.AuditProducer,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg DataExportConfig,
+) *DataExportService {
+	defaults := DefaultDataExportConfig()
+	if cfg.BundleTTL <= 0 {
+		cfg.BundleTTL = defaults.BundleTTL
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	return &DataExportService{
+		userRepo:      userRepo,
+		addressRepo:   addressRepo,
+		deviceRepo:    deviceRepo,
+		consentRepo:   consentRepo,
+		prefRepo:      prefRepo,
+		auditReader:   auditReader,
+		exportStore:   exportStore,
+		auditProducer: auditProducer,
+		log:           log.Named("data_export_service"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+		runCtx:        runCtx,
+		cancelRuns:    cancelRuns,
+	}
+}
+
+// Shutdown waits for running exports to finish
+// Once ctx is done the remaining exports are cancelled; they are recorded as failed and
+// release their locks before Shutdown returns ctx's error
+func (s *DataExportService) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancelRuns()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// dataExportActor identifies who asked for an export, for the audit trail
+type dataExportActor struct {
+	id        string
+	actorType audit.ActorType
+	clientIP  string
+	requestID string
+}
+
+// RequestExport queues an export for the user and returns immediately
+// Only one export per user may be in progress at a time
+func (s *DataExportService) RequestExport(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, clientIP, requestID string) (*domain.DataExportJob, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	job := &domain.DataExportJob{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Status:      domain.DataExportStatusPending,
+		RequestedAt: time.Now().UTC(),
+	}
+
+	// The in-progress marker outlives the timeout slightly so a crashed worker eventually frees it
+	if err := s.exportStore.CreateJob(ctx, job, s.cfg.BundleTTL, s.cfg.Timeout+time.Minute); err != nil {
+		if errors.Is(err, redis.ErrExportInProgress) {
+			return nil, ErrExportInProgress
+		}
+		return nil, err
+	}
+
+	s.runs.Add(1)
+	go s.run(job, dataExportActor{id: actorID, actorType: actorType, clientIP: clientIP, requestID: requestID})
+
+	return job, nil
+}
+
+// GetExport returns the status of an export owned by the user
+func (s *DataExportService) GetExport(ctx context.Context, userID, exportID uuid.UUID) (*domain.DataExportJob, error) {
+	job, err := s.exportStore.GetJob(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheMiss) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	// SECURITY: Exports of other users are indistinguishable from missing ones
+	if job.UserID != userID {
+		return nil, ErrExportNotFound
+	}
+
+	return job, nil
+}
+
+// DownloadExport returns the JSON bundle of a completed export
+func (s *DataExportService) DownloadExport(ctx context.Context, userID, exportID uuid.UUID, actorID string, actorType audit.ActorType, clientIP, requestID string) ([]byte, error) {
+	job, err := s.GetExport(ctx, userID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != domain.DataExportStatusCompleted {
+		return nil, ErrExportNotReady
+	}
+
+	bundle, err := s.exportStore.GetBundle(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheMiss) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	s.emitAuditEvent(ctx, userID, dataExportActor{id: actorID, actorType: actorType, clientIP: clientIP, requestID: requestID}, []string{"data_export_download"})
+
+	return bundle, nil
+}
+
+// run assembles the bundle; it owns its own context since the request has already returned
+func (s *DataExportService) run(job *domain.DataExportJob, actor dataExportActor) {
+	defer s.runs.Done()
+
+	ctx, cancel := context.WithTimeout(s.runCtx, s.cfg.Timeout)
+	defer cancel()
+
+	// The lock is released on a fresh context, so an export that timed out or was cancelled
+	// still frees the user for the next one
+	defer func() {
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), dataExportFinishTimeout)
+		defer releaseCancel()
+		if err := s.exportStore.ReleaseUser(releaseCtx, job.UserID); err != nil {
+			s.log.Warn("failed to release data export lock", logger.ErrorField(err))
+		}
+	}()
+
+	job.Status = domain.DataExportStatusRunning
+	if err := s.exportStore.SaveJob(ctx, job, s.cfg.BundleTTL); err != nil {
+		s.log.Warn("failed to update data export status", logger.ErrorField(err))
+	}
+
+	bundle, err := s.buildBundle(ctx, job)
+	if err == nil {
+		var data []byte
+		data, err = json.Marshal(bundle)
+		if err == nil {
+			err = s.exportStore.SaveBundle(ctx, job.ID, data, s.cfg.BundleTTL)
+		}
+	}
+
+	now := time.Now().UTC()
+	job.CompletedAt = &now
+	if err != nil {
+		s.log.Error("data export failed",
+			logger.RequestID(actor.requestID),
+			logger.UserID(job.UserID.String()),
+			logger.ErrorField(err),
+		)
+		job.Status = domain.DataExportStatusFailed
+	} else {
+		expiresAt := now.Add(s.cfg.BundleTTL)
+		job.Status = domain.DataExportStatusCompleted
+		job.ExpiresAt = &expiresAt
+		job.IncompleteSections = bundle.IncompleteSections
+
+		s.emitAuditEvent(ctx, job.UserID, actor, exportedSections(bundle))
+	}
+
+	// Likewise recorded on a fresh context, so a timed out export is not left RUNNING
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), dataExportFinishTimeout)
+	defer saveCancel()
+	if err := s.exportStore.SaveJob(saveCtx, job, s.cfg.BundleTTL); err != nil {
+		s.log.Error("failed to store data export result", logger.ErrorField(err))
+	}
+}
+
+// buildBundle gathers everything held about the user
+// Core sections fail the export; optional sources are recorded as incomplete instead
+func (s *DataExportService) buildBundle(ctx context.Context, job *domain.DataExportJob) (*domain.DataExportBundle, error) {
+	user, err := s.userRepo.GetByID(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.addressRepo.ListByUserID(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.addressRepo.ListHistoryByUserID(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := s.deviceRepo.ListByUserID(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	consents, err := s.consentRepo.ListEvents(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &domain.DataExportBundle{
+		FormatVersion:  domain.DataExportFormatVersion,
+		ExportID:       job.ID,
+		UserID:         job.UserID,
+		GeneratedAt:    time.Now().UTC(),
+		Profile:        domain.NewDataExportProfile(user),
+		Addresses:      addresses,
+		AddressHistory: history,
+		Devices:        devices,
+		Consents:       consents,
+		KYC: &domain.DataExportKYC{
+			Status:      user.KYCStatus,
+			ReferenceID: user.KYCReferenceID,
+		},
+		AuditEvents: []*domain.DataExportAudit{},
+	}
+
+	if s.prefRepo == nil {
+		bundle.IncompleteSections = append(bundle.IncompleteSections, domain.DataExportSectionPreferences)
+	} else if pref, err := s.prefRepo.GetByUserID(ctx, job.UserID); err != nil {
+		s.log.Warn("failed to load preferences for data export", logger.ErrorField(err))
+		bundle.IncompleteSections = append(bundle.IncompleteSections, domain.DataExportSectionPreferences)
+	} else {
+		bundle.Preferences = pref
+	}
+
+	if s.auditReader == nil {
+		bundle.IncompleteSections = append(bundle.IncompleteSections, domain.DataExportSectionAuditEvents)
+	} else if auditEvents, err := s.auditReader.ListByUserID(ctx, job.UserID); err != nil {
+		s.log.Warn("failed to load audit events for data export", logger.ErrorField(err))
+		bundle.IncompleteSections = append(bundle.IncompleteSections, domain.DataExportSectionAuditEvents)
+	} else {
+		for _, e := range auditEvents {
+			bundle.AuditEvents = append(bundle.AuditEvents, domain.NewDataExportAudit(e))
+		}
+	}
+
+	return bundle, nil
+}
+
+// exportedSections lists the sections actually disclosed, for the audit trail
+func exportedSections(bundle *domain.DataExportBundle) []string {
+	sections := []string{
+		domain.DataExportSectionProfile,
+		domain.DataExportSectionAddresses,
+		domain.DataExportSectionAddressHistory,
+		domain.DataExportSectionDevices,
+		domain.DataExportSectionKYC,
+		domain.DataExportSectionConsents,
+	}
+	if bundle.Preferences != nil {
+		sections = append(sections, domain.DataExportSectionPreferences)
+	}
+	if !containsString(bundle.IncompleteSections, domain.DataExportSectionAuditEvents) {
+		sections = append(sections, domain.DataExportSectionAuditEvents)
+	}
+	return sections
+}
+
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *DataExportService) emitAuditEvent(ctx context.Context, userID uuid.UUID, actor dataExportActor, fields []string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(actor.id, actor.actorType).
+		Action(audit.ActionAccess).
+		Resource(audit.ResourceProfile, userID.String()).
+		FieldsChanged(fields).
+		IPHash(audit.HashIP(actor.clientIP, s.hmacSecret)).
+		RequestID(actor.requestID).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+// DeviceFeed streams device changes to the device topic consumed by the fraud service
+// A nil feed, or one without a producer, drops changes
+type DeviceFeed struct {
+	producer *events.EventProducer
+	log      *logger.Logger
+}
+
+// NewDeviceFeed creates a device feed publishing through producer
+func NewDeviceFeed(producer *events.EventProducer, log *logger.Logger) *DeviceFeed {
+	return &DeviceFeed{
+		producer: producer,
+		log:      log.Named("device_feed"),
+	}
+}
+
+// Changed publishes the current state of device
+func (f *DeviceFeed) Changed(ctx context.Context, eventType string, device *domain.Device, reason string) {
+	f.publish(ctx, eventType, device.UserID, events.DeviceChangedEvent{
+		DeviceID:  device.ID,
+		Device:    device.ToFraudData(),
+		Reason:    reason,
+		ChangedAt: time.Now().UTC(),
+	})
+}
+
+// ChangedIDs publishes a change for devices known only by ID
+func (f *DeviceFeed) ChangedIDs(ctx context.Context, eventType string, userID uuid.UUID, deviceIDs []uuid.UUID, reason string) {
+	now := time.Now().UTC()
+	for _, id := range deviceIDs {
+		f.publish(ctx, eventType, userID, events.DeviceChangedEvent{
+			DeviceID:  id,
+			Reason:    reason,
+			ChangedAt: now,
+		})
+	}
+}
+
+// publish failures are logged since the change already committed
+func (f *DeviceFeed) publish(ctx context.Context, eventType string, userID uuid.UUID, data events.DeviceChangedEvent) {
+	if f == nil || f.producer == nil {
+		return
+	}
+	if err := f.producer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		f.log.Error("failed to produce device event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
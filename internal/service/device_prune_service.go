@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+)
+
+// devicePruneActor identifies the pruning job in audit events
+const devicePruneActor = "device_prune_job"
+
+// DevicePruneConfig holds stale device pruning settings
+type DevicePruneConfig struct {
+	InactiveDays int // Devices not active for this many days are removed
+	BatchSize    int
+}
+
+// DefaultDevicePruneConfig returns the default stale device pruning settings
+func DefaultDevicePruneConfig() DevicePruneConfig {
+	return DevicePruneConfig{
+		InactiveDays: 180,
+		BatchSize:    100,
+	}
+}
+
+// DevicePruneService removes devices that have not been used for a long time
+// so that forgotten devices do not keep their trust or count against the device cap
+type DevicePruneService struct {
+	deviceRepo    *postgres.DeviceRepository
+	feed          *DeviceFeed
+	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           DevicePruneConfig
+}
+
+// NewDevicePruneService creates a new stale device pruning service
+func NewDevicePruneService(
+	deviceRepo *postgres.DeviceRepository,
+	feed *DeviceFeed,
+	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg DevicePruneConfig,
+) *DevicePruneService {
+	defaults := DefaultDevicePruneConfig()
+	if cfg.InactiveDays <= 0 {
+		cfg.InactiveDays = defaults.InactiveDays
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	return &DevicePruneService{
+		deviceRepo:    deviceRepo,
+		feed:          feed,
+		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		log:           log.Named("device_prune_service"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+	}
+}
+
+// PruneInactive removes one batch of inactive devices and returns how many were removed
+func (s *DevicePruneService) PruneInactive(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	before := now.AddDate(0, 0, -s.cfg.InactiveDays)
+
+	devices, err := s.deviceRepo.PruneInactive(ctx, before, domain.TrustReasonInactive, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, device := range devices {
+		// Trust is only reset on devices that still had it
+		wasTrusted := device.TrustReason == domain.TrustReasonInactive
+
+		fields := []string{"deleted_at"}
+		if wasTrusted {
+			fields = append(fields, "is_trusted", "trust_reason", "trusted_until")
+		}
+		s.emitAuditEvent(ctx, device.UserID, device.ID, fields)
+
+		s.publishEvent(ctx, events.EventDevicePruned, device.UserID, events.DevicePrunedEvent{
+			DeviceID:     device.ID,
+			DeviceName:   device.DeviceName,
+			DeviceType:   device.DeviceType,
+			OS:           device.OS,
+			LastActiveAt: device.LastActiveAt,
+			WasTrusted:   wasTrusted,
+			PrunedAt:     now,
+		})
+		s.feed.ChangedIDs(ctx, events.EventDeviceRemoved, device.UserID, []uuid.UUID{device.ID}, domain.DeviceRemovedPruned)
+	}
+
+	return len(devices), nil
+}
+
+func (s *DevicePruneService) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+func (s *DevicePruneService) emitAuditEvent(ctx context.Context, userID, deviceID uuid.UUID, fields []string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(devicePruneActor, audit.ActorSystem).
+		Action(audit.ActionDelete).
+		Resource(audit.ResourceDevice, deviceID.String()).
+		FieldsChanged(fields).
+		Service(devicePruneActor).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
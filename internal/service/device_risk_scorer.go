@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+	"github.com/banking/user-service/internal/repository/redis"
+)
+
+// deviceRiskActor identifies the scorer in audit events
+const deviceRiskActor = "device_risk_scorer"
+
+// Suspicion signal weights; a device showing every signal scores 100
+const (
+	weightNewDevice           = 15
+	weightUnusualLocation     = 20
+	weightMultipleUsers       = 30
+	weightRapidLocationChange = 35
+)
+
+// DeviceRiskConfig holds device suspicion scoring settings
+type DeviceRiskConfig struct {
+	Threshold         int           // Score at which the user is flagged
+	RapidChangeWindow time.Duration // A new network this soon after the last activity counts as impossible travel
+}
+
+// DefaultDeviceRiskConfig returns the default device suspicion scoring settings
+func DefaultDeviceRiskConfig() DeviceRiskConfig {
+	return DeviceRiskConfig{
+		Threshold:         50,
+		RapidChangeWindow: time.Hour,
+	}
+}
+
+// DeviceRiskScorer scores devices on registration and heartbeat
+// Only hashes are stored, so location is approximated by the hashed network prefix:
+// an unseen network is an unusual location, and an unseen network shortly after
+// activity elsewhere is treated as impossible travel
+type DeviceRiskScorer struct {
+	deviceRepo    *postgres.DeviceRepository
+	userRepo      *postgres.UserRepository
+	cache         *redis.UserCache
+	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           DeviceRiskConfig
+}
+
+// NewDeviceRiskScorer creates a new device risk scorer
+func NewDeviceRiskScorer(
+	deviceRepo *postgres.DeviceRepository,
+	userRepo *postgres.UserRepository,
+	cache *redis.UserCache,
+	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg DeviceRiskConfig,
+) *DeviceRiskScorer {
+	defaults := DefaultDeviceRiskConfig()
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = defaults.Threshold
+	}
+	if cfg.RapidChangeWindow <= 0 {
+		cfg.RapidChangeWindow = defaults.RapidChangeWindow
+	}
+	return &DeviceRiskScorer{
+		deviceRepo:    deviceRepo,
+		userRepo:      userRepo,
+		cache:         cache,
+		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		log:           log.Named("device_risk_scorer"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+	}
+}
+
+// Assess scores device as seen from clientIP and stores the result with the activity
+// When the score crosses the threshold the user is flagged and a risk event is published
+func (s *DeviceRiskScorer) Assess(ctx context.Context, device *domain.Device, isNew bool, clientIP string) (*domain.DeviceSuspicion, error) {
+	ipHash := s.deviceRepo.HashIP(clientIP)
+	networkHash := s.deviceRepo.HashIP(networkPrefix(clientIP))
+
+	history, err := s.deviceRepo.GetRiskHistory(ctx, device.UserID, device.FingerprintHash, networkHash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	suspicion := &domain.DeviceSuspicion{
+		IsNewDevice:     isNew,
+		UnusualLocation: history.UserHasActivity && !history.NetworkSeen,
+		MultipleUsers:   history.OtherUsers > 0,
+		RapidLocationChange: !history.NetworkSeen && history.LastSeenAt != nil &&
+			history.LastNetworkHash != networkHash && now.Sub(*history.LastSeenAt) < s.cfg.RapidChangeWindow,
+	}
+	scoreSuspicion(suspicion)
+
+	if err := s.deviceRepo.RecordAssessment(ctx, device, ipHash, networkHash, suspicion); err != nil {
+		return nil, err
+	}
+
+	previous := 0
+	if device.Suspicion != nil {
+		previous = device.Suspicion.SuspicionScore
+	}
+	if suspicion.SuspicionScore >= s.cfg.Threshold && previous < s.cfg.Threshold {
+		s.flagUser(ctx, device, suspicion, now)
+	}
+
+	device.Suspicion = suspicion
+	return suspicion, nil
+}
+
+// flagUser records a threshold crossing; failures are logged since the score is already stored
+func (s *DeviceRiskScorer) flagUser(ctx context.Context, device *domain.Device, suspicion *domain.DeviceSuspicion, detectedAt time.Time) {
+	added, err := s.userRepo.AddRiskFlag(ctx, device.UserID, domain.RiskFlagSuspiciousDevice)
+	if err != nil {
+		s.log.Error("failed to flag user for suspicious device", logger.UserID(device.UserID.String()), logger.ErrorField(err))
+	}
+	if added {
+		// Risk flags are part of the cached summary served to other services
+		go func(id uuid.UUID) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.cache.InvalidateUser(ctx, id); err != nil {
+				s.log.Warn("failed to invalidate cache", logger.ErrorField(err))
+			}
+		}(device.UserID)
+
+		s.emitAuditEvent(ctx, device.UserID, audit.ResourceProfile, device.UserID.String(), []string{"risk_flags"})
+	}
+	s.emitAuditEvent(ctx, device.UserID, audit.ResourceDevice, device.ID.String(), []string{"suspicion_score"})
+
+	s.publishEvent(ctx, events.EventDeviceRiskDetected, device.UserID, events.DeviceRiskDetectedEvent{
+		DeviceID:   device.ID,
+		Score:      suspicion.SuspicionScore,
+		Reasons:    suspicion.Reasons,
+		RiskFlag:   domain.RiskFlagSuspiciousDevice,
+		DetectedAt: detectedAt,
+	})
+}
+
+// scoreSuspicion fills in the reasons and score from the signals
+func scoreSuspicion(s *domain.DeviceSuspicion) {
+	s.Reasons = nil
+	s.SuspicionScore = 0
+	for _, signal := range []struct {
+		set    bool
+		reason string
+		weight int
+	}{
+		{s.IsNewDevice, domain.SuspicionNewDevice, weightNewDevice},
+		{s.UnusualLocation, domain.SuspicionUnusualLocation, weightUnusualLocation},
+		{s.MultipleUsers, domain.SuspicionMultipleUsers, weightMultipleUsers},
+		{s.RapidLocationChange, domain.SuspicionRapidLocationChange, weightRapidLocationChange},
+	} {
+		if signal.set {
+			s.Reasons = append(s.Reasons, signal.reason)
+			s.SuspicionScore += signal.weight
+		}
+	}
+	if s.SuspicionScore > 100 {
+		s.SuspicionScore = 100
+	}
+}
+
+// networkPrefix coarsens an IP to the network it belongs to: /24 for IPv4, /48 for IPv6
+// Unparseable input is returned unchanged
+func networkPrefix(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
+
+func (s *DeviceRiskScorer) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+func (s *DeviceRiskScorer) emitAuditEvent(ctx context.Context, userID uuid.UUID, resource audit.Resource, resourceID string, fields []string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(deviceRiskActor, audit.ActorSystem).
+		Action(audit.ActionUpdate).
+		Resource(resource, resourceID).
+		FieldsChanged(fields).
+		Service(deviceRiskActor).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
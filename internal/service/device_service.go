@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -18,26 +19,56 @@ var (
 	ErrDeviceNotFound = errors.New("device not found")
 )
 
+// DeviceServiceConfig holds device registration settings
+type DeviceServiceConfig struct {
+	MaxDevicesPerUser int           // Registering beyond this evicts the least recently active device
+	HeartbeatInterval time.Duration // Heartbeats from the same device and IP more often than this are not recorded
+}
+
+// DefaultDeviceServiceConfig returns the default device service settings
+func DefaultDeviceServiceConfig() DeviceServiceConfig {
+	return DeviceServiceConfig{
+		MaxDevicesPerUser: 10,
+		HeartbeatInterval: time.Minute,
+	}
+}
+
 // DeviceService handles device-related business logic
 type DeviceService struct {
 	deviceRepo    *postgres.DeviceRepository
+	riskScorer    *DeviceRiskScorer
+	feed          *DeviceFeed
 	auditProducer *events.AuditProducer
 	log           *logger.Logger
 	hmacSecret    []byte
+	cfg           DeviceServiceConfig
 }
 
 // NewDeviceService creates a new device service
 func NewDeviceService(
 	deviceRepo *postgres.DeviceRepository,
+	riskScorer *DeviceRiskScorer,
+	feed *DeviceFeed,
 	auditProducer *events.AuditProducer,
 	log *logger.Logger,
 	hmacSecret []byte,
+	cfg DeviceServiceConfig,
 ) *DeviceService {
+	defaults := DefaultDeviceServiceConfig()
+	if cfg.MaxDevicesPerUser <= 0 {
+		cfg.MaxDevicesPerUser = defaults.MaxDevicesPerUser
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaults.HeartbeatInterval
+	}
 	return &DeviceService{
 		deviceRepo:    deviceRepo,
+		riskScorer:    riskScorer,
+		feed:          feed,
 		auditProducer: auditProducer,
 		log:           log.Named("device_service"),
 		hmacSecret:    hmacSecret,
+		cfg:           cfg,
 	}
 }
 
@@ -61,6 +92,86 @@ func (s *DeviceService) ListDevices(ctx context.Context, userID uuid.UUID, curre
 	return items, nil
 }
 
+// RegisterDevice registers the requesting device, or refreshes it if already registered
+// It reports whether the device is new
+func (s *DeviceService) RegisterDevice(ctx context.Context, userID uuid.UUID, req *domain.RegisterDeviceRequest, requestID string) (*domain.Device, bool, error) {
+	device := &domain.Device{
+		UserID:          userID,
+		FingerprintHash: s.deviceRepo.HashFingerprint(req.Fingerprint),
+		DeviceType:      req.DeviceType,
+		OS:              req.OS,
+		OSVersion:       req.OSVersion,
+		AppVersion:      req.AppVersion,
+		DeviceName:      req.DeviceName,
+		LastIPHash:      s.deviceRepo.HashIP(req.IP),
+	}
+
+	res, err := s.deviceRepo.Register(ctx, device, s.cfg.MaxDevicesPerUser)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if res.Created {
+		s.emitAuditEvent(ctx, userID, audit.ActionCreate, audit.ResourceDevice, res.Device.ID.String(),
+			[]string{"device_type", "os", "device_name"}, req.IP, requestID)
+	}
+	s.auditEvictions(ctx, userID, res.Evicted, req.IP, requestID)
+	s.assessRisk(ctx, res, req.IP)
+	s.publishUpsert(ctx, res)
+
+	return res.Device, res.Created, nil
+}
+
+// Heartbeat records that the device with this fingerprint is in use, registering it if new
+func (s *DeviceService) Heartbeat(ctx context.Context, userID uuid.UUID, fingerprint, clientIP, requestID string) error {
+	res, err := s.deviceRepo.Heartbeat(ctx, userID, s.deviceRepo.HashFingerprint(fingerprint), s.deviceRepo.HashIP(clientIP),
+		s.cfg.HeartbeatInterval, s.cfg.MaxDevicesPerUser)
+	if err != nil {
+		return err
+	}
+
+	if res.Created {
+		s.emitAuditEvent(ctx, userID, audit.ActionCreate, audit.ResourceDevice, res.Device.ID.String(), []string{"fingerprint_hash"}, clientIP, requestID)
+	}
+	s.auditEvictions(ctx, userID, res.Evicted, clientIP, requestID)
+	s.assessRisk(ctx, res, clientIP)
+	s.publishUpsert(ctx, res)
+
+	return nil
+}
+
+// auditEvictions records devices removed to make room for a new one
+func (s *DeviceService) auditEvictions(ctx context.Context, userID uuid.UUID, evicted []uuid.UUID, clientIP, requestID string) {
+	for _, id := range evicted {
+		s.emitAuditEvent(ctx, userID, audit.ActionDelete, audit.ResourceDevice, id.String(), []string{"deleted_at"}, clientIP, requestID)
+	}
+}
+
+// publishUpsert streams a registered or touched device, and any it evicted, to the device feed
+// It runs after scoring so the feed carries the latest score
+func (s *DeviceService) publishUpsert(ctx context.Context, res *postgres.DeviceUpsert) {
+	if res.Device == nil {
+		return
+	}
+	eventType := events.EventDeviceUpdated
+	if res.Created {
+		eventType = events.EventDeviceRegistered
+	}
+	s.feed.Changed(ctx, eventType, res.Device, "")
+	s.feed.ChangedIDs(ctx, events.EventDeviceRemoved, res.Device.UserID, res.Evicted, domain.DeviceRemovedEvicted)
+}
+
+// assessRisk scores a device that was just registered or seen active
+// Scoring is advisory, so a failure is logged rather than failing the request
+func (s *DeviceService) assessRisk(ctx context.Context, res *postgres.DeviceUpsert, clientIP string) {
+	if s.riskScorer == nil || res.Device == nil {
+		return
+	}
+	if _, err := s.riskScorer.Assess(ctx, res.Device, res.Created, clientIP); err != nil {
+		s.log.Error("failed to assess device risk", logger.UserID(res.Device.UserID.String()), logger.ErrorField(err))
+	}
+}
+
 // RemoveDevice soft-deletes a device
 func (s *DeviceService) RemoveDevice(ctx context.Context, userID, deviceID uuid.UUID, clientIP, requestID string) error {
 	err := s.deviceRepo.SoftDelete(ctx, userID, deviceID)
@@ -73,10 +184,79 @@ func (s *DeviceService) RemoveDevice(ctx context.Context, userID, deviceID uuid.
 
 	// Emit audit event
 	s.emitAuditEvent(ctx, userID, audit.ActionDelete, audit.ResourceDevice, deviceID.String(), []string{"deleted_at"}, clientIP, requestID)
+	s.feed.ChangedIDs(ctx, events.EventDeviceRemoved, userID, []uuid.UUID{deviceID}, domain.DeviceRemovedByUser)
 
 	return nil
 }
 
+// GetFraudData returns the user's active devices for the fraud service
+func (s *DeviceService) GetFraudData(ctx context.Context, userID uuid.UUID, serviceName, requestID string) ([]*domain.DeviceForFraud, error) {
+	devices, err := s.deviceRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditFraudAccess(ctx, devices, userID, userID.String(), serviceName, requestID)
+
+	return toFraudData(devices), nil
+}
+
+// FindByFingerprint returns the active devices of every user sharing the fingerprint hash
+func (s *DeviceService) FindByFingerprint(ctx context.Context, fingerprintHash, serviceName, requestID string) ([]*domain.DeviceForFraud, error) {
+	devices, err := s.deviceRepo.ListByFingerprint(ctx, fingerprintHash)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditFraudAccess(ctx, devices, uuid.Nil, fingerprintHash, serviceName, requestID)
+
+	return toFraudData(devices), nil
+}
+
+// auditFraudAccess records each device disclosed to another service
+// A lookup that matched nothing is still recorded against what was looked up
+func (s *DeviceService) auditFraudAccess(ctx context.Context, devices []*domain.Device, userID uuid.UUID, lookup, serviceName, requestID string) {
+	if len(devices) == 0 {
+		s.emitAccessEvent(ctx, userID, lookup, serviceName, requestID)
+		return
+	}
+	for _, device := range devices {
+		s.emitAccessEvent(ctx, device.UserID, device.ID.String(), serviceName, requestID)
+	}
+}
+
+func toFraudData(devices []*domain.Device) []*domain.DeviceForFraud {
+	data := make([]*domain.DeviceForFraud, 0, len(devices))
+	for _, device := range devices {
+		data = append(data, device.ToFraudData())
+	}
+	return data
+}
+
+// emitAccessEvent records another service reading device data
+func (s *DeviceService) emitAccessEvent(ctx context.Context, userID uuid.UUID, resourceID, serviceName, requestID string) {
+	builder := audit.NewAuditEvent(s.hmacSecret).
+		Actor(serviceName, audit.ActorService).
+		Action(audit.ActionAccess).
+		Resource(audit.ResourceDevice, resourceID).
+		FieldsChanged([]string{"fraud_data"}).
+		RequestID(requestID).
+		Service(serviceName)
+	if userID != uuid.Nil {
+		builder = builder.UserID(userID.String())
+	}
+
+	event, err := builder.Build()
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
+
 func (s *DeviceService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
 	event, err := audit.NewAuditEvent(s.hmacSecret).
 		UserID(userID.String()).
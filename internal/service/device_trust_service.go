@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/crypto"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+	"github.com/banking/user-service/internal/repository/redis"
+)
+
+// Device trust errors
+var (
+	ErrStepUpRequired = errors.New("recent step-up authentication required")
+)
+
+// stepUpClockSkew tolerates an auth_time slightly ahead of our clock
+const stepUpClockSkew = time.Minute
+
+// DeviceTrustConfig holds device trust settings
+type DeviceTrustConfig struct {
+	TrustTTL     time.Duration // How long a device stays trusted before step-up is needed again
+	StepUpMaxAge time.Duration // How recent the token's auth_time must be to trust without a code
+	StepUpACRs   []string      // acr values accepted as step-up; empty accepts any recent authentication
+
+	// Step-up codes follow the phone verification limits
+	CodeTTL        time.Duration
+	ResendCooldown time.Duration
+	MaxAttempts    int
+	Lockout        time.Duration
+}
+
+// DefaultDeviceTrustConfig returns the default device trust settings
+func DefaultDeviceTrustConfig() DeviceTrustConfig {
+	otp := DefaultPhoneVerificationConfig()
+	return DeviceTrustConfig{
+		TrustTTL:       30 * 24 * time.Hour,
+		StepUpMaxAge:   5 * time.Minute,
+		CodeTTL:        otp.CodeTTL,
+		ResendCooldown: otp.ResendCooldown,
+		MaxAttempts:    otp.MaxAttempts,
+		Lockout:        otp.Lockout,
+	}
+}
+
+// StepUp is the authentication context the request's access token carries
+type StepUp struct {
+	AuthTime time.Time // Zero when the token has no auth_time
+	ACR      string
+}
+
+// DeviceTrustService lets users trust the device they are on after a step-up, and
+// revokes that trust, or the devices themselves, when the account may be at risk
+type DeviceTrustService struct {
+	deviceRepo    *postgres.DeviceRepository
+	userRepo      *postgres.UserRepository
+	otpStore      *redis.OTPStore
+	feed          *DeviceFeed
+	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	notifier      *Notifier
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           DeviceTrustConfig
+}
+
+// NewDeviceTrustService creates a new device trust service
+func NewDeviceTrustService(
+	deviceRepo *postgres.DeviceRepository,
+	userRepo *postgres.UserRepository,
+	otpStore *redis.OTPStore,
+	feed *DeviceFeed,
+	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	notifier *Notifier,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg DeviceTrustConfig,
+) *DeviceTrustService {
+	defaults := DefaultDeviceTrustConfig()
+	if cfg.TrustTTL <= 0 {
+		cfg.TrustTTL = defaults.TrustTTL
+	}
+	if cfg.StepUpMaxAge <= 0 {
+		cfg.StepUpMaxAge = defaults.StepUpMaxAge
+	}
+	if cfg.CodeTTL <= 0 {
+		cfg.CodeTTL = defaults.CodeTTL
+	}
+	if cfg.ResendCooldown <= 0 {
+		cfg.ResendCooldown = defaults.ResendCooldown
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.Lockout <= 0 {
+		cfg.Lockout = defaults.Lockout
+	}
+	return &DeviceTrustService{
+		deviceRepo:    deviceRepo,
+		userRepo:      userRepo,
+		otpStore:      otpStore,
+		feed:          feed,
+		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		notifier:      notifier,
+		log:           log.Named("device_trust_service"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+	}
+}
+
+// StartStepUp texts a code to the user's verified phone that trusts the current device
+func (s *DeviceTrustService) StartStepUp(ctx context.Context, userID uuid.UUID, fingerprint, clientIP, requestID string) (*domain.PhoneVerificationResponse, error) {
+	device, err := s.currentDevice(ctx, userID, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if !user.IsPhoneVerified() {
+		return nil, ErrPhoneNotVerified
+	}
+
+	// Locked out users cannot request fresh codes to reset their attempts
+	attempts, err := s.otpStore.StepUpOTPAttempts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if attempts >= int64(s.cfg.MaxAttempts) {
+		return nil, ErrOTPAttemptsExceeded
+	}
+
+	code, err := crypto.GenerateNumericCode(otpDigits)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(s.cfg.CodeTTL)
+
+	err = s.otpStore.SaveStepUpOTP(ctx, userID, &redis.StepUpOTP{
+		CodeHash:        hashOTP(s.hmacSecret, userID, code),
+		FingerprintHash: device.FingerprintHash,
+		ExpiresAt:       expiresAt,
+	}, s.cfg.ResendCooldown)
+	if err != nil {
+		if errors.Is(err, redis.ErrOTPCooldown) {
+			return nil, ErrOTPCooldown
+		}
+		return nil, err
+	}
+
+	s.notifier.Send(ctx, events.EventDeviceStepUpRequested, userID, events.ContactNotification{
+		Channel:   string(domain.ChannelSMS),
+		Recipient: user.Phone,
+		Template:  events.TemplateDeviceStepUp,
+		Params: map[string]string{
+			"code":        code,
+			"device_name": device.DeviceName,
+		},
+	})
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, device.ID.String(), []string{"step_up_requested"}, clientIP, requestID)
+
+	return &domain.PhoneVerificationResponse{
+		Phone:     user.MaskedPhone(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// TrustCurrentDevice trusts the device with this fingerprint for the trust TTL
+// A fresh step-up in the access token is enough; otherwise the code from StartStepUp is required
+func (s *DeviceTrustService) TrustCurrentDevice(ctx context.Context, userID uuid.UUID, fingerprint string, stepUp StepUp, req *domain.TrustDeviceRequest, clientIP, requestID string) (*domain.Device, error) {
+	device, err := s.currentDevice(ctx, userID, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var reason string
+	switch {
+	case isFreshStepUp(stepUp, s.cfg.StepUpMaxAge, s.cfg.StepUpACRs, now):
+		reason = domain.TrustReasonStepUpAuth
+	case req.Code != "":
+		if err := s.verifyCode(ctx, userID, device.FingerprintHash, req.Code); err != nil {
+			return nil, err
+		}
+		reason = domain.TrustReasonStepUpOTP
+	default:
+		return nil, ErrStepUpRequired
+	}
+
+	trustedUntil := now.Add(s.cfg.TrustTTL)
+	if err := s.deviceRepo.Trust(ctx, userID, device.ID, reason, trustedUntil); err != nil {
+		if errors.Is(err, postgres.ErrDeviceNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, err
+	}
+
+	if reason == domain.TrustReasonStepUpOTP {
+		if err := s.otpStore.DeleteStepUpOTP(ctx, userID); err != nil {
+			s.log.Warn("failed to delete step-up otp", logger.ErrorField(err))
+		}
+	}
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, device.ID.String(), []string{"is_trusted", "trust_reason", "trusted_until"}, clientIP, requestID)
+
+	device.IsTrusted = true
+	device.TrustReason = reason
+	device.TrustedUntil = &trustedUntil
+	s.feed.Changed(ctx, events.EventDeviceTrustChanged, device, reason)
+
+	return device, nil
+}
+
+// verifyCode checks a step-up code; it only trusts the device it was requested from
+func (s *DeviceTrustService) verifyCode(ctx context.Context, userID uuid.UUID, fingerprintHash, code string) error {
+	otp, err := s.otpStore.GetStepUpOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheMiss) {
+			return ErrInvalidOTP
+		}
+		return err
+	}
+
+	// Count the attempt before comparing so concurrent guesses cannot exceed the limit
+	attempts, err := s.otpStore.IncrementStepUpOTPAttempts(ctx, userID, s.cfg.Lockout)
+	if err != nil {
+		return err
+	}
+	if attempts > int64(s.cfg.MaxAttempts) {
+		return ErrOTPAttemptsExceeded
+	}
+
+	if !hmac.Equal([]byte(otp.CodeHash), []byte(hashOTP(s.hmacSecret, userID, code))) {
+		return ErrInvalidOTP
+	}
+	if otp.FingerprintHash != fingerprintHash {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+// UntrustDevice revokes the user's trust in one of their devices
+func (s *DeviceTrustService) UntrustDevice(ctx context.Context, userID, deviceID uuid.UUID, clientIP, requestID string) error {
+	wasTrusted, err := s.deviceRepo.Untrust(ctx, userID, deviceID, domain.TrustReasonUserRevoked)
+	if err != nil {
+		if errors.Is(err, postgres.ErrDeviceNotFound) {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+
+	if wasTrusted {
+		s.emitAuditEvent(ctx, userID, audit.ActionUpdate, deviceID.String(), []string{"is_trusted", "trust_reason", "trusted_until"}, clientIP, requestID)
+		s.feed.ChangedIDs(ctx, events.EventDeviceTrustChanged, userID, []uuid.UUID{deviceID}, domain.TrustReasonUserRevoked)
+	}
+
+	return nil
+}
+
+// ReportCompromise revokes trust in all of the user's devices after they report
+// their account or a device compromised
+func (s *DeviceTrustService) ReportCompromise(ctx context.Context, userID uuid.UUID, clientIP, requestID string) error {
+	return s.untrustAll(ctx, userID, domain.TrustReasonCompromiseReported, userID.String(), audit.ActorUser, "", clientIP, requestID)
+}
+
+// UntrustAllForService revokes trust in all of the user's devices on behalf of another
+// service, e.g. the auth service after a password change
+func (s *DeviceTrustService) UntrustAllForService(ctx context.Context, userID uuid.UUID, reason, serviceName, requestID string) error {
+	return s.untrustAll(ctx, userID, reason, serviceName, audit.ActorService, serviceName, "", requestID)
+}
+
+func (s *DeviceTrustService) untrustAll(ctx context.Context, userID uuid.UUID, reason, actorID string, actorType audit.ActorType, serviceName, clientIP, requestID string) error {
+	ids, err := s.deviceRepo.UntrustAll(ctx, userID, reason)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		s.emitAuditEventAs(ctx, userID, actorID, actorType, serviceName, audit.ActionUpdate, id.String(),
+			[]string{"is_trusted", "trust_reason", "trusted_until"}, clientIP, requestID)
+	}
+
+	s.feed.ChangedIDs(ctx, events.EventDeviceTrustChanged, userID, ids, reason)
+
+	if len(ids) > 0 {
+		s.publishEvent(ctx, events.EventDevicesUntrusted, userID, events.DevicesUntrustedEvent{
+			DeviceIDs:   ids,
+			Reason:      reason,
+			ActorType:   actorType,
+			UntrustedAt: time.Now().UTC(),
+		})
+	}
+
+	return nil
+}
+
+// RevokeOtherDevices removes every device of the user except the one with this fingerprint
+// It returns the number of devices removed
+func (s *DeviceTrustService) RevokeOtherDevices(ctx context.Context, userID uuid.UUID, fingerprint, clientIP, requestID string) (int, error) {
+	// An unregistered fingerprint would otherwise revoke every device, including the caller's
+	device, err := s.currentDevice(ctx, userID, fingerprint)
+	if err != nil {
+		return 0, err
+	}
+
+	ids, err := s.deviceRepo.SoftDeleteAllExcept(ctx, userID, device.FingerprintHash)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		s.emitAuditEvent(ctx, userID, audit.ActionDelete, id.String(), []string{"deleted_at"}, clientIP, requestID)
+	}
+
+	s.feed.ChangedIDs(ctx, events.EventDeviceRemoved, userID, ids, domain.DeviceRemovedRevoked)
+
+	if len(ids) > 0 {
+		s.publishEvent(ctx, events.EventDevicesRevoked, userID, events.DevicesRevokedEvent{
+			DeviceIDs: ids,
+			RevokedAt: time.Now().UTC(),
+		})
+	}
+
+	return len(ids), nil
+}
+
+// currentDevice loads the registered device making the request
+func (s *DeviceTrustService) currentDevice(ctx context.Context, userID uuid.UUID, fingerprint string) (*domain.Device, error) {
+	device, err := s.deviceRepo.GetByFingerprint(ctx, userID, s.deviceRepo.HashFingerprint(fingerprint))
+	if err != nil {
+		if errors.Is(err, postgres.ErrDeviceNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, err
+	}
+	return device, nil
+}
+
+// isFreshStepUp reports whether the user authenticated within maxAge with an accepted acr
+// An empty acrs list accepts any recent authentication
+func isFreshStepUp(stepUp StepUp, maxAge time.Duration, acrs []string, now time.Time) bool {
+	if stepUp.AuthTime.IsZero() {
+		return false
+	}
+	if now.Sub(stepUp.AuthTime) > maxAge || stepUp.AuthTime.After(now.Add(stepUpClockSkew)) {
+		return false
+	}
+	return len(acrs) == 0 || slices.Contains(acrs, stepUp.ACR)
+}
+
+func (s *DeviceTrustService) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+func (s *DeviceTrustService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, deviceID string, fields []string, clientIP, requestID string) {
+	s.emitAuditEventAs(ctx, userID, userID.String(), audit.ActorUser, "", action, deviceID, fields, clientIP, requestID)
+}
+
+// emitAuditEventAs emits an audit event for an actor other than the affected user
+func (s *DeviceTrustService) emitAuditEventAs(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, serviceName string, action audit.Action, deviceID string, fields []string, clientIP, requestID string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(actorID, actorType).
+		Action(action).
+		Resource(audit.ResourceDevice, deviceID).
+		FieldsChanged(fields).
+		IPHash(audit.HashIP(clientIP, s.hmacSecret)).
+		RequestID(requestID).
+		Service(serviceName).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
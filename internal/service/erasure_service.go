@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+	"github.com/banking/user-service/internal/repository/redis"
+)
+
+// erasureActor identifies the erasure job in audit events
+const erasureActor = "erasure_job"
+
+// erasedFields lists what an erasure wipes; recorded in the audit trail instead of values
+var erasedFields = []string{
+	"legal_name", "email", "phone", "dob", "pending_email",
+	"addresses", "address_history", "device_names", "device_ips",
+	"preferences", "preference_history", "data_exports",
+}
+
+// PreferenceEraser deletes everything the preference store holds for a user
+type PreferenceEraser interface {
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// ErasureConfig holds erasure settings
+type ErasureConfig struct {
+	RetentionPeriod time.Duration // Time after soft delete before PII is wiped
+	BatchSize       int
+}
+
+// DefaultErasureConfig returns the default erasure settings
+func DefaultErasureConfig() ErasureConfig {
+	return ErasureConfig{
+		RetentionPeriod: 90 * 24 * time.Hour,
+		BatchSize:       100,
+	}
+}
+
+// ErasureService wipes PII of soft-deleted accounts once retention has passed
+// Accounts flagged with a legal hold are skipped until the flag is removed
+type ErasureService struct {
+	erasureRepo   *postgres.ErasureRepository
+	prefEraser    PreferenceEraser
+	exportStore   *redis.DataExportStore
+	cache         *redis.UserCache
+	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           ErasureConfig
+}
+
+// NewErasureService creates a new erasure service
+func NewErasureService(
+	erasureRepo *postgres.ErasureRepository,
+	prefEraser PreferenceEraser,
+	exportStore *redis.DataExportStore,
+	cache *redis.UserCache,
+	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg ErasureConfig,
+) *ErasureService {
+	defaults := DefaultErasureConfig()
+	if cfg.RetentionPeriod <= 0 {
+		cfg.RetentionPeriod = defaults.RetentionPeriod
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	return &ErasureService{
+		erasureRepo:   erasureRepo,
+		prefEraser:    prefEraser,
+		exportStore:   exportStore,
+		cache:         cache,
+		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		log:           log.Named("erasure_service"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+	}
+}
+
+// EraseExpired erases one batch of accounts past retention and returns how many were erased
+// A failure on one account is logged and does not stop the rest of the batch
+func (s *ErasureService) EraseExpired(ctx context.Context) (int, error) {
+	cutoff := time.Now().UTC().Add(-s.cfg.RetentionPeriod)
+
+	ids, err := s.erasureRepo.ListErasable(ctx, cutoff, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return erased, ctx.Err()
+		}
+
+		result, err := s.erasureRepo.Erase(ctx, id, cutoff, func(ctx context.Context) error {
+			return s.eraseElsewhere(ctx, id)
+		})
+		if err != nil {
+			// Restored, put on hold or taken by another instance since listing
+			if errors.Is(err, postgres.ErrNotErasable) {
+				continue
+			}
+			s.log.Error("failed to erase user", logger.UserID(id.String()), logger.ErrorField(err))
+			continue
+		}
+		erased++
+
+		if err := s.cache.InvalidateUser(ctx, id); err != nil {
+			s.log.Warn("failed to invalidate cache", logger.ErrorField(err))
+		}
+
+		s.publishEvent(ctx, events.EventUserErased, id, events.UserErasedEvent{
+			ErasedAt: result.ErasedAt,
+		})
+
+		s.emitAuditEvent(ctx, id)
+	}
+
+	return erased, nil
+}
+
+// eraseElsewhere deletes the user's preferences with their version history, and any export
+// bundles still cached, since those are copies of everything else erased
+func (s *ErasureService) eraseElsewhere(ctx context.Context, userID uuid.UUID) error {
+	if err := s.prefEraser.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+	return s.exportStore.DeleteUserExports(ctx, userID)
+}
+
+func (s *ErasureService) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+func (s *ErasureService) emitAuditEvent(ctx context.Context, userID uuid.UUID) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(erasureActor, audit.ActorSystem).
+		Action(audit.ActionErased).
+		Resource(audit.ResourceProfile, userID.String()).
+		FieldsChanged(erasedFields).
+		Service(erasureActor).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/mongodb"
+	"github.com/banking/user-service/internal/repository/postgres"
+)
+
+// Feature flag service errors
+var (
+	ErrFeatureFlagNotFound = errors.New("feature flag not found")
+	ErrFeatureFlagExists   = errors.New("feature flag already exists")
+)
+
+// FeatureFlagRepository defines the interface for feature flag definition storage
+// Update only saves if flag.Version is still the stored version
+type FeatureFlagRepository interface {
+	List(ctx context.Context) ([]*domain.FeatureFlag, error)
+	Get(ctx context.Context, key string) (*domain.FeatureFlag, error)
+	Create(ctx context.Context, flag *domain.FeatureFlag) error
+	Update(ctx context.Context, flag *domain.FeatureFlag) error
+	Delete(ctx context.Context, key string) error
+}
+
+// FeatureFlagService evaluates feature flags for users and manages their definitions
+type FeatureFlagService struct {
+	flagRepo      FeatureFlagRepository
+	prefRepo      PreferenceRepository
+	userRepo      *postgres.UserRepository
+	deviceRepo    *postgres.DeviceRepository
+	auditProducer *events.AuditProducer
+	log           *logger.Logger
+	hmacSecret    []byte
+}
+
+// NewFeatureFlagService creates a new feature flag service
+func NewFeatureFlagService(
+	flagRepo FeatureFlagRepository,
+	prefRepo PreferenceRepository,
+	userRepo *postgres.UserRepository,
+	deviceRepo *postgres.DeviceRepository,
+	auditProducer *events.AuditProducer,
+	log *logger.Logger,
+	hmacSecret []byte,
+) *FeatureFlagService {
+	return &FeatureFlagService{
+		flagRepo:      flagRepo,
+		prefRepo:      prefRepo,
+		userRepo:      userRepo,
+		deviceRepo:    deviceRepo,
+		auditProducer: auditProducer,
+		log:           log.Named("feature_flag_service"),
+		hmacSecret:    hmacSecret,
+	}
+}
+
+// EvaluateFlags returns every flag's value for the user
+// fingerprint identifies the calling device for device OS targeting; it may be empty
+func (s *FeatureFlagService) EvaluateFlags(ctx context.Context, userID uuid.UUID, fingerprint string) (*domain.FeatureFlagSet, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	defs, err := s.flagRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := domain.FlagSubject{
+		UserID:    userID,
+		Country:   user.Country,
+		KYCStatus: user.KYCStatus,
+		DeviceOS:  s.deviceOS(ctx, userID, fingerprint),
+	}
+
+	return &domain.FeatureFlagSet{
+		Flags:       domain.EvaluateFeatureFlags(defs, s.overrides(ctx, userID), subject),
+		EvaluatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// ListFlags returns every flag definition
+func (s *FeatureFlagService) ListFlags(ctx context.Context) ([]*domain.FeatureFlag, error) {
+	return s.flagRepo.List(ctx)
+}
+
+// GetFlag returns a flag definition
+func (s *FeatureFlagService) GetFlag(ctx context.Context, key string) (*domain.FeatureFlag, error) {
+	flag, err := s.flagRepo.Get(ctx, key)
+	if err != nil {
+		return nil, mapFeatureFlagError(err)
+	}
+	return flag, nil
+}
+
+// CreateFlag defines a new feature flag
+func (s *FeatureFlagService) CreateFlag(ctx context.Context, req *domain.CreateFeatureFlagRequest, adminID, clientIP, requestID string) (*domain.FeatureFlag, error) {
+	flag := &domain.FeatureFlag{
+		Key:            req.Key,
+		Description:    req.Description,
+		Enabled:        req.Enabled,
+		RolloutPercent: req.RolloutPercent,
+		Targeting:      req.Targeting,
+		UpdatedBy:      adminID,
+	}
+
+	if err := s.flagRepo.Create(ctx, flag); err != nil {
+		return nil, mapFeatureFlagError(err)
+	}
+
+	s.emitAuditEvent(ctx, adminID, audit.ActionCreate, flag.Key, []string{"enabled", "rollout_percent", "targeting"}, clientIP, requestID)
+
+	return flag, nil
+}
+
+// UpdateFlag changes a flag definition if req.Version is still current
+func (s *FeatureFlagService) UpdateFlag(ctx context.Context, key string, req *domain.UpdateFeatureFlagRequest, adminID, clientIP, requestID string) (*domain.FeatureFlag, error) {
+	flag, err := s.flagRepo.Get(ctx, key)
+	if err != nil {
+		return nil, mapFeatureFlagError(err)
+	}
+	if flag.Version != req.Version {
+		return nil, ErrOptimisticLock
+	}
+
+	changedFields := []string{}
+
+	if req.Description != nil && *req.Description != flag.Description {
+		flag.Description = *req.Description
+		changedFields = append(changedFields, "description")
+	}
+	if req.Enabled != nil && *req.Enabled != flag.Enabled {
+		flag.Enabled = *req.Enabled
+		changedFields = append(changedFields, "enabled")
+	}
+	if req.RolloutPercent != nil && *req.RolloutPercent != flag.RolloutPercent {
+		flag.RolloutPercent = *req.RolloutPercent
+		changedFields = append(changedFields, "rollout_percent")
+	}
+	if req.Targeting != nil {
+		flag.Targeting = *req.Targeting
+		changedFields = append(changedFields, "targeting")
+	}
+
+	if len(changedFields) == 0 {
+		return flag, nil
+	}
+
+	flag.UpdatedBy = adminID
+	if err := s.flagRepo.Update(ctx, flag); err != nil {
+		return nil, mapFeatureFlagError(err)
+	}
+
+	s.emitAuditEvent(ctx, adminID, audit.ActionUpdate, flag.Key, changedFields, clientIP, requestID)
+
+	return flag, nil
+}
+
+// DeleteFlag removes a flag definition; users' overrides keep being served
+func (s *FeatureFlagService) DeleteFlag(ctx context.Context, key, adminID, clientIP, requestID string) error {
+	if err := s.flagRepo.Delete(ctx, key); err != nil {
+		return mapFeatureFlagError(err)
+	}
+
+	s.emitAuditEvent(ctx, adminID, audit.ActionDelete, key, nil, clientIP, requestID)
+
+	return nil
+}
+
+// overrides returns the user's explicit flag values
+// Flags are still evaluated from their definitions while preferences are unavailable
+func (s *FeatureFlagService) overrides(ctx context.Context, userID uuid.UUID) map[string]bool {
+	if s.prefRepo == nil {
+		return nil
+	}
+	pref, err := s.prefRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, mongodb.ErrPreferenceNotFound) {
+			s.log.Warn("failed to get feature flag overrides", logger.ErrorField(err))
+		}
+		return nil
+	}
+	return pref.FeatureFlags
+}
+
+// deviceOS returns the OS of the calling device, or "" if it is not a registered device
+func (s *FeatureFlagService) deviceOS(ctx context.Context, userID uuid.UUID, fingerprint string) domain.DeviceOS {
+	if fingerprint == "" {
+		return ""
+	}
+	device, err := s.deviceRepo.GetByFingerprint(ctx, userID, s.deviceRepo.HashFingerprint(fingerprint))
+	if err != nil {
+		if !errors.Is(err, postgres.ErrDeviceNotFound) {
+			s.log.Warn("failed to get device for feature flags", logger.ErrorField(err))
+		}
+		return ""
+	}
+	return device.OS
+}
+
+func mapFeatureFlagError(err error) error {
+	switch {
+	case errors.Is(err, mongodb.ErrFeatureFlagNotFound):
+		return ErrFeatureFlagNotFound
+	case errors.Is(err, mongodb.ErrFeatureFlagExists):
+		return ErrFeatureFlagExists
+	case errors.Is(err, mongodb.ErrOptimisticLock):
+		return ErrOptimisticLock
+	default:
+		return err
+	}
+}
+
+// emitAuditEvent records an admin changing a flag definition
+// Definitions belong to no user, so the event is recorded against the admin
+func (s *FeatureFlagService) emitAuditEvent(ctx context.Context, adminID string, action audit.Action, key string, fields []string, clientIP, requestID string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(adminID).
+		Actor(adminID, audit.ActorAdmin).
+		Action(action).
+		Resource(audit.ResourceFeatureFlag, key).
+		FieldsChanged(fields).
+		IPHash(audit.HashIP(clientIP, s.hmacSecret)).
+		RequestID(requestID).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
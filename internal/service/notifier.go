@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+)
+
+// Notifier hands contact notifications to the notification service over its own topic
+// Notifications carry tokens, one-time codes and raw contact details, so they are kept off
+// the user events topic every other consumer reads
+// A nil notifier, or one without a producer, drops notifications
+type Notifier struct {
+	producer *events.EventProducer
+	log      *logger.Logger
+}
+
+// NewNotifier creates a notifier publishing through producer
+func NewNotifier(producer *events.EventProducer, log *logger.Logger) *Notifier {
+	return &Notifier{
+		producer: producer,
+		log:      log.Named("notifier"),
+	}
+}
+
+// Send publishes n for the user; failures are logged since the change already committed
+func (n *Notifier) Send(ctx context.Context, eventType string, userID uuid.UUID, notification events.ContactNotification) {
+	if n == nil || n.producer == nil {
+		return
+	}
+	if err := n.producer.ProduceUserEvent(ctx, eventType, userID, notification); err != nil {
+		n.log.Error("failed to produce notification", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
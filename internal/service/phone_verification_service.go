@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/crypto"
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+	"github.com/banking/user-service/internal/repository/redis"
+)
+
+// Phone verification errors
+var (
+	ErrPhoneNotVerified    = errors.New("phone number not verified")
+	ErrInvalidOTP          = errors.New("invalid or expired verification code")
+	ErrOTPAttemptsExceeded = errors.New("too many verification attempts")
+	ErrOTPCooldown         = errors.New("verification code sent too recently")
+)
+
+// otpDigits is the length of SMS verification codes
+const otpDigits = 6
+
+// PhoneVerificationConfig holds OTP settings
+type PhoneVerificationConfig struct {
+	CodeTTL        time.Duration
+	ResendCooldown time.Duration
+	MaxAttempts    int
+	Lockout        time.Duration // How long failed attempts are remembered
+}
+
+// DefaultPhoneVerificationConfig returns the default OTP settings
+func DefaultPhoneVerificationConfig() PhoneVerificationConfig {
+	return PhoneVerificationConfig{
+		CodeTTL:        5 * time.Minute,
+		ResendCooldown: time.Minute,
+		MaxAttempts:    5,
+		Lockout:        30 * time.Minute,
+	}
+}
+
+// PhoneVerificationService proves ownership of a user's phone number via SMS OTP
+type PhoneVerificationService struct {
+	userRepo      *postgres.UserRepository
+	otpStore      *redis.OTPStore
+	cache         *redis.UserCache
+	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	notifier      *Notifier
+	log           *logger.Logger
+	hmacSecret    []byte
+	cfg           PhoneVerificationConfig
+}
+
+// NewPhoneVerificationService creates a new phone verification service
+func NewPhoneVerificationService(
+	userRepo *postgres.UserRepository,
+	otpStore *redis.OTPStore,
+	cache *redis.UserCache,
+	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	notifier *Notifier,
+	log *logger.Logger,
+	hmacSecret []byte,
+	cfg PhoneVerificationConfig,
+) *PhoneVerificationService {
+	defaults := DefaultPhoneVerificationConfig()
+	if cfg.CodeTTL <= 0 {
+		cfg.CodeTTL = defaults.CodeTTL
+	}
+	if cfg.ResendCooldown <= 0 {
+		cfg.ResendCooldown = defaults.ResendCooldown
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.Lockout <= 0 {
+		cfg.Lockout = defaults.Lockout
+	}
+	return &PhoneVerificationService{
+		userRepo:      userRepo,
+		otpStore:      otpStore,
+		cache:         cache,
+		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		notifier:      notifier,
+		log:           log.Named("phone_verification_service"),
+		hmacSecret:    hmacSecret,
+		cfg:           cfg,
+	}
+}
+
+// StartVerification sends a one-time code to the user's current phone number
+func (s *PhoneVerificationService) StartVerification(ctx context.Context, userID uuid.UUID, clientIP, requestID string) (*domain.PhoneVerificationResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.Phone == "" || user.PhoneHash == nil || user.IsPhoneVerified() {
+		return nil, ErrInvalidInput
+	}
+
+	// Locked out users cannot request fresh codes to reset their attempts
+	attempts, err := s.otpStore.PhoneOTPAttempts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if attempts >= int64(s.cfg.MaxAttempts) {
+		return nil, ErrOTPAttemptsExceeded
+	}
+
+	code, err := crypto.GenerateNumericCode(otpDigits)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(s.cfg.CodeTTL)
+
+	err = s.otpStore.SavePhoneOTP(ctx, userID, &redis.PhoneOTP{
+		CodeHash:  s.hashCode(userID, code),
+		PhoneHash: *user.PhoneHash,
+		ExpiresAt: expiresAt,
+	}, s.cfg.ResendCooldown)
+	if err != nil {
+		if errors.Is(err, redis.ErrOTPCooldown) {
+			return nil, ErrOTPCooldown
+		}
+		return nil, err
+	}
+
+	s.notifier.Send(ctx, events.EventPhoneVerificationRequested, userID, events.ContactNotification{
+		Channel:   string(domain.ChannelSMS),
+		Recipient: user.Phone,
+		Template:  events.TemplatePhoneVerification,
+		Params: map[string]string{
+			"code": code,
+		},
+	})
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourceProfile, userID.String(), []string{"phone_verification_requested"}, clientIP, requestID)
+
+	return &domain.PhoneVerificationResponse{
+		Phone:     user.MaskedPhone(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ConfirmVerification checks the submitted code and marks the phone as verified
+func (s *PhoneVerificationService) ConfirmVerification(ctx context.Context, userID uuid.UUID, req *domain.ConfirmPhoneVerificationRequest, clientIP, requestID string) error {
+	otp, err := s.otpStore.GetPhoneOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheMiss) {
+			return ErrInvalidOTP
+		}
+		return err
+	}
+
+	// Count the attempt before comparing so concurrent guesses cannot exceed the limit
+	attempts, err := s.otpStore.IncrementPhoneOTPAttempts(ctx, userID, s.cfg.Lockout)
+	if err != nil {
+		return err
+	}
+	if attempts > int64(s.cfg.MaxAttempts) {
+		return ErrOTPAttemptsExceeded
+	}
+
+	if !hmac.Equal([]byte(otp.CodeHash), []byte(s.hashCode(userID, req.Code))) {
+		return ErrInvalidOTP
+	}
+
+	// Fails if the phone number was changed after the code was sent
+	if err := s.userRepo.MarkPhoneVerified(ctx, userID, otp.PhoneHash); err != nil {
+		if errors.Is(err, postgres.ErrPhoneMismatch) {
+			return ErrInvalidOTP
+		}
+		return err
+	}
+
+	if err := s.otpStore.DeletePhoneOTP(ctx, userID); err != nil {
+		s.log.Warn("failed to delete phone otp", logger.ErrorField(err))
+	}
+
+	// Invalidate cache in background with timeout to prevent goroutine leaks
+	go func(id uuid.UUID) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.cache.InvalidateUser(ctx, id); err != nil {
+			s.log.Warn("failed to invalidate cache", logger.ErrorField(err))
+		}
+	}(userID)
+
+	s.publishEvent(ctx, events.EventPhoneVerified, userID, nil)
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourceProfile, userID.String(), []string{"phone_verified_at"}, clientIP, requestID)
+
+	return nil
+}
+
+// IsPhoneVerified reports whether SMS may be used for the user
+func (s *PhoneVerificationService) IsPhoneVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return s.userRepo.IsPhoneVerified(ctx, userID)
+}
+
+func (s *PhoneVerificationService) hashCode(userID uuid.UUID, code string) string {
+	return hashOTP(s.hmacSecret, userID, code)
+}
+
+// hashOTP binds the code to the user so a stored hash cannot be replayed for another account
+func hashOTP(secret []byte, userID uuid.UUID, code string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(userID.String() + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *PhoneVerificationService) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+func (s *PhoneVerificationService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(userID.String(), audit.ActorUser).
+		Action(action).
+		Resource(resource, resourceID).
+		FieldsChanged(fields).
+		IPHash(audit.HashIP(clientIP, s.hmacSecret)).
+		RequestID(requestID).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
@@ -3,47 +3,78 @@ package service
 import (
 	"context"
 	"errors"
+	"reflect"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/banking/user-service/internal/customsettings"
 	"github.com/banking/user-service/internal/domain"
 	"github.com/banking/user-service/internal/domain/audit"
 	"github.com/banking/user-service/internal/events"
 	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/mongodb"
 )
 
 // Preference service errors
 var (
-	ErrPreferenceNotFound = errors.New("preference not found")
+	ErrPreferenceNotFound        = errors.New("preference not found")
+	ErrPreferenceVersionNotFound = errors.New("preference version not found")
+	ErrCustomSettingsNamespace   = errors.New("custom settings namespace is not registered")
+	ErrCustomSettingsTooLarge    = errors.New("custom settings document is too large")
 )
 
+// preferenceVersionListLimit caps how many versions a history listing returns
+const preferenceVersionListLimit = 100
+
 // PreferenceRepository defines the interface for preference storage
+// Upsert saves pref as a new version and only succeeds if pref.Version is still the latest
 type PreferenceRepository interface {
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Preference, error)
-	Upsert(ctx context.Context, pref *domain.Preference) error
+	Upsert(ctx context.Context, pref *domain.Preference, changedBy, changeSource string, changedFields []string) error
+	GetVersion(ctx context.Context, userID uuid.UUID, version int) (*domain.PreferenceVersion, error)
+	GetAsOf(ctx context.Context, userID uuid.UUID, at time.Time) (*domain.PreferenceVersion, error)
+	ListVersions(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.PreferenceVersion, error)
+}
+
+// PhoneVerificationChecker reports whether a user's phone number can receive SMS
+type PhoneVerificationChecker interface {
+	IsPhoneVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// ConsentChecker returns the purposes a user has consent in effect for
+type ConsentChecker interface {
+	GrantedConsents(ctx context.Context, userID uuid.UUID) (map[domain.ConsentPurpose]bool, error)
 }
 
 // PreferenceService handles preference-related business logic
 type PreferenceService struct {
-	prefRepo      PreferenceRepository
-	auditProducer *events.AuditProducer
-	log           *logger.Logger
-	hmacSecret    []byte
+	prefRepo       PreferenceRepository
+	phoneChecker   PhoneVerificationChecker
+	consentChecker ConsentChecker
+	customSettings *customsettings.Registry
+	auditProducer  *events.AuditProducer
+	log            *logger.Logger
+	hmacSecret     []byte
 }
 
 // NewPreferenceService creates a new preference service
 func NewPreferenceService(
 	prefRepo PreferenceRepository,
+	phoneChecker PhoneVerificationChecker,
+	consentChecker ConsentChecker,
 	auditProducer *events.AuditProducer,
 	log *logger.Logger,
 	hmacSecret []byte,
 ) *PreferenceService {
 	return &PreferenceService{
-		prefRepo:      prefRepo,
-		auditProducer: auditProducer,
-		log:           log.Named("preference_service"),
-		hmacSecret:    hmacSecret,
+		prefRepo:       prefRepo,
+		phoneChecker:   phoneChecker,
+		consentChecker: consentChecker,
+		customSettings: customsettings.Default(),
+		auditProducer:  auditProducer,
+		log:            log.Named("preference_service"),
+		hmacSecret:     hmacSecret,
 	}
 }
 
@@ -51,18 +82,21 @@ func NewPreferenceService(
 func (s *PreferenceService) GetPreferences(ctx context.Context, userID uuid.UUID) (*domain.Preference, error) {
 	pref, err := s.prefRepo.GetByUserID(ctx, userID)
 	if err != nil {
-		// If not found, return defaults
-		return domain.DefaultPreference(userID), nil
+		// Defaults are safe to show while the store is unavailable
+		if !errors.Is(err, mongodb.ErrPreferenceNotFound) {
+			s.log.Warn("failed to get preferences, returning defaults", logger.ErrorField(err))
+		}
+		pref = domain.DefaultPreference(userID)
 	}
+	s.present(ctx, userID, pref)
 	return pref, nil
 }
 
 // UpdateUXPreferences updates UX preferences
 func (s *PreferenceService) UpdateUXPreferences(ctx context.Context, userID uuid.UUID, req *domain.UpdateUXPreferencesRequest, clientIP, requestID string) (*domain.Preference, error) {
-	pref, err := s.prefRepo.GetByUserID(ctx, userID)
+	pref, err := s.loadForUpdate(ctx, userID)
 	if err != nil {
-		// Create default if not exists
-		pref = domain.DefaultPreference(userID)
+		return nil, err
 	}
 
 	changedFields := []string{}
@@ -102,26 +136,31 @@ func (s *PreferenceService) UpdateUXPreferences(ctx context.Context, userID uuid
 	}
 
 	if len(changedFields) == 0 {
+		s.present(ctx, userID, pref)
 		return pref, nil // No changes
 	}
 
-	pref.UpdatedAt = time.Now().UTC()
-	if err := s.prefRepo.Upsert(ctx, pref); err != nil {
+	if err := s.save(ctx, pref, userID.String(), changedFields); err != nil {
 		return nil, err
 	}
 
 	// Emit audit event
 	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourcePreference, userID.String(), changedFields, clientIP, requestID)
 
+	s.present(ctx, userID, pref)
 	return pref, nil
 }
 
 // UpdateNotificationSettings updates notification preferences
 func (s *PreferenceService) UpdateNotificationSettings(ctx context.Context, userID uuid.UUID, req *domain.UpdateNotificationRequest, clientIP, requestID string) (*domain.Preference, error) {
-	pref, err := s.prefRepo.GetByUserID(ctx, userID)
+	// Whether consent-governed types are sent follows the consent ledger, not this toggle
+	if _, governed := domain.NotificationConsent(req.Type); governed && req.Enabled != nil {
+		return nil, ErrConsentManaged
+	}
+
+	pref, err := s.loadForUpdate(ctx, userID)
 	if err != nil {
-		// Create default if not exists
-		pref = domain.DefaultPreference(userID)
+		return nil, err
 	}
 
 	changedFields := []string{}
@@ -148,31 +187,386 @@ func (s *PreferenceService) UpdateNotificationSettings(ctx context.Context, user
 		}
 	}
 	if req.Channels != nil {
+		// SMS cannot be selected until the phone number is verified
+		if domain.HasChannel(req.Channels, domain.ChannelSMS) {
+			verified, err := s.isPhoneVerified(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !verified {
+				return nil, ErrPhoneNotVerified
+			}
+		}
 		setting.Channels = req.Channels
 		changedFields = append(changedFields, string(req.Type)+"_channels")
 	}
+	if req.Quiet != nil {
+		// Security notifications must always get through
+		if domain.IsSecurityNotification(req.Type) {
+			return nil, domain.ErrQuietHoursNotAllowed
+		}
+		quiet, err := req.Quiet.ToQuietHours()
+		if err != nil {
+			return nil, err
+		}
+		if !quiet.Equal(setting.Quiet) {
+			setting.Quiet = quiet
+			changedFields = append(changedFields, string(req.Type)+"_quiet_hours")
+		}
+	}
 
 	pref.NotificationSettings[req.Type] = setting
 
 	if len(changedFields) == 0 {
+		s.present(ctx, userID, pref)
 		return pref, nil
 	}
 
-	pref.UpdatedAt = time.Now().UTC()
-	if err := s.prefRepo.Upsert(ctx, pref); err != nil {
+	if err := s.save(ctx, pref, userID.String(), changedFields); err != nil {
 		return nil, err
 	}
 
 	// Emit audit event
 	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourcePreference, userID.String(), changedFields, clientIP, requestID)
 
+	s.present(ctx, userID, pref)
+	return pref, nil
+}
+
+// GetCustomSettings returns one namespace of the user's custom settings, upgraded to the
+// namespace's current schema version; a namespace never written is returned empty
+func (s *PreferenceService) GetCustomSettings(ctx context.Context, userID uuid.UUID, namespace string) (*domain.CustomSettingsDocument, error) {
+	version, err := s.customSettings.Version(namespace)
+	if err != nil {
+		return nil, mapCustomSettingsError(err)
+	}
+
+	// Unlike other reads, no defaults: an empty document could be saved back over real settings
+	pref, err := s.loadForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &domain.CustomSettingsDocument{Namespace: namespace, SchemaVersion: version, Settings: map[string]interface{}{}}
+	stored, ok := pref.CustomSettings[namespace]
+	if !ok {
+		return doc, nil
+	}
+
+	settings, err := s.customSettings.Upgrade(namespace, pref.CustomSettingsVersions[namespace], stored)
+	if err != nil {
+		return nil, err
+	}
+	doc.Settings = settings
+	return doc, nil
+}
+
+// UpdateCustomSettings replaces one namespace of the user's custom settings
+// The document must match the namespace's current schema and size limit
+func (s *PreferenceService) UpdateCustomSettings(ctx context.Context, userID uuid.UUID, namespace string, settings map[string]interface{}, clientIP, requestID string) (*domain.CustomSettingsDocument, error) {
+	version, err := s.customSettings.Version(namespace)
+	if err != nil {
+		return nil, mapCustomSettingsError(err)
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	if err := s.customSettings.Validate(namespace, settings); err != nil {
+		return nil, mapCustomSettingsError(err)
+	}
+
+	pref, err := s.loadForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &domain.CustomSettingsDocument{Namespace: namespace, SchemaVersion: version, Settings: settings}
+	if pref.CustomSettingsVersions[namespace] == version && reflect.DeepEqual(pref.CustomSettings[namespace], settings) {
+		return doc, nil // No changes
+	}
+
+	if pref.CustomSettings == nil {
+		pref.CustomSettings = make(map[string]interface{})
+	}
+	if pref.CustomSettingsVersions == nil {
+		pref.CustomSettingsVersions = make(map[string]int)
+	}
+	pref.CustomSettings[namespace] = settings
+	pref.CustomSettingsVersions[namespace] = version
+
+	changedFields := []string{"custom_settings." + namespace}
+	if err := s.save(ctx, pref, userID.String(), changedFields); err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourcePreference, userID.String(), changedFields, clientIP, requestID)
+
+	return doc, nil
+}
+
+// DeleteCustomSettings removes one namespace of the user's custom settings
+func (s *PreferenceService) DeleteCustomSettings(ctx context.Context, userID uuid.UUID, namespace, clientIP, requestID string) error {
+	if _, err := s.customSettings.Version(namespace); err != nil {
+		return mapCustomSettingsError(err)
+	}
+
+	pref, err := s.loadForUpdate(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if _, ok := pref.CustomSettings[namespace]; !ok {
+		return nil
+	}
+
+	delete(pref.CustomSettings, namespace)
+	delete(pref.CustomSettingsVersions, namespace)
+
+	changedFields := []string{"custom_settings." + namespace}
+	if err := s.save(ctx, pref, userID.String(), changedFields); err != nil {
+		return err
+	}
+
+	s.emitAuditEvent(ctx, userID, audit.ActionDelete, audit.ResourcePreference, userID.String(), changedFields, clientIP, requestID)
+
+	return nil
+}
+
+// GetNotificationSummary returns the user's enabled notification channels for the notification
+// service, with whether each type may be delivered at the given instant
+func (s *PreferenceService) GetNotificationSummary(ctx context.Context, userID uuid.UUID, at time.Time, serviceName, requestID string) (*domain.NotificationPreferenceSummary, error) {
+	pref, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAccessEvent(ctx, userID, serviceName, requestID)
+
+	return pref.ToNotificationSummary(at), nil
+}
+
+// GetPreferenceVersions lists the saved versions of the user's preferences, newest first
+func (s *PreferenceService) GetPreferenceVersions(ctx context.Context, userID uuid.UUID) ([]*domain.PreferenceVersion, error) {
+	return s.prefRepo.ListVersions(ctx, userID, preferenceVersionListLimit)
+}
+
+// GetPreferencesAsOf returns the version of the user's preferences that was in effect at the given time
+// Snapshots are returned as stored, including channels that are hidden from current reads
+func (s *PreferenceService) GetPreferencesAsOf(ctx context.Context, userID uuid.UUID, at time.Time) (*domain.PreferenceVersion, error) {
+	version, err := s.prefRepo.GetAsOf(ctx, userID, at)
+	if err != nil {
+		if errors.Is(err, mongodb.ErrPreferenceVersionNotFound) {
+			return nil, ErrPreferenceNotFound
+		}
+		return nil, err
+	}
+	return version, nil
+}
+
+// DiffPreferenceVersions lists the settings that differ between two versions of the user's preferences
+func (s *PreferenceService) DiffPreferenceVersions(ctx context.Context, userID uuid.UUID, fromVersion, toVersion int) (*domain.PreferenceDiff, error) {
+	from, err := s.getVersion(ctx, userID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getVersion(ctx, userID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := domain.DiffPreferences(from.Preference, to.Preference)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PreferenceDiff{
+		UserID:      userID,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Changes:     changes,
+	}, nil
+}
+
+// GetPreferenceVersionsAsAdmin lists a user's preference versions to an investigator
+func (s *PreferenceService) GetPreferenceVersionsAsAdmin(ctx context.Context, userID uuid.UUID, adminID, clientIP, requestID string) ([]*domain.PreferenceVersion, error) {
+	versions, err := s.GetPreferenceVersions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEventAs(ctx, userID, adminID, audit.ActorAdmin, audit.ActionAccess, audit.ResourcePreference, userID.String(), []string{"preference_history"}, clientIP, requestID)
+
+	return versions, nil
+}
+
+// GetPreferencesAsOfAsAdmin returns a user's preferences at the given time to an investigator
+func (s *PreferenceService) GetPreferencesAsOfAsAdmin(ctx context.Context, userID uuid.UUID, at time.Time, adminID, clientIP, requestID string) (*domain.PreferenceVersion, error) {
+	version, err := s.GetPreferencesAsOf(ctx, userID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEventAs(ctx, userID, adminID, audit.ActorAdmin, audit.ActionAccess, audit.ResourcePreference, userID.String(), []string{"preference_history"}, clientIP, requestID)
+
+	return version, nil
+}
+
+// DiffPreferenceVersionsAsAdmin compares two of a user's preference versions for an investigator
+func (s *PreferenceService) DiffPreferenceVersionsAsAdmin(ctx context.Context, userID uuid.UUID, fromVersion, toVersion int, adminID, clientIP, requestID string) (*domain.PreferenceDiff, error) {
+	diff, err := s.DiffPreferenceVersions(ctx, userID, fromVersion, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAuditEventAs(ctx, userID, adminID, audit.ActorAdmin, audit.ActionAccess, audit.ResourcePreference, userID.String(), []string{"preference_history"}, clientIP, requestID)
+
+	return diff, nil
+}
+
+func (s *PreferenceService) getVersion(ctx context.Context, userID uuid.UUID, version int) (*domain.PreferenceVersion, error) {
+	v, err := s.prefRepo.GetVersion(ctx, userID, version)
+	if err != nil {
+		if errors.Is(err, mongodb.ErrPreferenceVersionNotFound) {
+			return nil, ErrPreferenceVersionNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// loadForUpdate retrieves the preferences to modify, starting from defaults if the user has none
+// Unlike reads, updates fail when the store is unavailable so defaults never overwrite real settings
+func (s *PreferenceService) loadForUpdate(ctx context.Context, userID uuid.UUID) (*domain.Preference, error) {
+	pref, err := s.prefRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongodb.ErrPreferenceNotFound) {
+			return domain.DefaultPreference(userID), nil
+		}
+		return nil, err
+	}
 	return pref, nil
 }
 
+// save stores pref as a new version unless it was modified since it was loaded
+func (s *PreferenceService) save(ctx context.Context, pref *domain.Preference, changedBy string, changedFields []string) error {
+	if err := s.prefRepo.Upsert(ctx, pref, changedBy, domain.PreferenceChangeSourceUser, changedFields); err != nil {
+		if errors.Is(err, mongodb.ErrOptimisticLock) {
+			return ErrOptimisticLock
+		}
+		return err
+	}
+	return nil
+}
+
+// present adjusts stored preferences to what is in effect for the user before they are returned
+func (s *PreferenceService) present(ctx context.Context, userID uuid.UUID, pref *domain.Preference) {
+	s.hideUnverifiedChannels(ctx, userID, pref)
+	s.applyConsent(ctx, userID, pref)
+	s.upgradeCustomSettings(pref)
+}
+
+// upgradeCustomSettings returns registered namespaces in their current schema version
+// Stored documents are left as they are until the namespace is next written
+func (s *PreferenceService) upgradeCustomSettings(pref *domain.Preference) {
+	if len(pref.CustomSettings) == 0 {
+		return
+	}
+	if pref.CustomSettingsVersions == nil {
+		pref.CustomSettingsVersions = make(map[string]int)
+	}
+	for namespace, stored := range pref.CustomSettings {
+		version, err := s.customSettings.Version(namespace)
+		if err != nil || pref.CustomSettingsVersions[namespace] == version {
+			continue // Written before namespaces were registered, or already current
+		}
+		settings, err := s.customSettings.Upgrade(namespace, pref.CustomSettingsVersions[namespace], stored)
+		if err != nil {
+			s.log.Warn("failed to upgrade custom settings", logger.ErrorField(err))
+			continue
+		}
+		pref.CustomSettings[namespace] = settings
+		pref.CustomSettingsVersions[namespace] = version
+	}
+}
+
+// applyConsent derives consent-governed notification types from the consent ledger
+func (s *PreferenceService) applyConsent(ctx context.Context, userID uuid.UUID, pref *domain.Preference) {
+	var granted map[domain.ConsentPurpose]bool
+	if s.consentChecker != nil {
+		var err error
+		granted, err = s.consentChecker.GrantedConsents(ctx, userID)
+		if err != nil {
+			// Fail closed: never enable marketing when consent is unknown
+			s.log.Warn("failed to check consent", logger.ErrorField(err))
+			granted = nil
+		}
+	}
+	pref.ApplyConsent(granted)
+}
+
+// hideUnverifiedChannels removes SMS from the returned preferences until the phone is verified
+// Stored channels are left intact so they take effect once verification completes
+func (s *PreferenceService) hideUnverifiedChannels(ctx context.Context, userID uuid.UUID, pref *domain.Preference) {
+	verified, err := s.isPhoneVerified(ctx, userID)
+	if err != nil {
+		// Fail closed: never offer SMS when verification status is unknown
+		s.log.Warn("failed to check phone verification", logger.ErrorField(err))
+	}
+	if !verified {
+		pref.RemoveChannel(domain.ChannelSMS)
+	}
+}
+
+func (s *PreferenceService) isPhoneVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if s.phoneChecker == nil {
+		return false, nil
+	}
+	return s.phoneChecker.IsPhoneVerified(ctx, userID)
+}
+
+// mapCustomSettingsError maps registry errors to service errors; schema violations are
+// returned as they are so clients see which value was rejected
+func mapCustomSettingsError(err error) error {
+	switch {
+	case errors.Is(err, customsettings.ErrUnknownNamespace):
+		return ErrCustomSettingsNamespace
+	case errors.Is(err, customsettings.ErrTooLarge):
+		return ErrCustomSettingsTooLarge
+	default:
+		return err
+	}
+}
+
+// emitAccessEvent records another service reading the user's notification preferences
+func (s *PreferenceService) emitAccessEvent(ctx context.Context, userID uuid.UUID, serviceName, requestID string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(serviceName, audit.ActorService).
+		Action(audit.ActionAccess).
+		Resource(audit.ResourcePreference, userID.String()).
+		FieldsChanged([]string{"notification_settings"}).
+		RequestID(requestID).
+		Service(serviceName).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
+
 func (s *PreferenceService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
+	s.emitAuditEventAs(ctx, userID, userID.String(), audit.ActorUser, action, resource, resourceID, fields, clientIP, requestID)
+}
+
+// emitAuditEventAs emits an audit event for an actor other than the affected user
+func (s *PreferenceService) emitAuditEventAs(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
 	event, err := audit.NewAuditEvent(s.hmacSecret).
 		UserID(userID.String()).
-		Actor(userID.String(), audit.ActorUser).
+		Actor(actorID, actorType).
 		Action(action).
 		Resource(resource, resourceID).
 		FieldsChanged(fields).
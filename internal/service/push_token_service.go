@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/postgres"
+)
+
+// pushTokenFields is what audit events record for push token changes and reads
+// The token itself is never logged or audited
+var pushTokenFields = []string{"push_token"}
+
+// PushTokenService manages the APNs and FCM tokens of users' devices
+// Tokens are set by the app on the device they belong to and read by the notification service
+type PushTokenService struct {
+	deviceRepo    *postgres.DeviceRepository
+	auditProducer *events.AuditProducer
+	log           *logger.Logger
+	hmacSecret    []byte
+}
+
+// NewPushTokenService creates a new push token service
+func NewPushTokenService(
+	deviceRepo *postgres.DeviceRepository,
+	auditProducer *events.AuditProducer,
+	log *logger.Logger,
+	hmacSecret []byte,
+) *PushTokenService {
+	return &PushTokenService{
+		deviceRepo:    deviceRepo,
+		auditProducer: auditProducer,
+		log:           log.Named("push_token_service"),
+		hmacSecret:    hmacSecret,
+	}
+}
+
+// RegisterPushToken sets or rotates the push token of the device with this fingerprint
+// The device must be registered first
+func (s *PushTokenService) RegisterPushToken(ctx context.Context, userID uuid.UUID, fingerprint string, req *domain.RegisterPushTokenRequest, clientIP, requestID string) error {
+	device, err := s.currentDevice(ctx, userID, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.deviceRepo.SetPushToken(ctx, userID, device.ID, req.Provider, req.Token)
+	if err != nil {
+		if errors.Is(err, postgres.ErrDeviceNotFound) {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+	if !res.Changed {
+		return nil
+	}
+
+	action := audit.ActionCreate
+	if res.Rotated {
+		action = audit.ActionUpdate
+	}
+	s.emitAuditEvent(ctx, userID, action, device.ID.String(), clientIP, requestID)
+
+	// The token moved here from another device, possibly another user's
+	for _, owner := range res.Displaced {
+		s.emitAuditEventAs(ctx, owner.UserID, userID.String(), audit.ActorUser, "", audit.ActionDelete, owner.DeviceID.String(), clientIP, requestID)
+	}
+
+	return nil
+}
+
+// RemovePushToken removes the push token of the device with this fingerprint, e.g. on sign-out
+func (s *PushTokenService) RemovePushToken(ctx context.Context, userID uuid.UUID, fingerprint, clientIP, requestID string) error {
+	device, err := s.currentDevice(ctx, userID, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	hadToken, err := s.deviceRepo.ClearPushToken(ctx, userID, device.ID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrDeviceNotFound) {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+
+	if hadToken {
+		s.emitAuditEvent(ctx, userID, audit.ActionDelete, device.ID.String(), clientIP, requestID)
+	}
+
+	return nil
+}
+
+// GetActiveTokens returns the push tokens of the user's trusted devices for the notification service
+func (s *PushTokenService) GetActiveTokens(ctx context.Context, userID uuid.UUID, serviceName, requestID string) ([]*domain.PushToken, error) {
+	tokens, err := s.deviceRepo.ListPushTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A lookup that found nothing is still recorded against the user
+	if len(tokens) == 0 {
+		s.emitAuditEventAs(ctx, userID, serviceName, audit.ActorService, serviceName, audit.ActionAccess, userID.String(), "", requestID)
+	}
+	for _, token := range tokens {
+		s.emitAuditEventAs(ctx, userID, serviceName, audit.ActorService, serviceName, audit.ActionAccess, token.DeviceID.String(), "", requestID)
+	}
+
+	return tokens, nil
+}
+
+// InvalidateToken removes a token the provider reported as no longer valid
+// It returns how many devices held the token; reporting an unknown token is not an error
+func (s *PushTokenService) InvalidateToken(ctx context.Context, token, serviceName, requestID string) (int, error) {
+	owners, err := s.deviceRepo.InvalidatePushToken(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, owner := range owners {
+		s.emitAuditEventAs(ctx, owner.UserID, serviceName, audit.ActorService, serviceName, audit.ActionDelete, owner.DeviceID.String(), "", requestID)
+	}
+
+	return len(owners), nil
+}
+
+func (s *PushTokenService) currentDevice(ctx context.Context, userID uuid.UUID, fingerprint string) (*domain.Device, error) {
+	device, err := s.deviceRepo.GetByFingerprint(ctx, userID, s.deviceRepo.HashFingerprint(fingerprint))
+	if err != nil {
+		if errors.Is(err, postgres.ErrDeviceNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *PushTokenService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, deviceID, clientIP, requestID string) {
+	s.emitAuditEventAs(ctx, userID, userID.String(), audit.ActorUser, "", action, deviceID, clientIP, requestID)
+}
+
+// emitAuditEventAs emits an audit event for an actor other than the affected user
+func (s *PushTokenService) emitAuditEventAs(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, serviceName string, action audit.Action, resourceID, clientIP, requestID string) {
+	event, err := audit.NewAuditEvent(s.hmacSecret).
+		UserID(userID.String()).
+		Actor(actorID, actorType).
+		Action(action).
+		Resource(audit.ResourceDevice, resourceID).
+		FieldsChanged(pushTokenFields).
+		IPHash(audit.HashIP(clientIP, s.hmacSecret)).
+		RequestID(requestID).
+		Service(serviceName).
+		Build()
+
+	if err != nil {
+		s.log.Error("failed to build audit event", logger.ErrorField(err))
+		return
+	}
+
+	if err := s.auditProducer.Produce(ctx, event); err != nil {
+		s.log.Error("failed to produce audit event", logger.ErrorField(err))
+	}
+}
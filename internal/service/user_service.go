@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/banking/user-service/internal/crypto"
 	"github.com/banking/user-service/internal/domain"
 	"github.com/banking/user-service/internal/domain/audit"
 	"github.com/banking/user-service/internal/events"
@@ -21,34 +23,116 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrOptimisticLock    = errors.New("optimistic lock conflict")
 	ErrInvalidInput      = errors.New("invalid input")
+	ErrInvalidToken      = errors.New("invalid or expired token")
+	ErrAccountNotDeleted = errors.New("account is not deleted")
+	ErrRestoreExpired    = errors.New("restore grace period has expired")
 )
 
+// UserServiceConfig holds tunable account lifecycle settings
+type UserServiceConfig struct {
+	EmailChangeTokenTTL time.Duration
+	RestoreGracePeriod  time.Duration // How long a soft-deleted account can be restored
+}
+
+// DefaultUserServiceConfig returns the default user service settings
+func DefaultUserServiceConfig() UserServiceConfig {
+	return UserServiceConfig{
+		EmailChangeTokenTTL: 24 * time.Hour,
+		RestoreGracePeriod:  30 * 24 * time.Hour,
+	}
+}
+
 // UserService handles user-related business logic
 type UserService struct {
 	userRepo      *postgres.UserRepository
+	prefRepo      PreferenceRepository
 	cache         *redis.UserCache
 	auditProducer *events.AuditProducer
+	eventProducer *events.EventProducer
+	notifier      *Notifier
 	log           *logger.Logger
 	hmacSecret    []byte
+	cfg           UserServiceConfig
 }
 
 // NewUserService creates a new user service
 func NewUserService(
 	userRepo *postgres.UserRepository,
+	prefRepo PreferenceRepository,
 	cache *redis.UserCache,
 	auditProducer *events.AuditProducer,
+	eventProducer *events.EventProducer,
+	notifier *Notifier,
 	log *logger.Logger,
 	hmacSecret []byte,
+	cfg UserServiceConfig,
 ) *UserService {
+	defaults := DefaultUserServiceConfig()
+	if cfg.EmailChangeTokenTTL <= 0 {
+		cfg.EmailChangeTokenTTL = defaults.EmailChangeTokenTTL
+	}
+	if cfg.RestoreGracePeriod <= 0 {
+		cfg.RestoreGracePeriod = defaults.RestoreGracePeriod
+	}
 	return &UserService{
 		userRepo:      userRepo,
+		prefRepo:      prefRepo,
 		cache:         cache,
 		auditProducer: auditProducer,
+		eventProducer: eventProducer,
+		notifier:      notifier,
 		log:           log.Named("user_service"),
 		hmacSecret:    hmacSecret,
+		cfg:           cfg,
 	}
 }
 
+// CreateUser registers a new user profile
+// Called by the auth service during onboarding; serviceName identifies the caller for audit
+func (s *UserService) CreateUser(ctx context.Context, req *domain.CreateUserRequest, serviceName, clientIP, requestID string) (*domain.User, error) {
+	user := &domain.User{
+		LegalName: strings.TrimSpace(req.LegalName),
+		Email:     normalizeEmail(req.Email),
+		Phone:     req.Phone,
+		Country:   req.Country,
+		Status:    domain.UserStatusActive,
+		KYCStatus: domain.KYCStatusPending,
+		RiskFlags: []string{},
+	}
+
+	if req.DOB != "" {
+		dob, err := time.Parse("2006-01-02", req.DOB)
+		if err != nil {
+			return nil, ErrInvalidInput
+		}
+		user.DOB = &dob
+	}
+
+	// Repository enforces email_hash uniqueness among non-deleted users
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, postgres.ErrUserAlreadyExists) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, err
+	}
+
+	// Seed default preferences; GetPreferences falls back to defaults if this fails
+	if s.prefRepo != nil {
+		if err := s.prefRepo.Upsert(ctx, domain.DefaultPreference(user.ID), serviceName, domain.PreferenceChangeSourceAPI, nil); err != nil {
+			s.log.Warn("failed to seed default preferences",
+				logger.UserID(user.ID.String()),
+				logger.ErrorField(err),
+			)
+		}
+	}
+
+	// Emit audit event
+	s.emitAuditEventAs(ctx, user.ID, serviceName, audit.ActorService, serviceName, audit.ActionCreate, audit.ResourceProfile, user.ID.String(),
+		[]string{"legal_name", "email", "phone", "dob", "country", "status", "kyc_status"}, clientIP, requestID)
+
+	return user, nil
+}
+
 // GetProfile retrieves a user profile by ID
 func (s *UserService) GetProfile(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
 	// Note: We skip cache here because we need the full profile including PII (decrypted),
@@ -135,7 +219,23 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *
 
 // DeleteProfile soft-deletes a user profile
 func (s *UserService) DeleteProfile(ctx context.Context, userID uuid.UUID, clientIP, requestID string) error {
-	err := s.userRepo.SoftDelete(ctx, userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.DeletedAt != nil {
+		return ErrUserNotFound
+	}
+
+	// Suspended, locked and closed accounts cannot be deleted by their owner
+	if err := domain.ValidateStatusTransition(user.Status, domain.UserStatusDeleted, audit.ActorUser, domain.StatusReasonSelfServiceDelete); err != nil {
+		return err
+	}
+
+	err = s.userRepo.SoftDelete(ctx, userID, userID.String())
 	if err != nil {
 		if errors.Is(err, postgres.ErrUserNotFound) {
 			return ErrUserNotFound
@@ -155,9 +255,254 @@ func (s *UserService) DeleteProfile(ctx context.Context, userID uuid.UUID, clien
 	// Emit audit event
 	s.emitAuditEvent(ctx, userID, audit.ActionDelete, audit.ResourceProfile, userID.String(), []string{"deleted_at", "status"}, clientIP, requestID)
 
+	s.publishEvent(ctx, events.EventUserStatusChanged, userID, events.StatusChangedEvent{
+		PreviousStatus: user.Status,
+		Status:         domain.UserStatusDeleted,
+		Reason:         domain.StatusReasonSelfServiceDelete,
+		ActorType:      audit.ActorUser,
+		ChangedAt:      time.Now().UTC(),
+	})
+
 	return nil
 }
 
+// ChangeStatus moves an account through the status state machine
+// actorID is the admin's user ID for ActorAdmin or the calling service name for ActorSystem
+func (s *UserService) ChangeStatus(ctx context.Context, userID uuid.UUID, to domain.UserStatus, reason domain.StatusReason, actorID string, actorType audit.ActorType, serviceName, clientIP, requestID string) (*domain.StatusChangeResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := domain.ValidateStatusTransition(user.Status, to, actorType, reason); err != nil {
+		return nil, err
+	}
+
+	changedAt, err := s.userRepo.UpdateStatus(ctx, userID, user.Status, to, reason)
+	if err != nil {
+		if errors.Is(err, postgres.ErrOptimisticLock) {
+			return nil, ErrOptimisticLock
+		}
+		return nil, err
+	}
+
+	// Invalidate synchronously: a suspended user must not be served from a stale summary
+	if err := s.cache.InvalidateUser(ctx, userID); err != nil {
+		s.log.Warn("failed to invalidate cache after status change",
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+	}
+
+	s.emitAuditEventAs(ctx, userID, actorID, actorType, serviceName, audit.ActionUpdate, audit.ResourceProfile, userID.String(),
+		[]string{"status", "status_reason"}, clientIP, requestID)
+
+	s.publishEvent(ctx, events.EventUserStatusChanged, userID, events.StatusChangedEvent{
+		PreviousStatus: user.Status,
+		Status:         to,
+		Reason:         reason,
+		ActorType:      actorType,
+		ChangedAt:      changedAt,
+	})
+
+	return &domain.StatusChangeResponse{
+		UserID:          userID,
+		PreviousStatus:  user.Status,
+		Status:          to,
+		Reason:          reason,
+		StatusChangedAt: changedAt,
+	}, nil
+}
+
+// RestoreAccount undoes a soft delete within the grace period
+// actorType is ActorUser when the auth service restores on behalf of a re-authenticated owner,
+// or ActorAdmin for back-office restores
+func (s *UserService) RestoreAccount(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, serviceName, clientIP, requestID string) (*domain.RestoreAccountResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.DeletedAt == nil {
+		return nil, ErrAccountNotDeleted
+	}
+
+	if err := domain.ValidateStatusTransition(user.Status, domain.UserStatusActive, actorType, domain.StatusReasonAccountRestored); err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now().UTC().Add(-s.cfg.RestoreGracePeriod)
+	changeSource := domain.AddressChangeSourceSystem
+	if actorType == audit.ActorAdmin {
+		changeSource = domain.AddressChangeSourceAdmin
+	}
+	result, err := s.userRepo.Restore(ctx, userID, notBefore, domain.StatusReasonAccountRestored, actorID, changeSource)
+	if err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, postgres.ErrNotDeleted):
+			return nil, ErrAccountNotDeleted
+		case errors.Is(err, postgres.ErrRestoreExpired):
+			return nil, ErrRestoreExpired
+		case errors.Is(err, postgres.ErrUserAlreadyExists):
+			// The email was claimed by a new account during the grace period
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, err
+	}
+
+	if err := s.cache.InvalidateUser(ctx, userID); err != nil {
+		s.log.Warn("failed to invalidate cache after restore",
+			logger.UserID(userID.String()),
+			logger.ErrorField(err),
+		)
+	}
+
+	s.emitAuditEventAs(ctx, userID, actorID, actorType, serviceName, audit.ActionUpdate, audit.ResourceProfile, userID.String(),
+		[]string{"deleted_at", "status", "status_reason"}, clientIP, requestID)
+
+	s.publishEvent(ctx, events.EventUserStatusChanged, userID, events.StatusChangedEvent{
+		PreviousStatus: user.Status,
+		Status:         domain.UserStatusActive,
+		Reason:         domain.StatusReasonAccountRestored,
+		ActorType:      actorType,
+		ChangedAt:      result.RestoredAt,
+	})
+
+	return &domain.RestoreAccountResponse{
+		UserID:            userID,
+		Status:            domain.UserStatusActive,
+		RestoredAt:        result.RestoredAt,
+		AddressesRestored: result.AddressesRestored,
+		DevicesRestored:   result.DevicesRestored,
+	}, nil
+}
+
+// RequestEmailChange starts a two-step email change
+// The new address only replaces the current one after its token is confirmed
+func (s *UserService) RequestEmailChange(ctx context.Context, userID uuid.UUID, req *domain.ChangeEmailRequest, clientIP, requestID string) (*domain.EmailChangeResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	newEmail := normalizeEmail(req.Email)
+	if newEmail == normalizeEmail(user.Email) {
+		return nil, ErrInvalidInput
+	}
+
+	exists, err := s.userRepo.EmailExists(ctx, newEmail)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUserAlreadyExists
+	}
+
+	token, err := crypto.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(s.cfg.EmailChangeTokenTTL)
+
+	// Replaces any earlier pending change, invalidating its token
+	if err := s.userRepo.SetPendingEmail(ctx, userID, newEmail, token, expiresAt); err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// Verification link goes to the new address; the current address is alerted
+	s.notifier.Send(ctx, events.EventEmailChangeRequested, userID, events.ContactNotification{
+		Channel:   string(domain.ChannelEmail),
+		Recipient: newEmail,
+		Template:  events.TemplateEmailVerification,
+		Params: map[string]string{
+			"token":      token,
+			"expires_at": expiresAt.Format(time.RFC3339),
+		},
+	})
+	s.notifier.Send(ctx, events.EventEmailChangeAlert, userID, events.ContactNotification{
+		Channel:   string(domain.ChannelEmail),
+		Recipient: user.Email,
+		Template:  events.TemplateEmailChangeAlert,
+		Params: map[string]string{
+			"new_email": domain.MaskEmail(newEmail),
+		},
+	})
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourceProfile, userID.String(), []string{"pending_email"}, clientIP, requestID)
+
+	return &domain.EmailChangeResponse{
+		PendingEmail: domain.MaskEmail(newEmail),
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// ConfirmEmailChange swaps in the pending email if the token matches and has not expired
+func (s *UserService) ConfirmEmailChange(ctx context.Context, userID uuid.UUID, req *domain.ConfirmEmailChangeRequest, clientIP, requestID string) (*domain.User, error) {
+	// Load the current address first so the old mailbox can be notified
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	oldEmail := user.Email
+
+	if err := s.userRepo.ConfirmEmailChange(ctx, userID, req.Token); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrInvalidToken):
+			return nil, ErrInvalidToken
+		case errors.Is(err, postgres.ErrUserAlreadyExists):
+			// Another account claimed the address after the change was requested
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, err
+	}
+
+	// Invalidate cache in background with timeout to prevent goroutine leaks
+	go func(id uuid.UUID) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.cache.InvalidateUser(ctx, id); err != nil {
+			s.log.Warn("failed to invalidate cache", logger.ErrorField(err))
+		}
+	}(userID)
+
+	updated, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifier.Send(ctx, events.EventEmailChanged, userID, events.ContactNotification{
+		Channel:   string(domain.ChannelEmail),
+		Recipient: oldEmail,
+		Template:  events.TemplateEmailChangedNotice,
+		Params: map[string]string{
+			"new_email": domain.MaskEmail(updated.Email),
+		},
+	})
+
+	s.emitAuditEvent(ctx, userID, audit.ActionUpdate, audit.ResourceProfile, userID.String(), []string{"email"}, clientIP, requestID)
+
+	return updated, nil
+}
+
 // GetUserSummary returns a lean user summary for internal services
 func (s *UserService) GetUserSummary(ctx context.Context, userID uuid.UUID) (*domain.UserSummary, error) {
 	// Try cache first
@@ -190,14 +535,20 @@ func (s *UserService) GetUserSummary(ctx context.Context, userID uuid.UUID) (*do
 }
 
 func (s *UserService) emitAuditEvent(ctx context.Context, userID uuid.UUID, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
+	s.emitAuditEventAs(ctx, userID, userID.String(), audit.ActorUser, "", action, resource, resourceID, fields, clientIP, requestID)
+}
+
+// emitAuditEventAs emits an audit event for an actor other than the affected user
+func (s *UserService) emitAuditEventAs(ctx context.Context, userID uuid.UUID, actorID string, actorType audit.ActorType, serviceName string, action audit.Action, resource audit.Resource, resourceID string, fields []string, clientIP, requestID string) {
 	event, err := audit.NewAuditEvent(s.hmacSecret).
 		UserID(userID.String()).
-		Actor(userID.String(), audit.ActorUser).
+		Actor(actorID, actorType).
 		Action(action).
 		Resource(resource, resourceID).
 		FieldsChanged(fields).
 		IPHash(audit.HashIP(clientIP, s.hmacSecret)).
 		RequestID(requestID).
+		Service(serviceName).
 		Build()
 
 	if err != nil {
@@ -209,3 +560,18 @@ func (s *UserService) emitAuditEvent(ctx context.Context, userID uuid.UUID, acti
 		s.log.Error("failed to produce audit event", logger.ErrorField(err))
 	}
 }
+
+// publishEvent sends a domain event; failures are logged since the state change already committed
+func (s *UserService) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	if s.eventProducer == nil {
+		return
+	}
+	if err := s.eventProducer.ProduceUserEvent(ctx, eventType, userID, data); err != nil {
+		s.log.Error("failed to produce domain event", logger.EventType(eventType), logger.ErrorField(err))
+	}
+}
+
+// normalizeEmail canonicalizes an email so that email_hash lookups are case-insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -2,21 +2,31 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
 	"github.com/google/uuid"
 
+	"github.com/banking/user-service/internal/customsettings"
 	"github.com/banking/user-service/internal/domain"
+	"github.com/banking/user-service/internal/domain/audit"
+	"github.com/banking/user-service/internal/events"
+	"github.com/banking/user-service/internal/pkg/logger"
+	"github.com/banking/user-service/internal/repository/mongodb"
 	"github.com/banking/user-service/internal/repository/postgres"
+	"github.com/banking/user-service/internal/resilience"
 )
 
 // MockUserRepository is a mock for testing
 type MockUserRepository struct {
 	GetByIDFunc    func(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	UpdateFunc     func(ctx context.Context, user *domain.User, expectedUpdatedAt time.Time) error
-	SoftDeleteFunc func(ctx context.Context, id uuid.UUID) error
+	SoftDeleteFunc func(ctx context.Context, id uuid.UUID, changedBy string) error
 }
 
 func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
@@ -33,9 +43,9 @@ func (m *MockUserRepository) Update(ctx context.Context, user *domain.User, expe
 	return nil
 }
 
-func (m *MockUserRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+func (m *MockUserRepository) SoftDelete(ctx context.Context, id uuid.UUID, changedBy string) error {
 	if m.SoftDeleteFunc != nil {
-		return m.SoftDeleteFunc(ctx, id)
+		return m.SoftDeleteFunc(ctx, id, changedBy)
 	}
 	return nil
 }
@@ -203,6 +213,8 @@ func TestUserStatus_Values(t *testing.T) {
 		domain.UserStatusActive,
 		domain.UserStatusPending,
 		domain.UserStatusSuspended,
+		domain.UserStatusLocked,
+		domain.UserStatusClosed,
 		domain.UserStatusDeleted,
 	}
 
@@ -215,6 +227,37 @@ func TestUserStatus_Values(t *testing.T) {
 	}
 }
 
+func TestValidateStatusTransition(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from     domain.UserStatus
+		to       domain.UserStatus
+		actor    audit.ActorType
+		reason   domain.StatusReason
+		expected error
+	}{
+		{"admin suspends active", domain.UserStatusActive, domain.UserStatusSuspended, audit.ActorAdmin, domain.StatusReasonFraudSuspected, nil},
+		{"admin reactivates suspended", domain.UserStatusSuspended, domain.UserStatusActive, audit.ActorAdmin, domain.StatusReasonReviewCleared, nil},
+		{"system locks active", domain.UserStatusActive, domain.UserStatusLocked, audit.ActorSystem, domain.StatusReasonFailedLogins, nil},
+		{"system unlocks locked", domain.UserStatusLocked, domain.UserStatusActive, audit.ActorSystem, domain.StatusReasonReviewCleared, nil},
+		{"system cannot lift suspension", domain.UserStatusSuspended, domain.UserStatusActive, audit.ActorSystem, domain.StatusReasonReviewCleared, domain.ErrTransitionNotPermitted},
+		{"user cannot delete while suspended", domain.UserStatusSuspended, domain.UserStatusDeleted, audit.ActorUser, domain.StatusReasonSelfServiceDelete, domain.ErrInvalidStatusTransition},
+		{"closed is terminal", domain.UserStatusClosed, domain.UserStatusActive, audit.ActorAdmin, domain.StatusReasonReviewCleared, domain.ErrInvalidStatusTransition},
+		{"owner restores deleted", domain.UserStatusDeleted, domain.UserStatusActive, audit.ActorUser, domain.StatusReasonAccountRestored, nil},
+		{"system cannot restore deleted", domain.UserStatusDeleted, domain.UserStatusActive, audit.ActorSystem, domain.StatusReasonAccountRestored, domain.ErrTransitionNotPermitted},
+		{"reason must match transition", domain.UserStatusActive, domain.UserStatusSuspended, audit.ActorAdmin, domain.StatusReasonFailedLogins, domain.ErrInvalidStatusReason},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := domain.ValidateStatusTransition(tc.from, tc.to, tc.actor, tc.reason)
+			if err != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
 func TestKYCStatus_Values(t *testing.T) {
 	statuses := []domain.KYCStatus{
 		domain.KYCStatusPending,
@@ -301,3 +344,782 @@ func TestNoChanges_EarlyReturn(t *testing.T) {
 
 	t.Error("should have returned early")
 }
+
+func TestPhoneVerification_HashCodeBoundToUser(t *testing.T) {
+	s := &PhoneVerificationService{hmacSecret: []byte("test-secret")}
+	userA := uuid.New()
+	userB := uuid.New()
+
+	if s.hashCode(userA, "123456") != s.hashCode(userA, "123456") {
+		t.Error("hash should be deterministic")
+	}
+	if s.hashCode(userA, "123456") == s.hashCode(userB, "123456") {
+		t.Error("same code for different users should hash differently")
+	}
+	if s.hashCode(userA, "123456") == s.hashCode(userA, "123457") {
+		t.Error("different codes should hash differently")
+	}
+}
+
+func TestExportedSections_OmitsUnavailableSources(t *testing.T) {
+	bundle := &domain.DataExportBundle{
+		IncompleteSections: []string{domain.DataExportSectionPreferences, domain.DataExportSectionAuditEvents},
+	}
+
+	sections := exportedSections(bundle)
+	for _, s := range sections {
+		if s == domain.DataExportSectionPreferences || s == domain.DataExportSectionAuditEvents {
+			t.Errorf("section %q was not exported and must not be audited as disclosed", s)
+		}
+	}
+
+	bundle = &domain.DataExportBundle{Preferences: &domain.Preference{}}
+	sections = exportedSections(bundle)
+	if len(sections) != 8 {
+		t.Errorf("expected all 8 sections, got %v", sections)
+	}
+}
+
+func TestDataExportService_Shutdown(t *testing.T) {
+	newService := func() *DataExportService {
+		runCtx, cancelRuns := context.WithCancel(context.Background())
+		return &DataExportService{runCtx: runCtx, cancelRuns: cancelRuns}
+	}
+
+	// A run that finishes by itself is waited for
+	s := newService()
+	finished := make(chan struct{})
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		time.Sleep(10 * time.Millisecond)
+		close(finished)
+	}()
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected drained shutdown, got %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Error("expected Shutdown to wait for the running export")
+	}
+
+	// A run still going when the deadline passes is cancelled and still waited for
+	s = newService()
+	cancelled := make(chan struct{})
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		<-s.runCtx.Done()
+		close(cancelled)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Error("expected the running export to be cancelled before Shutdown returned")
+	}
+}
+
+type failingPreferenceEraser struct{ err error }
+
+func (e failingPreferenceEraser) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return e.err
+}
+
+func TestErasureService_EraseElsewhereFailureIsReturned(t *testing.T) {
+	// The error rolls the Postgres erasure back, so the user is retried on the next run
+	errMongo := errors.New("mongo unavailable")
+	s := &ErasureService{prefEraser: failingPreferenceEraser{err: errMongo}}
+
+	if err := s.eraseElsewhere(context.Background(), uuid.New()); !errors.Is(err, errMongo) {
+		t.Errorf("expected %v, got %v", errMongo, err)
+	}
+}
+
+func TestCheckValidityWindow(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(24 * time.Hour)
+	later := now.Add(48 * time.Hour)
+
+	tests := []struct {
+		name    string
+		addr    domain.Address
+		wantErr bool
+	}{
+		{"temporary with future end", domain.Address{AddressType: domain.AddressTypeTemporary, ValidUntil: &future}, false},
+		{"temporary with window", domain.Address{AddressType: domain.AddressTypeTemporary, ValidFrom: &future, ValidUntil: &later}, false},
+		{"temporary without end", domain.Address{AddressType: domain.AddressTypeTemporary}, true},
+		{"temporary ending in the past", domain.Address{AddressType: domain.AddressTypeTemporary, ValidUntil: &past}, true},
+		{"temporary ending before it starts", domain.Address{AddressType: domain.AddressTypeTemporary, ValidFrom: &later, ValidUntil: &future}, true},
+		{"mailing without window", domain.Address{AddressType: domain.AddressTypeMailing}, false},
+		{"mailing with window", domain.Address{AddressType: domain.AddressTypeMailing, ValidUntil: &future}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkValidityWindow(&tt.addr, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckPrimaryStarted(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		addr    domain.Address
+		wantErr error
+	}{
+		{"primary temporary already started", domain.Address{AddressType: domain.AddressTypeTemporary, IsPrimary: true, ValidFrom: &past}, nil},
+		{"primary temporary without start", domain.Address{AddressType: domain.AddressTypeTemporary, IsPrimary: true}, nil},
+		{"primary temporary starting later", domain.Address{AddressType: domain.AddressTypeTemporary, IsPrimary: true, ValidFrom: &future}, ErrTemporaryNotStarted},
+		{"non-primary temporary starting later", domain.Address{AddressType: domain.AddressTypeTemporary, ValidFrom: &future}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPrimaryStarted(&tt.addr, now); err != tt.wantErr {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAddressChangePolicy(t *testing.T) {
+	policy := DefaultAddressChangePolicy(1)
+
+	tests := []struct {
+		name string
+		sig  AddressChangeSignals
+		want []string
+	}{
+		{"first edit from trusted device", AddressChangeSignals{DeviceKnown: true, DeviceTrusted: true}, nil},
+		{"unknown device", AddressChangeSignals{}, []string{domain.AddressHoldUntrustedDevice}},
+		{"known but untrusted device", AddressChangeSignals{DeviceKnown: true}, []string{domain.AddressHoldUntrustedDevice}},
+		{"country change", AddressChangeSignals{DeviceKnown: true, DeviceTrusted: true, CountryChanged: true}, []string{domain.AddressHoldCountryChange}},
+		{"second edit in window", AddressChangeSignals{DeviceKnown: true, DeviceTrusted: true, RecentChanges: 1}, []string{domain.AddressHoldChangeVelocity}},
+		{
+			"every rule",
+			AddressChangeSignals{CountryChanged: true, RecentChanges: 3},
+			[]string{domain.AddressHoldUntrustedDevice, domain.AddressHoldCountryChange, domain.AddressHoldChangeVelocity},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Evaluate(&tt.sig); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyPendingChange_ValidationResetOnlyOnNewContent(t *testing.T) {
+	validatedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	stored := func() *domain.Address {
+		return &domain.Address{
+			AddressType:      domain.AddressTypeMailing,
+			StreetLine1:      "1 Main St",
+			City:             "Springfield",
+			PostalCode:       "12345",
+			Country:          "US",
+			ValidationStatus: domain.ValidationStatusValid,
+			ValidatedAt:      &validatedAt,
+		}
+	}
+
+	primary := stored()
+	primary.IsPrimary = true
+	flip := stored()
+	flip.ApplyPendingChange(domain.NewPendingAddressChange(primary, []string{"is_primary"}))
+	if !flip.IsPrimary || flip.ValidationStatus != domain.ValidationStatusValid || flip.ValidatedAt == nil {
+		t.Errorf("expected primary flip to keep validation, got %+v", flip)
+	}
+
+	moved := stored()
+	moved.City = "Shelbyville"
+	edit := stored()
+	edit.ApplyPendingChange(domain.NewPendingAddressChange(moved, []string{"city"}))
+	if edit.City != "Shelbyville" || edit.ValidationStatus != domain.ValidationStatusPending || edit.ValidatedAt != nil {
+		t.Errorf("expected content edit to restart validation, got %+v", edit)
+	}
+}
+
+func TestScoreSuspicion(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          domain.DeviceSuspicion
+		wantScore   int
+		wantReasons []string
+	}{
+		{"no signals", domain.DeviceSuspicion{}, 0, nil},
+		{"new device", domain.DeviceSuspicion{IsNewDevice: true}, 15, []string{domain.SuspicionNewDevice}},
+		{
+			"new device on a new network",
+			domain.DeviceSuspicion{IsNewDevice: true, UnusualLocation: true},
+			35,
+			[]string{domain.SuspicionNewDevice, domain.SuspicionUnusualLocation},
+		},
+		{
+			"every signal",
+			domain.DeviceSuspicion{IsNewDevice: true, UnusualLocation: true, MultipleUsers: true, RapidLocationChange: true},
+			100,
+			[]string{domain.SuspicionNewDevice, domain.SuspicionUnusualLocation, domain.SuspicionMultipleUsers, domain.SuspicionRapidLocationChange},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.in
+			scoreSuspicion(&s)
+			if s.SuspicionScore != tt.wantScore {
+				t.Errorf("expected score %d, got %d", tt.wantScore, s.SuspicionScore)
+			}
+			if !reflect.DeepEqual(s.Reasons, tt.wantReasons) {
+				t.Errorf("expected reasons %v, got %v", tt.wantReasons, s.Reasons)
+			}
+			if restored := domain.NewDeviceSuspicion(s.SuspicionScore, s.Reasons); !reflect.DeepEqual(*restored, s) {
+				t.Errorf("expected stored reasons to restore %+v, got %+v", s, *restored)
+			}
+		})
+	}
+}
+
+func TestNetworkPrefix(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.57":        "203.0.113.0/24",
+		"::ffff:203.0.113.57": "203.0.113.0/24",
+		"2001:db8:abcd:12::1": "2001:db8:abcd::/48",
+		"not-an-ip":           "not-an-ip",
+	}
+
+	for in, want := range tests {
+		if got := networkPrefix(in); got != want {
+			t.Errorf("networkPrefix(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestIsFreshStepUp(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	maxAge := 5 * time.Minute
+	mfa := []string{"urn:banking:acr:mfa"}
+
+	tests := []struct {
+		name   string
+		stepUp StepUp
+		acrs   []string
+		want   bool
+	}{
+		{"no auth_time", StepUp{}, nil, false},
+		{"recent", StepUp{AuthTime: now.Add(-time.Minute)}, nil, true},
+		{"too old", StepUp{AuthTime: now.Add(-6 * time.Minute)}, nil, false},
+		{"slightly ahead of our clock", StepUp{AuthTime: now.Add(30 * time.Second)}, nil, true},
+		{"far in the future", StepUp{AuthTime: now.Add(time.Hour)}, nil, false},
+		{"accepted acr", StepUp{AuthTime: now.Add(-time.Minute), ACR: "urn:banking:acr:mfa"}, mfa, true},
+		{"weaker acr", StepUp{AuthTime: now.Add(-time.Minute), ACR: "urn:banking:acr:pwd"}, mfa, false},
+		{"missing acr", StepUp{AuthTime: now.Add(-time.Minute)}, mfa, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFreshStepUp(tt.stepUp, maxAge, tt.acrs, now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestToFraudData_IncludesSuspicion(t *testing.T) {
+	scoredAt := time.Now()
+	devices := []*domain.Device{
+		{ID: uuid.New(), UserID: uuid.New(), FingerprintHash: "fp"},
+		{
+			ID:              uuid.New(),
+			UserID:          uuid.New(),
+			FingerprintHash: "fp",
+			Suspicion:       domain.NewDeviceSuspicion(30, []string{domain.SuspicionMultipleUsers}),
+			ScoredAt:        &scoredAt,
+		},
+	}
+
+	data := toFraudData(devices)
+	if len(data) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(data))
+	}
+	if data[0].SuspicionScore != 0 || data[0].ScoredAt != nil {
+		t.Errorf("expected unscored device to have no score, got %d", data[0].SuspicionScore)
+	}
+	if data[1].SuspicionScore != 30 || !reflect.DeepEqual(data[1].SuspicionReasons, []string{domain.SuspicionMultipleUsers}) {
+		t.Errorf("expected score 30 with MULTIPLE_USERS, got %d %v", data[1].SuspicionScore, data[1].SuspicionReasons)
+	}
+	if data[1].DeviceID != devices[1].ID || data[1].UserID != devices[1].UserID {
+		t.Error("expected device and user IDs to be carried over")
+	}
+
+	// A feed without a producer drops changes rather than failing
+	var feed *DeviceFeed
+	feed.Changed(context.Background(), "device.updated", devices[0], "")
+}
+
+// MockPreferenceRepository is a mock preference store for testing
+type MockPreferenceRepository struct {
+	GetByUserIDFunc func(ctx context.Context, userID uuid.UUID) (*domain.Preference, error)
+	UpsertFunc      func(ctx context.Context, pref *domain.Preference, changedBy, changeSource string, changedFields []string) error
+	GetVersionFunc  func(ctx context.Context, userID uuid.UUID, version int) (*domain.PreferenceVersion, error)
+}
+
+func (m *MockPreferenceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.Preference, error) {
+	if m.GetByUserIDFunc != nil {
+		return m.GetByUserIDFunc(ctx, userID)
+	}
+	return nil, mongodb.ErrPreferenceNotFound
+}
+
+func (m *MockPreferenceRepository) Upsert(ctx context.Context, pref *domain.Preference, changedBy, changeSource string, changedFields []string) error {
+	if m.UpsertFunc != nil {
+		return m.UpsertFunc(ctx, pref, changedBy, changeSource, changedFields)
+	}
+	return nil
+}
+
+func (m *MockPreferenceRepository) GetVersion(ctx context.Context, userID uuid.UUID, version int) (*domain.PreferenceVersion, error) {
+	if m.GetVersionFunc != nil {
+		return m.GetVersionFunc(ctx, userID, version)
+	}
+	return nil, mongodb.ErrPreferenceVersionNotFound
+}
+
+func (m *MockPreferenceRepository) GetAsOf(ctx context.Context, userID uuid.UUID, at time.Time) (*domain.PreferenceVersion, error) {
+	return nil, mongodb.ErrPreferenceVersionNotFound
+}
+
+func (m *MockPreferenceRepository) ListVersions(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.PreferenceVersion, error) {
+	return []*domain.PreferenceVersion{}, nil
+}
+
+func TestPreferenceService_LoadAndSave(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	s := &PreferenceService{prefRepo: &MockPreferenceRepository{}}
+	pref, err := s.loadForUpdate(ctx, userID)
+	if err != nil {
+		t.Fatalf("missing preferences should start from defaults, got %v", err)
+	}
+	if pref.UserID != userID {
+		t.Errorf("expected defaults for %s, got %s", userID, pref.UserID)
+	}
+
+	storeDown := errors.New("connection refused")
+	s = &PreferenceService{prefRepo: &MockPreferenceRepository{
+		GetByUserIDFunc: func(ctx context.Context, userID uuid.UUID) (*domain.Preference, error) {
+			return nil, storeDown
+		},
+	}}
+	if _, err := s.loadForUpdate(ctx, userID); !errors.Is(err, storeDown) {
+		t.Errorf("updates must not start from defaults when the store fails, got %v", err)
+	}
+
+	s = &PreferenceService{prefRepo: &MockPreferenceRepository{
+		UpsertFunc: func(ctx context.Context, pref *domain.Preference, changedBy, changeSource string, changedFields []string) error {
+			return mongodb.ErrOptimisticLock
+		},
+	}}
+	if err := s.save(ctx, domain.DefaultPreference(userID), userID.String(), []string{"theme"}); err != ErrOptimisticLock {
+		t.Errorf("expected ErrOptimisticLock, got %v", err)
+	}
+}
+
+func TestDiffPreferenceVersions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	v1 := domain.DefaultPreference(userID)
+	v1.Version = 1
+	v2 := domain.DefaultPreference(userID)
+	v2.Version = 2
+	v2.UpdatedAt = time.Now()
+	v2.UXPreferences.Theme = domain.ThemeDark
+	fraud := v2.NotificationSettings[domain.NotificationFraudAlert]
+	fraud.Channels = []domain.NotificationChannel{domain.ChannelSMS}
+	v2.NotificationSettings[domain.NotificationFraudAlert] = fraud
+
+	versions := map[int]*domain.Preference{1: v1, 2: v2}
+	s := &PreferenceService{prefRepo: &MockPreferenceRepository{
+		GetVersionFunc: func(ctx context.Context, userID uuid.UUID, version int) (*domain.PreferenceVersion, error) {
+			pref, ok := versions[version]
+			if !ok {
+				return nil, mongodb.ErrPreferenceVersionNotFound
+			}
+			return &domain.PreferenceVersion{UserID: userID, Version: version, Preference: pref}, nil
+		},
+	}}
+
+	diff, err := s.DiffPreferenceVersions(ctx, userID, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Version and timestamp bookkeeping is not reported as a change
+	want := []string{"notification_settings.FRAUD_ALERT.channels", "ux_preferences.theme"}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("expected changes %v, got %+v", want, diff.Changes)
+	}
+	for i, field := range want {
+		if diff.Changes[i].Field != field {
+			t.Errorf("expected change %d to be %s, got %s", i, field, diff.Changes[i].Field)
+		}
+	}
+	if diff.Changes[1].From != string(domain.ThemeSystem) || diff.Changes[1].To != string(domain.ThemeDark) {
+		t.Errorf("expected theme SYSTEM -> DARK, got %v -> %v", diff.Changes[1].From, diff.Changes[1].To)
+	}
+
+	if _, err := s.DiffPreferenceVersions(ctx, userID, 1, 3); err != ErrPreferenceVersionNotFound {
+		t.Errorf("expected ErrPreferenceVersionNotFound, got %v", err)
+	}
+}
+
+func TestQuietHours_NextAllowed(t *testing.T) {
+	overnight := &domain.QuietHours{Enabled: true, StartHour: 22, EndHour: 7, Timezone: "America/New_York"}
+	daytime := &domain.QuietHours{Enabled: true, StartHour: 9, EndHour: 17}
+
+	testCases := []struct {
+		name            string
+		quiet           *domain.QuietHours
+		at              string
+		wantDeliverable bool
+		wantNext        string
+	}{
+		{"overnight before start", overnight, "2026-03-02T02:30:00Z", true, ""},                           // 21:30 EST
+		{"overnight after start", overnight, "2026-03-02T03:30:00Z", false, "2026-03-02T12:00:00Z"},       // 22:30 EST
+		{"overnight past midnight", overnight, "2026-03-02T08:00:00Z", false, "2026-03-02T12:00:00Z"},     // 03:00 EST
+		{"overnight at end", overnight, "2026-03-02T12:00:00Z", true, ""},                                 // 07:00 EST
+		{"overnight across dst change", overnight, "2026-03-08T03:30:00Z", false, "2026-03-08T11:00:00Z"}, // 22:30 EST, ends 07:00 EDT
+		{"daytime uses fallback zone", daytime, "2026-03-02T10:00:00Z", false, "2026-03-02T17:00:00Z"},
+		{"daytime before start", daytime, "2026-03-02T08:59:00Z", true, ""},
+		{"disabled", &domain.QuietHours{StartHour: 22, EndHour: 7}, "2026-03-02T23:00:00Z", true, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			at, _ := time.Parse(time.RFC3339, tc.at)
+			deliverable, next := tc.quiet.NextAllowed(at, "UTC")
+			if deliverable != tc.wantDeliverable {
+				t.Fatalf("expected deliverable %v, got %v", tc.wantDeliverable, deliverable)
+			}
+			if !deliverable && next.Format(time.RFC3339) != tc.wantNext {
+				t.Errorf("expected next allowed %s, got %s", tc.wantNext, next.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestToNotificationSummary_SecurityIgnoresQuietHours(t *testing.T) {
+	pref := domain.DefaultPreference(uuid.New())
+	quiet := &domain.QuietHours{Enabled: true, StartHour: 0, EndHour: 23}
+	for _, notifType := range []domain.NotificationType{domain.NotificationFraudAlert, domain.NotificationTransactionAlert} {
+		setting := pref.NotificationSettings[notifType]
+		setting.Quiet = quiet
+		pref.NotificationSettings[notifType] = setting
+	}
+
+	summary := pref.ToNotificationSummary(time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	if !summary.Delivery[domain.NotificationFraudAlert].DeliverableNow {
+		t.Error("security notifications must be deliverable during quiet hours")
+	}
+	if summary.Delivery[domain.NotificationTransactionAlert].DeliverableNow {
+		t.Error("transaction alerts should be held during quiet hours")
+	}
+	if _, ok := summary.Delivery[domain.NotificationMarketingEmail]; ok {
+		t.Error("disabled notification types should not be listed")
+	}
+}
+
+func TestEvaluateFeatureFlags(t *testing.T) {
+	subject := domain.FlagSubject{
+		UserID:    uuid.MustParse("6f1c2b7e-3d4a-4e5f-9a8b-0c1d2e3f4a5b"),
+		Country:   "GB",
+		KYCStatus: domain.KYCStatusApproved,
+		DeviceOS:  domain.DeviceOSiOS,
+	}
+	bucket := domain.RolloutBucket("instant_transfers", subject.UserID)
+	if bucket != domain.RolloutBucket("instant_transfers", subject.UserID) {
+		t.Fatal("rollout bucket must be stable for a user")
+	}
+
+	testCases := []struct {
+		name       string
+		flag       domain.FeatureFlag
+		wantOn     bool
+		wantReason domain.FlagReason
+	}{
+		{"disabled", domain.FeatureFlag{RolloutPercent: 100}, false, domain.FlagReasonDisabled},
+		{"full rollout", domain.FeatureFlag{Enabled: true, RolloutPercent: 100}, true, domain.FlagReasonRollout},
+		{"no rollout", domain.FeatureFlag{Enabled: true}, false, domain.FlagReasonOutsideRollout},
+		{"just inside rollout", domain.FeatureFlag{Enabled: true, RolloutPercent: bucket + 1}, true, domain.FlagReasonRollout},
+		{"just outside rollout", domain.FeatureFlag{Enabled: true, RolloutPercent: bucket}, false, domain.FlagReasonOutsideRollout},
+		{"country targeted", domain.FeatureFlag{Enabled: true, RolloutPercent: 100, Targeting: domain.FlagTargeting{
+			Countries: []string{"GB", "IE"},
+		}}, true, domain.FlagReasonRollout},
+		{"country not targeted", domain.FeatureFlag{Enabled: true, RolloutPercent: 100, Targeting: domain.FlagTargeting{
+			Countries: []string{"US"},
+		}}, false, domain.FlagReasonNotTargeted},
+		{"kyc and os must both match", domain.FeatureFlag{Enabled: true, RolloutPercent: 100, Targeting: domain.FlagTargeting{
+			KYCStatuses: []domain.KYCStatus{domain.KYCStatusApproved},
+			DeviceOS:    []domain.DeviceOS{domain.DeviceOSAndroid},
+		}}, false, domain.FlagReasonNotTargeted},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.flag.Key = "instant_transfers"
+			got := tc.flag.Evaluate(subject)
+			if got.Enabled != tc.wantOn || got.Reason != tc.wantReason {
+				t.Errorf("expected %v/%s, got %v/%s", tc.wantOn, tc.wantReason, got.Enabled, got.Reason)
+			}
+		})
+	}
+
+	// Overrides win over definitions and are served for undefined flags too
+	defs := []*domain.FeatureFlag{{Key: "instant_transfers", Enabled: true, RolloutPercent: 100}}
+	flags := domain.EvaluateFeatureFlags(defs, map[string]bool{"instant_transfers": false, "beta_ui": true}, subject)
+	if flags["instant_transfers"] != (domain.EvaluatedFlag{Enabled: false, Reason: domain.FlagReasonOverride}) {
+		t.Errorf("expected override to disable instant_transfers, got %+v", flags["instant_transfers"])
+	}
+	if !flags["beta_ui"].Enabled {
+		t.Error("expected override of an undefined flag to be served")
+	}
+}
+
+func TestNewConsentRecord(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		latest  *domain.ConsentEvent
+		pending *time.Time
+		want    domain.ConsentStatus
+	}{
+		{"never recorded", nil, nil, domain.ConsentStatusNotGranted},
+		{"granted", &domain.ConsentEvent{Action: domain.ConsentActionGranted, CreatedAt: now}, nil, domain.ConsentStatusGranted},
+		{"requested with live link", &domain.ConsentEvent{Action: domain.ConsentActionRequested, CreatedAt: now}, &expiresAt, domain.ConsentStatusPending},
+		{"requested with lapsed link", &domain.ConsentEvent{Action: domain.ConsentActionRequested, CreatedAt: now}, nil, domain.ConsentStatusNotGranted},
+		{"withdrawn", &domain.ConsentEvent{Action: domain.ConsentActionWithdrawn, CreatedAt: now}, nil, domain.ConsentStatusNotGranted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := domain.NewConsentRecord(domain.ConsentMarketingEmail, tt.latest, tt.pending)
+			if record.Status != tt.want {
+				t.Errorf("expected status %s, got %s", tt.want, record.Status)
+			}
+		})
+	}
+}
+
+func TestPreferenceService_ConsentGovernedNotifications(t *testing.T) {
+	pref := domain.DefaultPreference(uuid.New())
+
+	pref.ApplyConsent(map[domain.ConsentPurpose]bool{})
+	if pref.NotificationSettings[domain.NotificationMarketingEmail].Enabled {
+		t.Error("expected marketing email disabled without consent")
+	}
+	pref.ApplyConsent(map[domain.ConsentPurpose]bool{domain.ConsentMarketingEmail: true})
+	if !pref.NotificationSettings[domain.NotificationMarketingEmail].Enabled {
+		t.Error("expected marketing email enabled with consent")
+	}
+
+	// The toggle cannot override the consent ledger
+	s := &PreferenceService{prefRepo: &MockPreferenceRepository{}}
+	enabled := true
+	_, err := s.UpdateNotificationSettings(context.Background(), uuid.New(), &domain.UpdateNotificationRequest{
+		Type:    domain.NotificationMarketingEmail,
+		Enabled: &enabled,
+	}, "", "")
+	if err != ErrConsentManaged {
+		t.Errorf("expected ErrConsentManaged, got %v", err)
+	}
+}
+
+func TestPreferenceService_CustomSettings(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	stored := domain.DefaultPreference(userID)
+	stored.CustomSettings = map[string]interface{}{
+		"dashboard": map[string]interface{}{"widget_order": []interface{}{"CARDS"}},
+	}
+	stored.CustomSettingsVersions = map[string]int{"dashboard": 1}
+
+	s := &PreferenceService{
+		customSettings: customsettings.Default(),
+		prefRepo: &MockPreferenceRepository{
+			GetByUserIDFunc: func(ctx context.Context, userID uuid.UUID) (*domain.Preference, error) {
+				return stored, nil
+			},
+		},
+	}
+
+	if _, err := s.UpdateCustomSettings(ctx, userID, "ads", map[string]interface{}{}, "", ""); err != ErrCustomSettingsNamespace {
+		t.Errorf("expected ErrCustomSettingsNamespace, got %v", err)
+	}
+
+	var invalid *customsettings.ValidationError
+	_, err := s.UpdateCustomSettings(ctx, userID, "dashboard", map[string]interface{}{"colour": "red"}, "", "")
+	if !errors.As(err, &invalid) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+
+	// Documents written under an older schema version are read in the current one
+	doc, err := s.GetCustomSettings(ctx, userID, "dashboard")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.SchemaVersion != 2 {
+		t.Errorf("expected schema version 2, got %d", doc.SchemaVersion)
+	}
+	widgets, _ := doc.Settings["widgets"].([]interface{})
+	if len(widgets) != 1 {
+		t.Fatalf("expected the widget order to become widgets, got %v", doc.Settings)
+	}
+}
+
+func TestDeviceService_EvictionsAreAuditedAndPublished(t *testing.T) {
+	log, err := logger.New(logger.Config{Level: "error", OutputPath: "stderr"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	cb := resilience.NewCircuitBreaker(resilience.DefaultSettings("kafka"))
+
+	userID := uuid.New()
+	device := &domain.Device{ID: uuid.New(), UserID: userID}
+	// The repository returns evicted devices least recently active first
+	evicted := []uuid.UUID{uuid.New(), uuid.New()}
+
+	auditConfig := sarama.NewConfig()
+	auditConfig.Producer.Return.Successes = true
+	auditClient := mocks.NewAsyncProducer(t, auditConfig)
+	var audited []audit.AuditEvent
+	for range evicted {
+		auditClient.ExpectInputWithCheckerFunctionAndSucceed(func(val []byte) error {
+			var event audit.AuditEvent
+			if err := json.Unmarshal(val, &event); err != nil {
+				return err
+			}
+			audited = append(audited, event)
+			return nil
+		})
+	}
+
+	feedClient := mocks.NewAsyncProducer(t, nil)
+	var published []events.UserEvent
+	for i := 0; i < 1+len(evicted); i++ {
+		feedClient.ExpectInputWithCheckerFunctionAndSucceed(func(val []byte) error {
+			var event events.UserEvent
+			data := &events.DeviceChangedEvent{}
+			event.Data = data
+			if err := json.Unmarshal(val, &event); err != nil {
+				return err
+			}
+			published = append(published, event)
+			return nil
+		})
+	}
+
+	auditProducer := events.NewAuditProducerWithClient(auditClient, "audit", 10, cb, nil, log)
+	feedProducer := events.NewEventProducerWithClient(feedClient, "devices", cb, log)
+	s := &DeviceService{
+		feed:          NewDeviceFeed(feedProducer, log),
+		auditProducer: auditProducer,
+		log:           log,
+		hmacSecret:    []byte("secret"),
+	}
+
+	res := &postgres.DeviceUpsert{Device: device, Created: true, Evicted: evicted}
+	s.auditEvictions(context.Background(), userID, res.Evicted, "203.0.113.7", "req-1")
+	s.publishUpsert(context.Background(), res)
+
+	// Closing waits for the mocks to see every message and fails the test on missing ones
+	if err := auditProducer.Close(); err != nil {
+		t.Fatalf("failed to close audit producer: %v", err)
+	}
+	if err := feedProducer.Close(); err != nil {
+		t.Fatalf("failed to close device feed producer: %v", err)
+	}
+
+	if len(audited) != len(evicted) {
+		t.Fatalf("expected %d audit events, got %d", len(evicted), len(audited))
+	}
+	for i, id := range evicted {
+		e := audited[i]
+		if e.Action != audit.ActionDelete || e.Resource != audit.ResourceDevice || e.ResourceID != id.String() {
+			t.Errorf("audit event %d: expected DELETE of device %s, got %s of %s %s", i, id, e.Action, e.Resource, e.ResourceID)
+		}
+		if e.UserID != userID.String() {
+			t.Errorf("audit event %d: expected user %s, got %s", i, userID, e.UserID)
+		}
+	}
+
+	if len(published) != 1+len(evicted) {
+		t.Fatalf("expected %d device events, got %d", 1+len(evicted), len(published))
+	}
+	if published[0].EventType != events.EventDeviceRegistered || published[0].Data.(*events.DeviceChangedEvent).DeviceID != device.ID {
+		t.Errorf("expected the registered device first, got %s for %v", published[0].EventType, published[0].Data)
+	}
+	for i, id := range evicted {
+		e := published[1+i]
+		data := e.Data.(*events.DeviceChangedEvent)
+		if e.EventType != events.EventDeviceRemoved || data.DeviceID != id || data.Reason != domain.DeviceRemovedEvicted {
+			t.Errorf("device event %d: expected %s of %s (%s), got %s of %s (%s)", 1+i, events.EventDeviceRemoved, id, domain.DeviceRemovedEvicted, e.EventType, data.DeviceID, data.Reason)
+		}
+		if e.UserID != userID.String() {
+			t.Errorf("device event %d: expected user %s, got %s", 1+i, userID, e.UserID)
+		}
+	}
+}
+
+func TestDeviceService_NoEvictionsNoRemovals(t *testing.T) {
+	log, err := logger.New(logger.Config{Level: "error", OutputPath: "stderr"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	cb := resilience.NewCircuitBreaker(resilience.DefaultSettings("kafka"))
+
+	auditConfig := sarama.NewConfig()
+	auditConfig.Producer.Return.Successes = true
+	// No expectations: any audit event fails the test
+	auditClient := mocks.NewAsyncProducer(t, auditConfig)
+	feedClient := mocks.NewAsyncProducer(t, nil)
+	feedClient.ExpectInputAndSucceed()
+
+	auditProducer := events.NewAuditProducerWithClient(auditClient, "audit", 10, cb, nil, log)
+	feedProducer := events.NewEventProducerWithClient(feedClient, "devices", cb, log)
+	s := &DeviceService{
+		feed:          NewDeviceFeed(feedProducer, log),
+		auditProducer: auditProducer,
+		log:           log,
+		hmacSecret:    []byte("secret"),
+	}
+
+	userID := uuid.New()
+	res := &postgres.DeviceUpsert{Device: &domain.Device{ID: uuid.New(), UserID: userID}}
+	s.auditEvictions(context.Background(), userID, res.Evicted, "203.0.113.7", "req-1")
+	s.publishUpsert(context.Background(), res)
+
+	auditProducer.Close()
+	feedProducer.Close()
+}
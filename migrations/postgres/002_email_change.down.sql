@@ -0,0 +1,10 @@
+-- Banking User Service: Rollback Email Change Verification
+-- Migration: 002_email_change.down.sql
+
+DROP INDEX IF EXISTS idx_users_pending_email_hash;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email_change_expires_at,
+    DROP COLUMN IF EXISTS email_change_token_hash,
+    DROP COLUMN IF EXISTS pending_email_hash,
+    DROP COLUMN IF EXISTS pending_email_encrypted;
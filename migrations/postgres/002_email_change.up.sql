@@ -0,0 +1,15 @@
+-- Banking User Service: Email Change Verification
+-- Migration: 002_email_change.up.sql
+-- Pending email is stored encrypted; the one-time token is stored as an HMAC hash only
+
+ALTER TABLE users
+    ADD COLUMN pending_email_encrypted BYTEA,
+    ADD COLUMN pending_email_hash VARCHAR(64),
+    ADD COLUMN email_change_token_hash VARCHAR(64),
+    ADD COLUMN email_change_expires_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_pending_email_hash ON users(pending_email_hash)
+    WHERE pending_email_hash IS NOT NULL;
+
+COMMENT ON COLUMN users.pending_email_encrypted IS 'New email awaiting verification, swapped into email_encrypted on confirmation';
+COMMENT ON COLUMN users.email_change_token_hash IS 'HMAC of the one-time verification token, never the raw token';
@@ -0,0 +1,5 @@
+-- Banking User Service: Rollback Phone Verification
+-- Migration: 003_phone_verification.down.sql
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS phone_verified_at;
@@ -0,0 +1,8 @@
+-- Banking User Service: Phone Verification
+-- Migration: 003_phone_verification.up.sql
+-- OTP codes live in Redis; only the verification timestamp is persisted
+
+ALTER TABLE users
+    ADD COLUMN phone_verified_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN users.phone_verified_at IS 'Set when the current phone number passed OTP verification, cleared when the number changes';
@@ -0,0 +1,12 @@
+-- Banking User Service: Rollback Account Status Lifecycle
+-- Migration: 004_status_lifecycle.down.sql
+-- LOCKED and CLOSED accounts fall back to SUSPENDED so the original constraint holds
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS status_reason;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_check;
+UPDATE users SET status = 'SUSPENDED' WHERE status IN ('LOCKED', 'CLOSED');
+ALTER TABLE users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('ACTIVE', 'SUSPENDED', 'PENDING', 'DELETED'));
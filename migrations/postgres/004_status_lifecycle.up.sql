@@ -0,0 +1,13 @@
+-- Banking User Service: Account Status Lifecycle
+-- Migration: 004_status_lifecycle.up.sql
+-- Adds LOCKED and CLOSED statuses and records why and when the status last changed
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_check;
+ALTER TABLE users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('ACTIVE', 'SUSPENDED', 'PENDING', 'LOCKED', 'CLOSED', 'DELETED'));
+
+ALTER TABLE users
+    ADD COLUMN status_reason VARCHAR(40),
+    ADD COLUMN status_changed_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN users.status_reason IS 'Coded reason for the last status transition, never free text';
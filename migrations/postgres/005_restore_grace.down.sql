@@ -0,0 +1,9 @@
+-- Banking User Service: Rollback Account Restore
+-- Migration: 005_restore_grace.down.sql
+-- Fails if a deleted and a live account share an email; resolve those rows first
+
+DROP INDEX IF EXISTS idx_devices_user_deleted;
+DROP INDEX IF EXISTS idx_addresses_user_deleted;
+DROP INDEX IF EXISTS idx_users_email_hash_active;
+
+ALTER TABLE users ADD CONSTRAINT users_email_hash_key UNIQUE (email_hash);
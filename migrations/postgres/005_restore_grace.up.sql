@@ -0,0 +1,13 @@
+-- Banking User Service: Account Restore
+-- Migration: 005_restore_grace.up.sql
+-- Email uniqueness now applies to live accounts only, so a deleted account's email can be
+-- reused during its restore grace period; restore re-checks for a conflict
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_hash_key;
+
+CREATE UNIQUE INDEX idx_users_email_hash_active ON users(email_hash)
+    WHERE deleted_at IS NULL;
+
+-- Restore looks up children deleted together with the user
+CREATE INDEX idx_addresses_user_deleted ON addresses(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_devices_user_deleted ON devices(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
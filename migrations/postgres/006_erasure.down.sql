@@ -0,0 +1,9 @@
+-- Banking User Service: Rollback GDPR Erasure
+-- Migration: 006_erasure.down.sql
+-- Erased rows cannot regain their PII; NOT NULL constraints can only be restored
+-- once those tombstones are removed
+
+DROP INDEX IF EXISTS idx_users_erasure_pending;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS erased_at;
@@ -0,0 +1,23 @@
+-- Banking User Service: GDPR Erasure
+-- Migration: 006_erasure.up.sql
+-- PII columns become nullable so erasure can wipe them. Field keys are shared across users,
+-- so erasure overwrites ciphertext instead of destroying a per-user key.
+-- The users row is kept as a non-PII tombstone (id, country, status, timestamps, erased_at).
+
+ALTER TABLE users
+    ALTER COLUMN legal_name_encrypted DROP NOT NULL,
+    ALTER COLUMN email_encrypted DROP NOT NULL,
+    ALTER COLUMN email_hash DROP NOT NULL,
+    ADD COLUMN erased_at TIMESTAMPTZ;
+
+ALTER TABLE addresses
+    ALTER COLUMN address_encrypted DROP NOT NULL;
+
+ALTER TABLE address_history
+    ALTER COLUMN address_encrypted DROP NOT NULL;
+
+-- Erasure job scans deleted accounts that still hold PII
+CREATE INDEX idx_users_erasure_pending ON users(deleted_at)
+    WHERE deleted_at IS NOT NULL AND erased_at IS NULL;
+
+COMMENT ON COLUMN users.erased_at IS 'When PII was wiped by the erasure job; the row remains as a tombstone';
@@ -0,0 +1,6 @@
+-- Banking User Service: Rollback Address History
+-- Migration: 007_address_history.down.sql
+
+DROP INDEX IF EXISTS idx_address_history_version;
+
+ALTER TABLE address_history DROP COLUMN IF EXISTS change_type;
@@ -0,0 +1,12 @@
+-- Banking User Service: Address History
+-- Migration: 007_address_history.up.sql
+-- Every address create, update and delete now writes a snapshot; change_type tells them apart
+
+ALTER TABLE address_history
+    ADD COLUMN change_type VARCHAR(10) NOT NULL DEFAULT 'UPDATE'
+        CHECK (change_type IN ('CREATE', 'UPDATE', 'DELETE'));
+
+ALTER TABLE address_history ALTER COLUMN change_type DROP DEFAULT;
+
+-- One snapshot per address version
+CREATE UNIQUE INDEX idx_address_history_version ON address_history(address_id, version);
@@ -0,0 +1,4 @@
+-- Banking User Service: Rollback Address Validation
+-- Migration: 008_address_validation.down.sql
+
+DROP INDEX IF EXISTS idx_addresses_validation_pending;
@@ -0,0 +1,7 @@
+-- Banking User Service: Address Validation
+-- Migration: 008_address_validation.up.sql
+-- The validation worker polls for PENDING addresses; keep that scan off the main table
+
+CREATE INDEX idx_addresses_validation_pending
+    ON addresses(created_at)
+    WHERE validation_status = 'PENDING' AND deleted_at IS NULL;
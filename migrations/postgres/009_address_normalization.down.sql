@@ -0,0 +1,4 @@
+-- Banking User Service: Rollback Address Normalization
+-- Migration: 009_address_normalization.down.sql
+
+ALTER TABLE address_history DROP COLUMN IF EXISTS raw_input_encrypted;
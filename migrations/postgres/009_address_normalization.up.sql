@@ -0,0 +1,5 @@
+-- Banking User Service: Address Normalization
+-- Migration: 009_address_normalization.up.sql
+-- addresses hold the normalized form; history also keeps what the user actually typed
+
+ALTER TABLE address_history ADD COLUMN raw_input_encrypted BYTEA;
@@ -0,0 +1,11 @@
+-- Banking User Service: Rollback Temporary Addresses
+-- Migration: 010_temporary_addresses.down.sql
+
+DROP INDEX IF EXISTS idx_addresses_temporary_expiry;
+
+ALTER TABLE addresses DROP CONSTRAINT IF EXISTS chk_addresses_validity_window;
+
+ALTER TABLE addresses
+    DROP COLUMN IF EXISTS replaces_address_id,
+    DROP COLUMN IF EXISTS valid_until,
+    DROP COLUMN IF EXISTS valid_from;
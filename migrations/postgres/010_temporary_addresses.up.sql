@@ -0,0 +1,21 @@
+-- Banking User Service: Temporary Addresses
+-- Migration: 010_temporary_addresses.up.sql
+-- Temporary addresses carry a validity window and remember the primary they displaced,
+-- so the expiry job can hand primary status back when the window closes
+
+ALTER TABLE addresses
+    ADD COLUMN valid_from TIMESTAMPTZ,
+    ADD COLUMN valid_until TIMESTAMPTZ,
+    ADD COLUMN replaces_address_id UUID;
+
+-- NOT VALID: temporary addresses created before this migration get a window on their next edit
+ALTER TABLE addresses
+    ADD CONSTRAINT chk_addresses_validity_window CHECK (
+        (address_type = 'TEMPORARY' AND valid_until IS NOT NULL
+            AND (valid_from IS NULL OR valid_from < valid_until))
+        OR (address_type <> 'TEMPORARY' AND valid_from IS NULL AND valid_until IS NULL)
+    ) NOT VALID;
+
+CREATE INDEX idx_addresses_temporary_expiry
+    ON addresses(valid_until)
+    WHERE address_type = 'TEMPORARY' AND deleted_at IS NULL;
@@ -0,0 +1,11 @@
+-- Banking User Service: Rollback Address Change Hold
+-- Migration: 011_address_change_hold.down.sql
+
+DROP INDEX IF EXISTS idx_address_history_user_created;
+
+ALTER TABLE addresses
+    DROP COLUMN IF EXISTS pending_change_expires_at,
+    DROP COLUMN IF EXISTS pending_change_requested_at,
+    DROP COLUMN IF EXISTS pending_change_reasons,
+    DROP COLUMN IF EXISTS pending_change_token_hash,
+    DROP COLUMN IF EXISTS pending_change_encrypted;
@@ -0,0 +1,15 @@
+-- Banking User Service: Address Change Hold
+-- Migration: 011_address_change_hold.up.sql
+-- Risky address edits are parked on the address until the user confirms them with an
+-- out-of-band token; only one change can be pending per address
+
+ALTER TABLE addresses
+    ADD COLUMN pending_change_encrypted BYTEA,
+    ADD COLUMN pending_change_token_hash VARCHAR(64),
+    ADD COLUMN pending_change_reasons TEXT[],
+    ADD COLUMN pending_change_requested_at TIMESTAMPTZ,
+    ADD COLUMN pending_change_expires_at TIMESTAMPTZ;
+
+-- Velocity checks count a user's recent edits
+CREATE INDEX idx_address_history_user_created
+    ON address_history(user_id, created_at);
@@ -0,0 +1,9 @@
+-- Banking User Service: Rollback Device Risk Scoring
+-- Migration: 012_device_risk.down.sql
+
+ALTER TABLE devices
+    DROP COLUMN IF EXISTS scored_at,
+    DROP COLUMN IF EXISTS suspicion_reasons,
+    DROP COLUMN IF EXISTS suspicion_score;
+
+DROP TABLE IF EXISTS device_activity;
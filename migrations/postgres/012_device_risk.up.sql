@@ -0,0 +1,24 @@
+-- Banking User Service: Device Risk Scoring
+-- Migration: 012_device_risk.up.sql
+-- Each registration or recorded heartbeat leaves an activity row with the hashed IP and
+-- hashed network prefix; the suspicion score computed from that history is kept on the device
+
+CREATE TABLE device_activity (
+    id BIGSERIAL PRIMARY KEY,
+    device_id UUID NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_hash VARCHAR(64) NOT NULL,
+    network_hash VARCHAR(64) NOT NULL, -- /24 for IPv4, /48 for IPv6
+    seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_device_activity_user_seen ON device_activity(user_id, seen_at DESC);
+CREATE INDEX idx_device_activity_user_network ON device_activity(user_id, network_hash);
+
+ALTER TABLE devices
+    ADD COLUMN suspicion_score SMALLINT NOT NULL DEFAULT 0
+        CHECK (suspicion_score BETWEEN 0 AND 100),
+    ADD COLUMN suspicion_reasons TEXT[],
+    ADD COLUMN scored_at TIMESTAMPTZ;
+
+COMMENT ON TABLE device_activity IS 'Hashed IP history per device for suspicion scoring';
@@ -0,0 +1,8 @@
+-- Banking User Service: Rollback Device Trust
+-- Migration: 013_device_trust.down.sql
+
+DROP INDEX IF EXISTS idx_devices_trusted;
+
+ALTER TABLE devices
+    DROP COLUMN IF EXISTS trust_changed_at,
+    DROP COLUMN IF EXISTS trusted_until;
@@ -0,0 +1,10 @@
+-- Banking User Service: Device Trust
+-- Migration: 013_device_trust.up.sql
+-- Trust is granted after a step-up and lapses at trusted_until; trust_reason records
+-- why trust was last granted or revoked, at trust_changed_at
+
+ALTER TABLE devices
+    ADD COLUMN trusted_until TIMESTAMPTZ,
+    ADD COLUMN trust_changed_at TIMESTAMPTZ;
+
+CREATE INDEX idx_devices_trusted ON devices(user_id) WHERE is_trusted AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+-- Banking User Service: Rollback Device Fingerprint Lookup
+-- Migration: 014_device_fingerprint_lookup.down.sql
+
+DROP INDEX IF EXISTS idx_devices_fingerprint_hash;
@@ -0,0 +1,6 @@
+-- Banking User Service: Device Fingerprint Lookup
+-- Migration: 014_device_fingerprint_lookup.up.sql
+-- The fraud service looks up every user sharing a fingerprint; the existing
+-- fingerprint index leads with user_id and cannot serve that query
+
+CREATE INDEX idx_devices_fingerprint_hash ON devices(fingerprint_hash) WHERE deleted_at IS NULL;
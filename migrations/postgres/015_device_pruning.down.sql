@@ -0,0 +1,4 @@
+-- Banking User Service: Rollback Stale Device Pruning
+-- Migration: 015_device_pruning.down.sql
+
+DROP INDEX IF EXISTS idx_devices_last_seen;
@@ -0,0 +1,6 @@
+-- Banking User Service: Stale Device Pruning
+-- Migration: 015_device_pruning.up.sql
+-- The pruning job finds devices by last activity, falling back to registration time
+-- for devices that never reported activity
+
+CREATE INDEX idx_devices_last_seen ON devices((COALESCE(last_active_at, created_at))) WHERE deleted_at IS NULL;
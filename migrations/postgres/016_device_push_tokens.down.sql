@@ -0,0 +1,13 @@
+-- Banking User Service: Rollback Device Push Tokens
+-- Migration: 016_device_push_tokens.down.sql
+
+DROP INDEX IF EXISTS idx_devices_push_token_hash;
+
+ALTER TABLE devices
+    DROP CONSTRAINT IF EXISTS chk_devices_push_provider,
+    DROP CONSTRAINT IF EXISTS chk_devices_push_token,
+    DROP COLUMN IF EXISTS push_token_updated_at,
+    DROP COLUMN IF EXISTS push_token_key_version,
+    DROP COLUMN IF EXISTS push_token_hash,
+    DROP COLUMN IF EXISTS push_token_encrypted,
+    DROP COLUMN IF EXISTS push_provider;
@@ -0,0 +1,21 @@
+-- Banking User Service: Device Push Tokens
+-- Migration: 016_device_push_tokens.up.sql
+-- APNs/FCM tokens are stored encrypted on the device they were issued to; the hash
+-- finds a token reported invalid by the provider without decrypting every row.
+-- Tokens are cleared whenever their device is removed
+
+ALTER TABLE devices
+    ADD COLUMN push_provider VARCHAR(10),
+    ADD COLUMN push_token_encrypted BYTEA,
+    ADD COLUMN push_token_hash VARCHAR(64),
+    ADD COLUMN push_token_key_version INT,
+    ADD COLUMN push_token_updated_at TIMESTAMPTZ,
+    ADD CONSTRAINT chk_devices_push_token CHECK (
+        (push_token_hash IS NULL) = (push_token_encrypted IS NULL)
+        AND (push_token_hash IS NULL) = (push_provider IS NULL)
+    ),
+    ADD CONSTRAINT chk_devices_push_provider CHECK (push_provider IN ('APNS', 'FCM'));
+
+-- A token identifies one app install, so it can belong to one active device only
+CREATE UNIQUE INDEX idx_devices_push_token_hash ON devices(push_token_hash)
+    WHERE push_token_hash IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- Banking User Service: Rollback Consent Ledger
+-- Migration: 017_consent_ledger.down.sql
+
+DROP TABLE IF EXISTS consent_confirmations;
+DROP TRIGGER IF EXISTS consent_events_append_only ON consent_events;
+DROP FUNCTION IF EXISTS reject_consent_event_change();
+DROP TABLE IF EXISTS consent_events;
@@ -0,0 +1,50 @@
+-- Banking User Service: Consent Ledger
+-- Migration: 017_consent_ledger.up.sql
+-- Consent is recorded as an append-only ledger of grant and withdraw events per purpose,
+-- with the version of the wording shown and the channel it was given through. Email
+-- marketing needs double opt-in: the grant is only recorded once the emailed link is used
+
+CREATE TABLE consent_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    purpose VARCHAR(32) NOT NULL
+        CHECK (purpose IN ('MARKETING_EMAIL', 'MARKETING_SMS', 'PROFILING', 'DATA_SHARING')),
+    action VARCHAR(16) NOT NULL -- REQUESTED starts a double opt-in
+        CHECK (action IN ('REQUESTED', 'GRANTED', 'WITHDRAWN')),
+    wording_version VARCHAR(64) NOT NULL,
+    channel VARCHAR(16) NOT NULL
+        CHECK (channel IN ('WEB', 'MOBILE', 'EMAIL', 'SUPPORT')),
+    actor_id VARCHAR(100) NOT NULL,
+    ip_hash VARCHAR(64),
+    request_id VARCHAR(64),
+    confirms_event_id UUID REFERENCES consent_events(id), -- The REQUESTED event a double opt-in grant confirms
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_consent_events_user_purpose ON consent_events(user_id, purpose, created_at DESC);
+
+-- Evidence is never edited or removed
+CREATE OR REPLACE FUNCTION reject_consent_event_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'consent_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER consent_events_append_only
+    BEFORE UPDATE OR DELETE ON consent_events
+    FOR EACH ROW EXECUTE FUNCTION reject_consent_event_change();
+
+-- Outstanding double opt-in links; a new request for the purpose replaces the old link
+CREATE TABLE consent_confirmations (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    request_event_id UUID NOT NULL REFERENCES consent_events(id),
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, purpose)
+);
+
+CREATE UNIQUE INDEX idx_consent_confirmations_token ON consent_confirmations(token_hash);
+
+COMMENT ON TABLE consent_events IS 'Append-only consent evidence per user and purpose';